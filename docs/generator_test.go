@@ -20,8 +20,9 @@ var testTables = []*schema.Table{
 		Description: "Description for test table",
 		Columns: []schema.Column{
 			{
-				Name: "int_col",
-				Type: arrow.PrimitiveTypes.Int64,
+				Name:        "int_col",
+				Type:        arrow.PrimitiveTypes.Int64,
+				Description: "Description for int column",
 			},
 			{
 				Name:       "id_col",
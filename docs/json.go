@@ -20,6 +20,7 @@ type jsonTable struct {
 type jsonColumn struct {
 	Name             string `json:"name"`
 	Type             string `json:"type"`
+	Description      string `json:"description,omitempty"`
 	IsPrimaryKey     bool   `json:"is_primary_key,omitempty"`
 	IsIncrementalKey bool   `json:"is_incremental_key,omitempty"`
 }
@@ -46,6 +47,7 @@ func (g *Generator) jsonifyTables(tables schema.Tables) []jsonTable {
 			jsonColumns[c] = jsonColumn{
 				Name:             col.Name,
 				Type:             col.Type.String(),
+				Description:      col.Description,
 				IsPrimaryKey:     col.PrimaryKey,
 				IsIncrementalKey: col.IncrementalKey,
 			}
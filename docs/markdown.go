@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/cloudquery/plugin-sdk/v4/schema"
@@ -57,7 +58,9 @@ func (g *Generator) renderAllTables(dir string, t *schema.Table) error {
 
 func (g *Generator) renderTable(dir string, table *schema.Table) error {
 	t := template.New("").Funcs(map[string]any{
-		"title": g.titleTransformer,
+		"title":           g.titleTransformer,
+		"tableCell":       tableCell,
+		"hasDescriptions": hasDescriptions,
 	})
 	t, err := t.New("table.md.go.tpl").ParseFS(templatesFS, "templates/table.md.go.tpl")
 	if err != nil {
@@ -85,6 +88,22 @@ func formatMarkdown(s string) string {
 	return reMatchHeaders.ReplaceAllString(s, `$1`+"\n\n")
 }
 
+// tableCell escapes s so it can be used as a markdown table cell
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// hasDescriptions returns true if any of the columns has a description
+func hasDescriptions(columns schema.ColumnList) bool {
+	for _, c := range columns {
+		if c.Description != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func indentToDepth(table *schema.Table) string {
 	s := ""
 	t := table
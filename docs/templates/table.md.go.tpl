@@ -37,8 +37,16 @@ The following tables depend on {{.Name}}:
 {{- end }}
 
 ## Columns
+{{ if hasDescriptions $.Columns -}}
+| Name          | Type          | Description   |
+| ------------- | ------------- | ------------- |
+{{- range $column := $.Columns }}
+|{{$column.Name}}{{if $column.PrimaryKey}} (PK){{end}}{{if $column.IncrementalKey}} (Incremental Key){{end}}|`{{$column.Type}}`|{{tableCell $column.Description}}|
+{{- end }}
+{{- else -}}
 | Name          | Type          |
 | ------------- | ------------- |
 {{- range $column := $.Columns }}
 |{{$column.Name}}{{if $column.PrimaryKey}} (PK){{end}}{{if $column.IncrementalKey}} (Incremental Key){{end}}|`{{$column.Type}}`|
+{{- end }}
 {{- end }}
\ No newline at end of file
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
 	"github.com/rs/zerolog"
 )
 
@@ -26,6 +27,13 @@ func (f faker) getFakedValue(a any) (reflect.Value, error) {
 	if f.maxDepth < 0 {
 		return reflect.Value{}, fmt.Errorf("max_depth reached")
 	}
+	if ext, ok := scalar.ExtensionForGoType(t); ok && ext.Fake != nil {
+		v := reflect.ValueOf(ext.Fake())
+		if !v.IsValid() || !v.Type().ConvertibleTo(t) {
+			return reflect.Value{}, fmt.Errorf("fake value for extension %s is not convertible to %s", ext.Type.ExtensionName(), t)
+		}
+		return v.Convert(t), nil
+	}
 	k := t.Kind()
 	switch k {
 	case reflect.Ptr:
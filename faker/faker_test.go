@@ -3,9 +3,13 @@ package faker
 import (
 	"encoding/json"
 	"net"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -81,3 +85,65 @@ func TestFakerCanFakeNetIP(t *testing.T) {
 	assert.NotEmpty(t, a.NestedComplex.IPAddress)
 	assert.Equal(t, "1.1.1.1", a.NestedComplex.IPAddress.String())
 }
+
+type cidr string
+
+type cidrArray struct {
+	array.ExtensionArrayBase
+}
+
+type cidrType struct {
+	arrow.ExtensionBase
+}
+
+func (*cidrType) ArrayType() reflect.Type { return reflect.TypeOf(cidrArray{}) }
+
+func (*cidrType) ExtensionName() string { return "test.cidr" }
+
+func (*cidrType) Serialize() string { return "test.cidr" }
+
+func (*cidrType) Deserialize(storage arrow.DataType, _ string) (arrow.ExtensionType, error) {
+	return &cidrType{ExtensionBase: arrow.ExtensionBase{Storage: storage}}, nil
+}
+
+func (e *cidrType) ExtensionEquals(other arrow.ExtensionType) bool {
+	return e.ExtensionName() == other.ExtensionName()
+}
+
+type cidrScalar struct {
+	scalar.Binary
+}
+
+func (*cidrScalar) DataType() arrow.DataType {
+	return &cidrType{ExtensionBase: arrow.ExtensionBase{Storage: arrow.BinaryTypes.Binary}}
+}
+
+type fakerStructWithExtension struct {
+	A cidr
+	B *cidr
+	C []cidr
+}
+
+func TestFakerWithExtension(t *testing.T) {
+	if err := scalar.RegisterExtension(scalar.Extension{
+		Type:   &cidrType{ExtensionBase: arrow.ExtensionBase{Storage: arrow.BinaryTypes.Binary}},
+		New:    func() scalar.Scalar { return &cidrScalar{} },
+		GoType: reflect.TypeOf(cidr("")),
+		Fake:   func() any { return "10.0.0.0/8" },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := scalar.UnregisterExtension("test.cidr"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	a := fakerStructWithExtension{}
+	if err := FakeObject(&a); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, cidr("10.0.0.0/8"), a.A)
+	assert.Equal(t, cidr("10.0.0.0/8"), *a.B)
+	assert.Equal(t, []cidr{"10.0.0.0/8"}, a.C)
+}
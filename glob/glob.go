@@ -1,28 +1,82 @@
 package glob
 
-import "strings"
+import (
+	"strings"
+	"unicode/utf8"
+)
 
 // The character which is treated like a glob
 const GLOB = "*"
 
+// NEGATE is the prefix that inverts the result of a pattern.
+const NEGATE = "!"
+
+// IncludeTable reports whether name is selected by tables (see Match) and not by skipTables (see Skipped).
 func IncludeTable(name string, tables []string, skipTables []string) bool {
-	for _, t := range skipTables {
-		if Glob(t, name) {
-			return false
-		}
+	if Skipped(skipTables, name) {
+		return false
 	}
-	for _, t := range tables {
-		if Glob(t, name) {
-			return true
+	return Match(tables, name)
+}
+
+// Skipped reports whether subj is selected by a list of skip patterns. Unlike Match, negated patterns only exempt
+// subjects matched by the positive patterns of the list, so a list with only negated patterns skips nothing.
+func Skipped(patterns []string, subj string) bool {
+	for _, p := range patterns {
+		if !IsNegated(p) {
+			return Match(patterns, subj)
 		}
 	}
 	return false
 }
 
+// Match reports whether subj is selected by a list of patterns.
+// A subject is selected when it matches at least one positive pattern and none of the
+// negated (prefixed with "!") patterns. If the list only contains negated patterns,
+// every subject that none of them exclude is selected.
+func Match(patterns []string, subj string) bool {
+	matched, hasPositive := false, false
+	for _, p := range patterns {
+		if IsNegated(p) {
+			if Glob(p[len(NEGATE):], subj) {
+				return false
+			}
+			continue
+		}
+		hasPositive = true
+		if !matched && Glob(p, subj) {
+			matched = true
+		}
+	}
+	if !hasPositive {
+		return len(patterns) > 0
+	}
+	return matched
+}
+
+// IsNegated reports whether the pattern starts with the negation prefix.
+func IsNegated(pattern string) bool {
+	return strings.HasPrefix(pattern, NEGATE)
+}
+
 // Glob will test a string pattern, potentially containing globs, against a
 // subject string. The result is a simple true/false, determining whether or
 // not the glob pattern matched the subject text.
+//
+// The following syntax is supported:
+//
+//	?         matches any single character
+//	*         matches any sequence of characters (** is the same as *)
+//	[abc]     matches one character from the set; ranges like [a-z] are allowed
+//	[!abc]    matches one character not in the set ([^abc] is also accepted)
+//	{a,b}     matches any of the comma-separated alternatives, which may nest
+//	\x        matches the character x literally
+//	!pattern  a leading ! inverts the result of the rest of the pattern
 func Glob(pattern, subj string) bool {
+	if IsNegated(pattern) {
+		return !Glob(pattern[len(NEGATE):], subj)
+	}
+
 	// Empty pattern can only match empty subject
 	if pattern == "" {
 		return subj == pattern
@@ -33,38 +87,212 @@ func Glob(pattern, subj string) bool {
 		return true
 	}
 
-	parts := strings.Split(pattern, GLOB)
-
-	if len(parts) == 1 {
-		// No globs in pattern, so test for equality
+	// Fast path: plain patterns are tested for equality.
+	if !strings.ContainsAny(pattern, "*?[{\\") {
 		return subj == pattern
 	}
 
-	leadingGlob := strings.HasPrefix(pattern, GLOB)
-	trailingGlob := strings.HasSuffix(pattern, GLOB)
-	end := len(parts) - 1
+	for _, p := range expandBraces(pattern) {
+		if matchTokens(tokenize(p), subj) {
+			return true
+		}
+	}
+	return false
+}
+
+type tokenKind int
 
-	// Go over the leading parts and ensure they match.
-	for i := 0; i < end; i++ {
-		idx := strings.Index(subj, parts[i])
+const (
+	tokenLiteral tokenKind = iota
+	tokenAny
+	tokenStar
+	tokenClass
+)
 
-		switch i {
-		case 0:
-			// Check the first section. Requires special handling.
-			if !leadingGlob && idx != 0 {
-				return false
+type token struct {
+	kind    tokenKind
+	r       rune
+	class   []classRange
+	negated bool
+}
+
+type classRange struct {
+	lo, hi rune
+}
+
+func (t token) matches(r rune) bool {
+	switch t.kind {
+	case tokenLiteral:
+		return t.r == r
+	case tokenAny:
+		return true
+	case tokenClass:
+		for _, c := range t.class {
+			if c.lo <= r && r <= c.hi {
+				return !t.negated
 			}
+		}
+		return t.negated
+	default:
+		return false
+	}
+}
+
+// tokenize splits a brace-free pattern into tokens, collapsing runs of stars.
+// A '[' without a closing ']' is treated as a literal character.
+func tokenize(pattern string) []token {
+	var tokens []token
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		switch r {
+		case '*':
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != tokenStar {
+				tokens = append(tokens, token{kind: tokenStar})
+			}
+			i += size
+		case '?':
+			tokens = append(tokens, token{kind: tokenAny})
+			i += size
+		case '[':
+			if t, n, ok := parseClass(pattern[i+size:]); ok {
+				tokens = append(tokens, t)
+				i += size + n
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenLiteral, r: r})
+			i += size
+		case '\\':
+			i += size
+			if i < len(pattern) {
+				r, size = utf8.DecodeRuneInString(pattern[i:])
+			}
+			tokens = append(tokens, token{kind: tokenLiteral, r: r})
+			i += size
 		default:
-			// Check that the middle parts match.
-			if idx < 0 {
-				return false
+			tokens = append(tokens, token{kind: tokenLiteral, r: r})
+			i += size
+		}
+	}
+	return tokens
+}
+
+// parseClass parses a character class body (everything after the opening '[').
+// It returns the token, the number of bytes consumed including the closing ']' and
+// whether a valid class was found.
+func parseClass(s string) (token, int, bool) {
+	t := token{kind: tokenClass}
+	i := 0
+	if i < len(s) && (s[i] == '!' || s[i] == '^') {
+		t.negated = true
+		i++
+	}
+	first := true
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == ']' && !first {
+			return t, i + size, true
+		}
+		first = false
+		if r == '\\' && i+size < len(s) {
+			i += size
+			r, size = utf8.DecodeRuneInString(s[i:])
+		}
+		i += size
+		lo, hi := r, r
+		if i+1 < len(s) && s[i] == '-' && s[i+1] != ']' {
+			hi, size = utf8.DecodeRuneInString(s[i+1:])
+			i += 1 + size
+			if hi < lo {
+				lo, hi = hi, lo
+			}
+		}
+		t.class = append(t.class, classRange{lo: lo, hi: hi})
+	}
+	return token{}, 0, false
+}
+
+// matchTokens matches the subject against the tokens, backtracking only to the
+// most recent star, which keeps matching linear for typical patterns.
+func matchTokens(tokens []token, subj string) bool {
+	s := []rune(subj)
+	px, sx := 0, 0
+	nextPx, nextSx := -1, -1
+	for px < len(tokens) || sx < len(s) {
+		if px < len(tokens) {
+			t := tokens[px]
+			if t.kind == tokenStar {
+				nextPx, nextSx = px, sx+1
+				px++
+				continue
+			}
+			if sx < len(s) && t.matches(s[sx]) {
+				px++
+				sx++
+				continue
 			}
 		}
+		if nextPx >= 0 && nextSx <= len(s) {
+			px, sx = nextPx, nextSx
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// expandBraces expands the first top-level brace group of the pattern into its
+// alternatives, recursively. Patterns without a valid brace group are returned as is.
+func expandBraces(pattern string) []string {
+	start, end, alternatives := findBraces(pattern)
+	if start < 0 {
+		return []string{pattern}
+	}
+	prefix, suffix := pattern[:start], pattern[end+1:]
+	var expanded []string
+	for _, alt := range alternatives {
+		expanded = append(expanded, expandBraces(prefix+alt+suffix)...)
+	}
+	return expanded
+}
 
-		// Trim evaluated text from subj as we loop over the pattern.
-		subj = subj[idx+len(parts[i]):]
+// findBraces returns the position of the first brace group containing at least one
+// top-level comma, along with its alternatives. start is -1 if there is none.
+func findBraces(pattern string) (start, end int, alternatives []string) {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			if end, alternatives := parseBraceGroup(pattern, i); len(alternatives) > 1 {
+				return i, end, alternatives
+			}
+		}
 	}
+	return -1, -1, nil
+}
 
-	// Reached the last section. Requires special handling.
-	return trailingGlob || strings.HasSuffix(subj, parts[end])
+// parseBraceGroup splits the brace group opening at pattern[start] on its top-level
+// commas. It returns the index of the closing brace, or -1 if it is unbalanced.
+func parseBraceGroup(pattern string, start int) (int, []string) {
+	depth, last := 0, start+1
+	var alternatives []string
+	for i := start; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case ',':
+			if depth == 1 {
+				alternatives = append(alternatives, pattern[last:i])
+				last = i + 1
+			}
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, append(alternatives, pattern[last:i])
+			}
+		}
+	}
+	return -1, nil
 }
@@ -96,6 +96,99 @@ func TestGlob(t *testing.T) {
 	}
 }
 
+func TestGlobExtendedSyntax(t *testing.T) {
+	for _, pattern := range []string{
+		"aws_ec2_?nstances",
+		"aws_ec2_?????????",
+		"aws_ec[0-9]_instances",
+		"aws_ec[!a-z]_instances",
+		"aws_ec[^a-z]_instances",
+		"aws_ec2_[ix]nstances",
+		"aws_ec2_{instances,volumes}",
+		"aws_{ec2,s3}_{volumes,instances}",
+		"aws_{ec{1,2},s3}_instances",
+		"aws_**_instances",
+		"!aws_*_logs",
+		`aws_ec2\_instances`,
+	} {
+		testGlobMatch(t, pattern, "aws_ec2_instances")
+	}
+
+	for _, pattern := range []string{
+		"aws_ec2_?",
+		"aws_ec2_instances?",
+		"aws_ec[a-z]_instances",
+		"aws_ec[!0-9]_instances",
+		"aws_ec2_{volumes,logs}",
+		"!aws_*",
+		"aws_ec2_instances[",
+		`aws_ec2_\*`,
+	} {
+		testGlobNoMatch(t, pattern, "aws_ec2_instances")
+	}
+
+	// An unterminated class is treated literally
+	testGlobMatch(t, "aws_[ec2", "aws_[ec2")
+	// A brace group without alternatives is treated literally
+	testGlobMatch(t, "aws_{ec2}", "aws_{ec2}")
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		subj     string
+		want     bool
+	}{
+		{patterns: nil, subj: "aws_ec2_instances", want: false},
+		{patterns: []string{"aws_*"}, subj: "aws_ec2_instances", want: true},
+		{patterns: []string{"aws_*", "!aws_ec2_*"}, subj: "aws_ec2_instances", want: false},
+		{patterns: []string{"aws_*", "!aws_ec2_*"}, subj: "aws_s3_buckets", want: true},
+		{patterns: []string{"!aws_*_logs"}, subj: "aws_s3_buckets", want: true},
+		{patterns: []string{"!aws_*_logs"}, subj: "aws_cloudtrail_logs", want: false},
+		{patterns: []string{"gcp_*", "!aws_*_logs"}, subj: "aws_s3_buckets", want: false},
+	}
+	for _, tc := range cases {
+		if got := Match(tc.patterns, tc.subj); got != tc.want {
+			t.Errorf("Match(%v, %q) = %v, want %v", tc.patterns, tc.subj, got, tc.want)
+		}
+	}
+}
+
+func TestIncludeTable(t *testing.T) {
+	if !IncludeTable("aws_s3_buckets", []string{"aws_*"}, []string{"aws_ec2_*"}) {
+		t.Fatal("aws_s3_buckets should be included")
+	}
+	if IncludeTable("aws_ec2_instances", []string{"aws_*"}, []string{"aws_ec2_*"}) {
+		t.Fatal("aws_ec2_instances should be skipped")
+	}
+	if !IncludeTable("aws_ec2_instances", []string{"aws_*"}, []string{"aws_ec2_*", "!aws_ec2_instances"}) {
+		t.Fatal("aws_ec2_instances should be exempt from skip")
+	}
+	if !IncludeTable("aws_s3_buckets", []string{"*"}, []string{"!aws_ec2_instances"}) {
+		t.Fatal("skip tables with only negated patterns should skip nothing")
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		subj     string
+		want     bool
+	}{
+		{patterns: nil, subj: "aws_ec2_instances", want: false},
+		{patterns: []string{"aws_ec2_*"}, subj: "aws_ec2_instances", want: true},
+		{patterns: []string{"aws_ec2_*", "!aws_ec2_instances"}, subj: "aws_ec2_instances", want: false},
+		{patterns: []string{"aws_ec2_*", "!aws_ec2_instances"}, subj: "aws_ec2_volumes", want: true},
+		{patterns: []string{"!aws_ec2_instances"}, subj: "aws_ec2_instances", want: false},
+		{patterns: []string{"!aws_ec2_instances"}, subj: "aws_s3_buckets", want: false},
+	}
+	for _, tc := range cases {
+		if got := Skipped(tc.patterns, tc.subj); got != tc.want {
+			t.Errorf("Skipped(%v, %q) = %v, want %v", tc.patterns, tc.subj, got, tc.want)
+		}
+	}
+}
+
 func BenchmarkGlob(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		if !Glob("*quick*fox*dog", "The quick brown fox jumped over the lazy dog") {
@@ -103,3 +196,11 @@ func BenchmarkGlob(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkGlobExtended(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if !Glob("*{quick,slow}*f?x*[a-z]og", "The quick brown fox jumped over the lazy dog") {
+			b.Fatalf("should match")
+		}
+	}
+}
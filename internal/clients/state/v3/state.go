@@ -3,8 +3,12 @@ package state
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/apache/arrow/go/v13/arrow/array"
@@ -12,29 +16,107 @@ import (
 	"github.com/apache/arrow/go/v13/arrow/memory"
 	pb "github.com/cloudquery/plugin-pb-go/pb/plugin/v3"
 	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/google/uuid"
 )
 
 const keyColumn = "key"
 const valueColumn = "value"
+const versionColumn = "version"
+const writerColumn = "writer"
 
+// Client keeps the state in memory, and writes the keys that changed since the last flush to the state table.
+// Deleted keys are written with an empty value, which is the same as a key that isn't set.
+//
+// Every key has a version, incremented when it's written, and the ID of the client that wrote it.
+// With WithConflictCheck, flushes detect keys changed by other clients sharing the state table (see ConflictError):
+// the keys are only written if their stored version is still the version the client read, and then read back
+// to confirm the client's write wasn't overwritten by a racing flush. Destinations have no conditional writes, so this
+// isn't a compare-and-set: a flush that starts and finishes between the read and the write of another client's flush
+// of the same keys is still overwritten without either client getting an error.
 type Client struct {
 	client    pb.PluginClient
 	tableName string
-	mem       map[string]string
-	mutex     *sync.RWMutex
-	keys      []string
-	values    []string
-	schema    *arrow.Schema
+	// id is written with the keys, to confirm which client wrote them
+	id            string
+	checkConflict bool
+	mem           map[string]string
+	// versions has the versions of the keys in the state table the values in mem are based on
+	versions map[string]int64
+	// dirty has the keys that changed since the last flush
+	dirty      map[string]struct{}
+	mutex      *sync.RWMutex
+	schema     *arrow.Schema
+	tableBytes []byte
+	// flushMutex serializes flushes, so values are written in order
+	flushMutex *sync.Mutex
+	// backgroundErr is the error of the last background flush, returned by the next flush
+	backgroundErr error
+	// stop stops the background flushes when the client is closed, and stopped is closed when they stopped
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
 }
 
-func NewClient(ctx context.Context, pbClient pb.PluginClient, tableName string) (*Client, error) {
+// ConflictError is returned by Flush with WithConflictCheck when keys were changed by another client since this client read them.
+// The conflicting keys are reloaded with their stored values, discarding the local changes, so they can be set again,
+// and the other keys are written.
+type ConflictError struct {
+	Table string
+	Keys  []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("state keys %s in table %s were changed by another client", strings.Join(e.Keys, ", "), e.Table)
+}
+
+type entry struct {
+	value   string
+	version int64
+	writer  string
+}
+
+type Option func(*options)
+
+type options struct {
+	flushInterval time.Duration
+	checkConflict bool
+}
+
+// WithFlushInterval sets the interval of the background flushes, or disables them if it isn't positive.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.flushInterval = interval
+	}
+}
+
+// WithConflictCheck makes flushes read the state table before and after writing the keys, to detect keys changed
+// by other clients. Without it, flushes only write the changed keys.
+// The check is best-effort (see Client), so clients running at the same time still shouldn't share a state table.
+func WithConflictCheck(checkConflict bool) Option {
+	return func(o *options) {
+		o.checkConflict = checkConflict
+	}
+}
+
+// NewClient reads the state table, and flushes the changes in the background until the client is closed,
+// or until ctx is done, and then once more.
+func NewClient(ctx context.Context, pbClient pb.PluginClient, tableName string, opts ...Option) (*Client, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	c := &Client{
-		client:    pbClient,
-		tableName: tableName,
-		mem:       make(map[string]string),
-		mutex:     &sync.RWMutex{},
-		keys:      make([]string, 0),
-		values:    make([]string, 0),
+		client:        pbClient,
+		tableName:     tableName,
+		id:            uuid.NewString(),
+		checkConflict: o.checkConflict,
+		mem:           make(map[string]string),
+		versions:      make(map[string]int64),
+		dirty:         make(map[string]struct{}),
+		mutex:         &sync.RWMutex{},
+		flushMutex:    &sync.Mutex{},
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
 	}
 	table := &schema.Table{
 		Name: tableName,
@@ -48,6 +130,14 @@ func NewClient(ctx context.Context, pbClient pb.PluginClient, tableName string)
 				Name: valueColumn,
 				Type: arrow.BinaryTypes.String,
 			},
+			{
+				Name: versionColumn,
+				Type: arrow.PrimitiveTypes.Int64,
+			},
+			{
+				Name: writerColumn,
+				Type: arrow.BinaryTypes.String,
+			},
 		},
 	}
 	sc := table.ToArrowSchema()
@@ -56,6 +146,7 @@ func NewClient(ctx context.Context, pbClient pb.PluginClient, tableName string)
 	if err != nil {
 		return nil, err
 	}
+	c.tableBytes = tableBytes
 
 	writeClient, err := c.client.Write(ctx)
 	if err != nil {
@@ -74,14 +165,29 @@ func NewClient(ctx context.Context, pbClient pb.PluginClient, tableName string)
 		return nil, err
 	}
 
-	readClient, err := c.client.Read(ctx, &pb.Read_Request{
-		Table: tableBytes,
-	})
+	stored, err := c.read(ctx)
 	if err != nil {
 		return nil, err
 	}
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	for k, e := range stored {
+		c.setStored(k, e)
+	}
+	go c.flushInBackground(ctx, o.flushInterval)
+	return c, nil
+}
+
+// read returns the keys in the state table.
+// Rows written before the version and writer columns were added have version 0 and no writer.
+func (c *Client) read(ctx context.Context) (map[string]entry, error) {
+	readClient, err := c.client.Read(ctx, &pb.Read_Request{
+		Table: c.tableBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]entry)
 	for {
 		res, err := readClient.Recv()
 		if err != nil {
@@ -105,32 +211,229 @@ func NewClient(ctx context.Context, pbClient pb.PluginClient, tableName string)
 			if record.NumRows() == 0 {
 				continue
 			}
-			keys := record.Columns()[0].(*array.String)
-			values := record.Columns()[1].(*array.String)
+			keys := record.Column(record.Schema().FieldIndices(keyColumn)[0]).(*array.String)
+			values := record.Column(record.Schema().FieldIndices(valueColumn)[0]).(*array.String)
+			var versions *array.Int64
+			if indices := record.Schema().FieldIndices(versionColumn); len(indices) > 0 {
+				versions = record.Column(indices[0]).(*array.Int64)
+			}
+			var writers *array.String
+			if indices := record.Schema().FieldIndices(writerColumn); len(indices) > 0 {
+				writers = record.Column(indices[0]).(*array.String)
+			}
 			for i := 0; i < keys.Len(); i++ {
-				c.mem[keys.Value(i)] = values.Value(i)
+				e := entry{value: values.Value(i)}
+				if versions != nil && versions.IsValid(i) {
+					e.version = versions.Value(i)
+				}
+				if writers != nil && writers.IsValid(i) {
+					e.writer = writers.Value(i)
+				}
+				stored[keys.Value(i)] = e
 			}
 		}
 	}
-	return c, nil
+	return stored, nil
+}
+
+// setStored sets the key to its stored value and version
+func (c *Client) setStored(key string, e entry) {
+	if e.value == "" {
+		delete(c.mem, key)
+	} else {
+		c.mem[key] = e.value
+	}
+	c.versions[key] = e.version
+}
+
+// flushInBackground flushes the client every interval until it's closed, or until ctx is done and then once more
+func (c *Client) flushInBackground(ctx context.Context, interval time.Duration) {
+	defer close(c.stopped)
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+			c.backgroundFlush(ctx)
+		case <-c.stop:
+			// Close flushes the client
+			return
+		case <-ctx.Done():
+			// ctx is done, so the last flush can't use it
+			c.backgroundFlush(context.Background())
+			return
+		}
+	}
+}
+
+func (c *Client) backgroundFlush(ctx context.Context) {
+	c.flushMutex.Lock()
+	defer c.flushMutex.Unlock()
+	if err := c.flush(ctx); err != nil {
+		c.backgroundErr = err
+	}
 }
 
 func (c *Client) SetKey(_ context.Context, key string, value string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	c.mem[key] = value
+	if value == "" {
+		delete(c.mem, key)
+	} else {
+		c.mem[key] = value
+	}
+	c.dirty[key] = struct{}{}
 	return nil
 }
 
-func (c *Client) Flush(ctx context.Context) error {
+func (c *Client) DeleteKey(ctx context.Context, key string) error {
+	return c.SetKey(ctx, key, "")
+}
+
+func (c *Client) ListKeys(_ context.Context, prefix string) ([]string, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
+	keys := make([]string, 0)
+	for k := range c.mem {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close stops the background flushes, and flushes the client. It returns the error of the flush,
+// or of the last background flush (e.g. when ctx of NewClient was done).
+func (c *Client) Close(ctx context.Context) error {
+	c.closeOnce.Do(func() { close(c.stop) })
+	<-c.stopped
+	return c.Flush(ctx)
+}
+
+// Flush writes the keys that changed since the last flush in a single record. With WithConflictCheck, keys changed
+// by another client since they were read aren't written, or are reloaded if they were overwritten, and it returns a *ConflictError.
+// It also returns the error of the last background flush, if it failed.
+func (c *Client) Flush(ctx context.Context) error {
+	c.flushMutex.Lock()
+	defer c.flushMutex.Unlock()
+	err := c.flush(ctx)
+	if err == nil {
+		err = c.backgroundErr
+	}
+	c.backgroundErr = nil
+	return err
+}
+
+func (c *Client) flush(ctx context.Context) error {
+	c.mutex.Lock()
+	if len(c.dirty) == 0 {
+		c.mutex.Unlock()
+		return nil
+	}
+	dirty := c.dirty
+	c.dirty = make(map[string]struct{})
+	c.mutex.Unlock()
+
+	var stored map[string]entry
+	if c.checkConflict {
+		var err error
+		if stored, err = c.read(ctx); err != nil {
+			markDirtyKeys(c, dirty)
+			return fmt.Errorf("failed to read state table %s: %w", c.tableName, err)
+		}
+	}
+
+	c.mutex.Lock()
 	bldr := array.NewRecordBuilder(memory.DefaultAllocator, c.schema)
-	for k, v := range c.mem {
+	defer bldr.Release()
+	written := make(map[string]int64, len(dirty))
+	var conflicts []string
+	for k := range dirty {
+		if c.checkConflict && stored[k].version != c.versions[k] {
+			conflicts = append(conflicts, k)
+			c.reload(k, stored[k])
+			continue
+		}
+		version := c.versions[k] + 1
 		bldr.Field(0).(*array.StringBuilder).Append(k)
-		bldr.Field(1).(*array.StringBuilder).Append(v)
+		bldr.Field(1).(*array.StringBuilder).Append(c.mem[k])
+		bldr.Field(2).(*array.Int64Builder).Append(version)
+		bldr.Field(3).(*array.StringBuilder).Append(c.id)
+		written[k] = version
+	}
+	c.mutex.Unlock()
+
+	if len(written) > 0 {
+		if err := c.write(ctx, bldr.NewRecord()); err != nil {
+			// the keys are written by the next flush
+			markDirtyKeys(c, written)
+			return err
+		}
+		confirmed, err := c.confirm(ctx, written)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, confirmed...)
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return &ConflictError{Table: c.tableName, Keys: conflicts}
+	}
+	return nil
+}
+
+// confirm sets the versions of the written keys. With the conflict check, it reads the keys back, and reloads
+// and returns the keys that were overwritten by another client.
+func (c *Client) confirm(ctx context.Context, written map[string]int64) ([]string, error) {
+	var stored map[string]entry
+	if c.checkConflict {
+		var err error
+		if stored, err = c.read(ctx); err != nil {
+			// the keys were written, but the next flush detects if they were overwritten
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			for k, version := range written {
+				c.versions[k] = version
+			}
+			return nil, fmt.Errorf("failed to read back state table %s: %w", c.tableName, err)
+		}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var conflicts []string
+	for k, version := range written {
+		if c.checkConflict && (stored[k].version != version || stored[k].writer != c.id) {
+			conflicts = append(conflicts, k)
+			c.reload(k, stored[k])
+			continue
+		}
+		c.versions[k] = version
+	}
+	return conflicts, nil
+}
+
+// reload sets the conflicting key to its stored value, discarding the local changes
+func (c *Client) reload(key string, e entry) {
+	c.setStored(key, e)
+	delete(c.dirty, key)
+}
+
+// markDirtyKeys marks the keys to be written by the next flush
+func markDirtyKeys[V any](c *Client, keys map[string]V) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for k := range keys {
+		c.dirty[k] = struct{}{}
 	}
-	rec := bldr.NewRecord()
+}
+
+func (c *Client) write(ctx context.Context, rec arrow.Record) error {
+	defer rec.Release()
 	recordBytes, err := pb.RecordToBytes(rec)
 	if err != nil {
 		return err
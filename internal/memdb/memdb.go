@@ -68,6 +68,13 @@ func (c *client) overwrite(table *schema.Table, data arrow.Record) {
 		c.memoryDB[tableName] = append(c.memoryDB[tableName], data)
 		return
 	}
+	if data.NumRows() > 1 {
+		// rows are upserted one by one
+		for i := int64(0); i < data.NumRows(); i++ {
+			c.overwrite(table, data.NewSlice(i, i+1))
+		}
+		return
+	}
 
 	for i, row := range c.memoryDB[tableName] {
 		found := true
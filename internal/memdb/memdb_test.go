@@ -1,7 +1,9 @@
 package memdb
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/cloudquery/plugin-sdk/v4/plugin"
@@ -22,6 +24,33 @@ func TestPlugin(t *testing.T) {
 	)
 }
 
+func TestPluginTypes(t *testing.T) {
+	ctx := context.Background()
+	p := plugin.NewPlugin("test", "development", NewMemDBClient)
+	if err := p.Init(ctx, nil, plugin.NewClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	report := &bytes.Buffer{}
+	plugin.TestWriterSuiteRunner(
+		t,
+		p,
+		plugin.WriterTestSuiteTests{
+			SkipUpsert:      true,
+			SkipDeleteStale: true,
+			SkipInsert:      true,
+			SkipMigrate:     true,
+			TestTypes:       true,
+		},
+		plugin.WithTestTypesReport(report),
+	)
+	if !strings.Contains(report.String(), "| uint64 | `uint64` | yes |  |") {
+		t.Fatalf("expected uint64 to be supported, got report:\n%s", report)
+	}
+	if strings.Contains(report.String(), "| no |") {
+		t.Fatalf("expected all types to be supported, got report:\n%s", report)
+	}
+}
+
 func TestPluginOnNewError(t *testing.T) {
 	ctx := context.Background()
 	p := plugin.NewPlugin("test", "development", NewMemDBClientErrOnNew)
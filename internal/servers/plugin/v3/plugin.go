@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/apache/arrow/go/v13/arrow"
 	pb "github.com/cloudquery/plugin-pb-go/pb/plugin/v3"
@@ -13,6 +14,7 @@ import (
 	"github.com/rs/zerolog"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
@@ -115,6 +117,15 @@ func (s *Server) Sync(req *pb.Sync_Request, stream pb.Plugin_SyncServer) error {
 		SkipDependentTables: req.SkipDependentTables,
 		DeterministicCQID:   req.DeterministicCqId,
 	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(plugin.FullResyncMetadataKey); len(values) > 0 {
+			fullResync, err := strconv.ParseBool(values[0])
+			if err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid %s metadata: %v", plugin.FullResyncMetadataKey, err)
+			}
+			syncOptions.FullResync = fullResync
+		}
+	}
 	if req.Backend != nil {
 		syncOptions.BackendOptions = &plugin.BackendOptions{
 			TableName:  req.Backend.TableName,
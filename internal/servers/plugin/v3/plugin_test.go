@@ -10,10 +10,14 @@ import (
 	"github.com/apache/arrow/go/v13/arrow/memory"
 	pb "github.com/cloudquery/plugin-pb-go/pb/plugin/v3"
 	"github.com/cloudquery/plugin-sdk/v4/internal/memdb"
+	"github.com/cloudquery/plugin-sdk/v4/message"
 	"github.com/cloudquery/plugin-sdk/v4/plugin"
 	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestGetName(t *testing.T) {
@@ -47,6 +51,7 @@ func TestGetVersion(t *testing.T) {
 type mockSyncServer struct {
 	grpc.ServerStream
 	messages []*pb.Sync_Response
+	ctx      context.Context
 }
 
 func (s *mockSyncServer) Send(*pb.Sync_Response) error {
@@ -62,7 +67,10 @@ func (*mockSyncServer) SendHeader(metadata.MD) error {
 }
 func (*mockSyncServer) SetTrailer(metadata.MD) {
 }
-func (*mockSyncServer) Context() context.Context {
+func (s *mockSyncServer) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
 	return context.Background()
 }
 func (*mockSyncServer) SendMsg(any) error {
@@ -183,3 +191,53 @@ func TestPluginSync(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type testSyncOptionsClient struct {
+	options *plugin.SyncOptions
+}
+
+func (*testSyncOptionsClient) Close(context.Context) error {
+	return nil
+}
+
+func (*testSyncOptionsClient) Tables(context.Context, plugin.TableOptions) (schema.Tables, error) {
+	return nil, nil
+}
+
+func (c *testSyncOptionsClient) Sync(_ context.Context, options plugin.SyncOptions, _ chan<- message.SyncMessage) error {
+	*c.options = options
+	return nil
+}
+
+func TestPluginSyncFullResync(t *testing.T) {
+	ctx := context.Background()
+	var options plugin.SyncOptions
+	s := Server{
+		Plugin: plugin.NewSourcePlugin("test", "development", func(context.Context, zerolog.Logger, any) (plugin.SourceClient, error) {
+			return &testSyncOptionsClient{options: &options}, nil
+		}),
+	}
+	if _, err := s.Init(ctx, &pb.Init_Request{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Sync(&pb.Sync_Request{}, &mockSyncServer{}); err != nil {
+		t.Fatal(err)
+	}
+	if options.FullResync {
+		t.Fatal("expected no full resync without metadata")
+	}
+
+	md := metadata.Pairs(plugin.FullResyncMetadataKey, "true")
+	if err := s.Sync(&pb.Sync_Request{}, &mockSyncServer{ctx: metadata.NewIncomingContext(ctx, md)}); err != nil {
+		t.Fatal(err)
+	}
+	if !options.FullResync {
+		t.Fatal("expected a full resync with the metadata")
+	}
+
+	md = metadata.Pairs(plugin.FullResyncMetadataKey, "maybe")
+	if err := s.Sync(&pb.Sync_Request{}, &mockSyncServer{ctx: metadata.NewIncomingContext(ctx, md)}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected an invalid argument error, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Package pagination provides a helper for table resolvers fetching paginated APIs.
+package pagination
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudquery/plugin-sdk/v4/scheduler"
+	"github.com/cloudquery/plugin-sdk/v4/state"
+)
+
+// FetchFunc fetches the page at cursor, which is empty for the first page.
+// It returns the items of the page and the cursor of the next page, which is empty if it's the last page.
+type FetchFunc[T any] func(ctx context.Context, cursor string) (items []T, next string, err error)
+
+type Option func(*options)
+
+type options struct {
+	retryPolicy *scheduler.RetryPolicy
+	state       state.Client
+	key         string
+}
+
+// WithRetryPolicy sets the retry policy of page fetches.
+// Defaults to the retry policy of the scheduler syncing the table (see scheduler.WithRetryPolicy).
+func WithRetryPolicy(policy scheduler.RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = &policy
+	}
+}
+
+// WithCheckpoint saves the cursor of the pages in the state client under a key for the table and client,
+// so a sync that was interrupted resumes from the last page that was sent instead of the first page.
+// The cursor is flushed after every page, and removed once the last page was sent.
+//
+// Resuming fetches the last checkpointed page again, as its items may not have been written by the destination,
+// so tables should have primary keys to avoid duplicates.
+func WithCheckpoint(st state.Client, table string, clientID string) Option {
+	return func(o *options) {
+		o.state = st
+		o.key = CheckpointKey(table, clientID)
+	}
+}
+
+// CheckpointKey returns the state key the cursor of the table and client is saved under by WithCheckpoint.
+func CheckpointKey(table string, clientID string) string {
+	return "cursor:" + table + ":" + clientID
+}
+
+// Resolve fetches all the pages and sends their items to res, until the last page is fetched or the context is done.
+// Failed page fetches are retried (see WithRetryPolicy), and errors wrapped with scheduler.Permanent aren't retried.
+func Resolve[T any](ctx context.Context, res chan<- any, fetch FetchFunc[T], opts ...Option) error {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	policy := scheduler.RetryPolicyFromContext(ctx)
+	if o.retryPolicy != nil {
+		policy = *o.retryPolicy
+	}
+
+	var cursor string
+	if o.state != nil {
+		var err error
+		if cursor, err = o.state.GetKey(ctx, o.key); err != nil {
+			return fmt.Errorf("failed to get checkpoint %s: %w", o.key, err)
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var items []T
+		var next string
+		err := policy.Retry(ctx, func(ctx context.Context) error {
+			var err error
+			items, next, err = fetch(ctx, cursor)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch page: %w", err)
+		}
+		for _, item := range items {
+			select {
+			case res <- item:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if next == "" {
+			// start from the first page in the next sync
+			return o.checkpoint(ctx, "")
+		}
+		if next == cursor {
+			return fmt.Errorf("next page cursor %q is the same as the current one", next)
+		}
+		if err := o.checkpoint(ctx, cursor); err != nil {
+			return err
+		}
+		cursor = next
+	}
+}
+
+// checkpoint saves and flushes the cursor, if checkpointing is enabled
+func (o *options) checkpoint(ctx context.Context, cursor string) error {
+	if o.state == nil {
+		return nil
+	}
+	if err := o.state.SetKey(ctx, o.key, cursor); err != nil {
+		return fmt.Errorf("failed to set checkpoint %s: %w", o.key, err)
+	}
+	if err := o.state.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to flush checkpoint %s: %w", o.key, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudquery/plugin-sdk/v4/scheduler"
+	"github.com/cloudquery/plugin-sdk/v4/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryState struct {
+	mu      sync.Mutex
+	keys    map[string]string
+	flushed map[string]string
+}
+
+func newMemoryState() *memoryState {
+	return &memoryState{keys: make(map[string]string), flushed: make(map[string]string)}
+}
+
+func (s *memoryState) SetKey(_ context.Context, key string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key] = value
+	return nil
+}
+
+func (s *memoryState) GetKey(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key], nil
+}
+
+func (s *memoryState) DeleteKey(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key)
+	return nil
+}
+
+func (s *memoryState) ListKeys(_ context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0)
+	for k := range s.keys {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *memoryState) Flush(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.keys {
+		s.flushed[k] = v
+	}
+	return nil
+}
+
+var _ state.Client = (*memoryState)(nil)
+
+var errFetch = errors.New("fetch failed")
+
+// testPages returns a fetch function for pages of 2 items with cursors "p1", "p2"..., and the cursors it was called with.
+// failAt is the number of the fetch call that fails (1 for the first call), or 0.
+func testPages(pages int, failAt int, permanent bool) (FetchFunc[int], *[]string) {
+	var calls []string
+	return func(_ context.Context, cursor string) ([]int, string, error) {
+		calls = append(calls, cursor)
+		if len(calls) == failAt {
+			if permanent {
+				return nil, "", scheduler.Permanent(errFetch)
+			}
+			return nil, "", errFetch
+		}
+		page := 0
+		if cursor != "" {
+			page, _ = strconv.Atoi(cursor[1:])
+		}
+		next := ""
+		if page+1 < pages {
+			next = "p" + strconv.Itoa(page+1)
+		}
+		return []int{page * 2, page*2 + 1}, next, nil
+	}, &calls
+}
+
+func collect(t *testing.T, fn func(res chan<- any) error) ([]any, error) {
+	t.Helper()
+	res := make(chan any)
+	var items []any
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for item := range res {
+			items = append(items, item)
+		}
+	}()
+	err := fn(res)
+	close(res)
+	<-done
+	return items, err
+}
+
+func TestResolve(t *testing.T) {
+	fetch, calls := testPages(3, 0, false)
+	items, err := collect(t, func(res chan<- any) error {
+		return Resolve(context.Background(), res, fetch)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []any{0, 1, 2, 3, 4, 5}, items)
+	assert.Equal(t, []string{"", "p1", "p2"}, *calls)
+}
+
+func TestResolveRetries(t *testing.T) {
+	fetch, calls := testPages(3, 2, false)
+	policy := scheduler.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	items, err := collect(t, func(res chan<- any) error {
+		return Resolve(context.Background(), res, fetch, WithRetryPolicy(policy))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []any{0, 1, 2, 3, 4, 5}, items)
+	assert.Equal(t, []string{"", "p1", "p1", "p2"}, *calls)
+
+	fetch, calls = testPages(3, 2, true)
+	_, err = collect(t, func(res chan<- any) error {
+		return Resolve(context.Background(), res, fetch, WithRetryPolicy(policy))
+	})
+	require.ErrorIs(t, err, errFetch)
+	assert.Equal(t, []string{"", "p1"}, *calls)
+}
+
+func TestResolveCheckpoint(t *testing.T) {
+	st := newMemoryState()
+	key := CheckpointKey("test_table", "test_client")
+
+	// the sync is interrupted while fetching the 4th page
+	fetch, _ := testPages(5, 4, true)
+	items, err := collect(t, func(res chan<- any) error {
+		return Resolve(context.Background(), res, fetch, WithCheckpoint(st, "test_table", "test_client"))
+	})
+	require.ErrorIs(t, err, errFetch)
+	assert.Equal(t, []any{0, 1, 2, 3, 4, 5}, items)
+	assert.Equal(t, "p2", st.flushed[key])
+
+	// the next sync resumes from the last page that was sent
+	fetch, calls := testPages(5, 0, false)
+	items, err = collect(t, func(res chan<- any) error {
+		return Resolve(context.Background(), res, fetch, WithCheckpoint(st, "test_table", "test_client"))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []any{4, 5, 6, 7, 8, 9}, items)
+	assert.Equal(t, []string{"p2", "p3", "p4"}, *calls)
+	assert.Equal(t, "", st.flushed[key])
+}
+
+func TestResolveErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fetch, _ := testPages(3, 0, false)
+	res := make(chan any)
+	go func() {
+		<-res
+		cancel()
+	}()
+	err := Resolve(ctx, res, fetch)
+	require.ErrorIs(t, err, context.Canceled)
+
+	loop := func(context.Context, string) ([]int, string, error) {
+		return []int{1}, "same", nil
+	}
+	_, err = collect(t, func(res chan<- any) error {
+		return Resolve(context.Background(), res, loop)
+	})
+	require.ErrorContains(t, err, `next page cursor "same" is the same as the current one`)
+}
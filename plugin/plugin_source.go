@@ -3,24 +3,91 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/cloudquery/plugin-sdk/v4/glob"
 	"github.com/cloudquery/plugin-sdk/v4/message"
+	"github.com/cloudquery/plugin-sdk/v4/scheduler"
 	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/state"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 type BackendOptions struct {
-	TableName  string
+	TableName string
+	// Connection is the gRPC address of the destination plugin storing the state,
+	// or a local state file prefixed with state.FileConnectionPrefix (e.g. "file://state.json").
 	Connection string
 }
 
+// StateClient returns the state client selected by the backend options, and a function flushing and closing it.
+// Nil options return a state.NoOpClient, connections prefixed with state.FileConnectionPrefix return a state.FileClient,
+// and other connections are dialed to a destination plugin.
+func (o *BackendOptions) StateClient(ctx context.Context) (state.Client, func() error, error) {
+	noClose := func() error { return nil }
+	if o == nil {
+		return &state.NoOpClient{}, noClose, nil
+	}
+	if strings.HasPrefix(o.Connection, state.FileConnectionPrefix) {
+		client, err := state.NewFileClient(strings.TrimPrefix(o.Connection, state.FileConnectionPrefix), o.TableName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, noClose, nil
+	}
+	conn, err := grpc.DialContext(ctx, o.Connection, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial grpc state backend %s: %w", o.Connection, err)
+	}
+	client, err := state.NewClient(ctx, conn, o.TableName)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	closeClient := func() error {
+		var err error
+		if closer, ok := client.(state.Closer); ok {
+			// ctx may be done by now, and the client must flush before the connection is closed
+			err = closer.Close(context.Background())
+		}
+		if closeErr := conn.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+	return client, closeClient, nil
+}
+
 type SyncOptions struct {
 	Tables              []string
 	SkipTables          []string
 	SkipDependentTables bool
 	DeterministicCQID   bool
 	BackendOptions      *BackendOptions
+	// FullResync ignores the state of incremental tables, so they are synced from scratch (see scheduler.WithSyncFullResync).
+	// Over gRPC, it's set with the FullResyncMetadataKey metadata of the sync request.
+	FullResync bool
+}
+
+// FullResyncMetadataKey is the gRPC metadata key of sync requests setting SyncOptions.FullResync, e.g. "true".
+// The sync request message has no field for it.
+const FullResyncMetadataKey = "cq-full-resync"
+
+// SchedulerOptions returns the scheduler.Sync options of the sync: DeterministicCQID, FullResync, and the state client
+// of the BackendOptions, which enables incremental syncs. The returned function closes the state client, and must be
+// called after the sync.
+func (o SyncOptions) SchedulerOptions(ctx context.Context) ([]scheduler.SyncOption, func() error, error) {
+	stateClient, closeState, err := o.BackendOptions.StateClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []scheduler.SyncOption{
+		scheduler.WithSyncDeterministicCQID(o.DeterministicCQID),
+		scheduler.WithSyncStateClient(stateClient),
+		scheduler.WithSyncFullResync(o.FullResync),
+	}, closeState, nil
 }
 
 type SourceClient interface {
@@ -29,18 +96,10 @@ type SourceClient interface {
 	Sync(ctx context.Context, options SyncOptions, res chan<- message.SyncMessage) error
 }
 
+// MatchesTable reports whether a table name is selected by the include patterns and not by the skip patterns.
+// See glob.Glob for the supported pattern syntax.
 func MatchesTable(name string, includeTablesPattern []string, skipTablesPattern []string) bool {
-	for _, pattern := range skipTablesPattern {
-		if glob.Glob(pattern, name) {
-			return false
-		}
-	}
-	for _, pattern := range includeTablesPattern {
-		if glob.Glob(pattern, name) {
-			return true
-		}
-	}
-	return false
+	return glob.IncludeTable(name, includeTablesPattern, skipTablesPattern)
 }
 
 type NewSourceClientFunc func(context.Context, zerolog.Logger, any) (SourceClient, error)
@@ -2,11 +2,15 @@ package plugin
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/cloudquery/plugin-sdk/v4/message"
+	"github.com/cloudquery/plugin-sdk/v4/scheduler"
 	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/state"
 	"github.com/rs/zerolog"
 )
 
@@ -94,3 +98,69 @@ func TestPluginSuccess(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestBackendOptionsStateClient(t *testing.T) {
+	ctx := context.Background()
+	var nilOptions *BackendOptions
+	client, closeClient, err := nilOptions.StateClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.(*state.NoOpClient); !ok {
+		t.Fatalf("expected a no-op state client, got %T", client)
+	}
+	if err := closeClient(); err != nil {
+		t.Fatal(err)
+	}
+
+	options := &BackendOptions{TableName: "test_state", Connection: state.FileConnectionPrefix + filepath.Join(t.TempDir(), "state.json")}
+	client, closeClient, err = options.StateClient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeClient()
+	if _, ok := client.(*state.FileClient); !ok {
+		t.Fatalf("expected a file state client, got %T", client)
+	}
+}
+
+func TestSyncOptionsFullResync(t *testing.T) {
+	ctx := context.Background()
+	table := &schema.Table{
+		Name:          "test_incremental_table",
+		IsIncremental: true,
+		Resolver: func(ctx context.Context, _ schema.ClientMeta, _ *schema.Resource, res chan<- any) error {
+			if scheduler.HighWatermark(ctx, "updated_at") == nil {
+				res <- map[string]any{"ID": int64(1), "UpdatedAt": time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)}
+			}
+			return nil
+		},
+		Columns: []schema.Column{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64, PrimaryKey: true, Resolver: schema.PathResolver("ID")},
+			{Name: "updated_at", Type: arrow.FixedWidthTypes.Timestamp_us, IncrementalKey: true, Resolver: schema.PathResolver("UpdatedAt")},
+		},
+	}
+	p := newTestSourcePlugin(schema.Tables{table})
+	if err := p.Init(ctx, nil, NewClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	backend := &BackendOptions{TableName: "test_state", Connection: state.FileConnectionPrefix + filepath.Join(t.TempDir(), "state.json")}
+	syncInserts := func(options SyncOptions) int {
+		t.Helper()
+		messages, err := p.SyncAll(ctx, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(messages.GetInserts())
+	}
+
+	if inserts := syncInserts(SyncOptions{Tables: []string{"*"}, BackendOptions: backend}); inserts != 1 {
+		t.Fatalf("expected 1 insert in the first sync, got %d", inserts)
+	}
+	if inserts := syncInserts(SyncOptions{Tables: []string{"*"}, BackendOptions: backend}); inserts != 0 {
+		t.Fatalf("expected no inserts in an incremental sync, got %d", inserts)
+	}
+	if inserts := syncInserts(SyncOptions{Tables: []string{"*"}, BackendOptions: backend, FullResync: true}); inserts != 1 {
+		t.Fatalf("expected 1 insert in a full resync, got %d", inserts)
+	}
+}
@@ -0,0 +1,215 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	snapshotUUID      = "<uuid>"
+	snapshotTimestamp = "<timestamp>"
+	snapshotIgnored   = "<ignored>"
+)
+
+type SnapshotTest struct {
+	// directory the snapshots are saved in, relative to the test package
+	directory string
+
+	// update the snapshots instead of comparing them. Defaults to the UPDATE_SNAPSHOTS environment variable being set.
+	update *bool
+
+	// ignoreColumns are replaced with a placeholder, in addition to the UUID and timestamp columns
+	ignoreColumns []string
+}
+
+func WithSnapshotDirectory(directory string) func(o *SnapshotTest) {
+	return func(o *SnapshotTest) {
+		o.directory = directory
+	}
+}
+
+// WithSnapshotUpdate overwrites the snapshots with the sync output, instead of comparing them.
+func WithSnapshotUpdate(update bool) func(o *SnapshotTest) {
+	return func(o *SnapshotTest) {
+		o.update = &update
+	}
+}
+
+// WithSnapshotIgnoreColumns replaces the values of the columns with a placeholder, for columns with values
+// that change between syncs.
+func WithSnapshotIgnoreColumns(columns ...string) func(o *SnapshotTest) {
+	return func(o *SnapshotTest) {
+		o.ignoreColumns = append(o.ignoreColumns, columns...)
+	}
+}
+
+type snapshotColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type snapshotTable struct {
+	Columns []snapshotColumn `json:"columns"`
+	Rows    []map[string]any `json:"rows"`
+}
+
+// TestSyncSnapshots syncs the initialized plugin and compares the columns and rows of every table with its snapshot,
+// saved in the testdata directory as stable JSON. The rows are sorted, and the values of timestamp columns
+// and the ignored columns are replaced with placeholders, as they change between syncs.
+// The values of UUID columns (such as _cq_id) are replaced with tokens (<uuid-1>, <uuid-2>, ...) numbered in order
+// of first appearance across all tables, so the same UUID gets the same token, e.g. in _cq_id and _cq_parent_id.
+// Missing snapshots are created, failing the test. To update the snapshots, set the UPDATE_SNAPSHOTS environment variable.
+func TestSyncSnapshots(t *testing.T, p *Plugin, options SyncOptions, opts ...func(o *SnapshotTest)) {
+	snapshotTest := &SnapshotTest{
+		directory: "testdata",
+	}
+	for _, opt := range opts {
+		opt(snapshotTest)
+	}
+	configurators := []cupaloy.Configurator{cupaloy.SnapshotSubdirectory(snapshotTest.directory)}
+	if snapshotTest.update != nil {
+		update := *snapshotTest.update
+		configurators = append(configurators, cupaloy.ShouldUpdate(func() bool { return update }))
+	}
+	cup := cupaloy.New(configurators...)
+
+	ctx := context.Background()
+	tables, err := p.Tables(ctx, TableOptions{
+		Tables:              options.Tables,
+		SkipTables:          options.SkipTables,
+		SkipDependentTables: options.SkipDependentTables,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := p.SyncAll(ctx, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inserts := messages.GetInserts()
+	// the snapshots are created before running the subtests, so the UUID tokens don't depend on the subtests being run
+	flatTables := tables.FlattenTables()
+	uuids := make(snapshotUUIDs)
+	snapshots := make([]snapshotTable, len(flatTables))
+	for i, table := range flatTables {
+		snapshots[i] = snapshotTest.tableSnapshot(table, inserts.GetRecordsForTable(table), uuids)
+	}
+	for i, table := range flatTables {
+		snapshot := snapshots[i]
+		t.Run(table.Name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			enc := json.NewEncoder(b)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(snapshot); err != nil {
+				t.Fatal(err)
+			}
+			cup.SnapshotT(t, bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+		})
+	}
+}
+
+// tableSnapshot returns the columns of the table, and the normalized rows of the records sorted by their JSON encoding.
+// UUIDs without a token are sorted as a placeholder, and get their tokens in the order of the sorted rows.
+func (s *SnapshotTest) tableSnapshot(table *schema.Table, records []arrow.Record, uuids snapshotUUIDs) snapshotTable {
+	snapshot := snapshotTable{
+		Columns: make([]snapshotColumn, len(table.Columns)),
+		Rows:    make([]map[string]any, 0),
+	}
+	for i, c := range table.Columns {
+		snapshot.Columns[i] = snapshotColumn{Name: c.Name, Type: c.Type.String()}
+	}
+	for _, record := range records {
+		for i := 0; i < int(record.NumRows()); i++ {
+			row := make(map[string]any, record.NumCols())
+			for j, field := range record.Schema().Fields() {
+				row[field.Name] = s.snapshotValue(field, record.Column(j), i)
+			}
+			snapshot.Rows = append(snapshot.Rows, row)
+		}
+	}
+	keys := make([]string, len(snapshot.Rows))
+	for i, row := range snapshot.Rows {
+		masked := make(map[string]any, len(row))
+		for name, v := range row {
+			if id, ok := v.(snapshotUUIDValue); ok {
+				v = uuids.placeholder(string(id))
+			}
+			masked[name] = v
+		}
+		b, _ := json.Marshal(masked)
+		keys[i] = string(b)
+	}
+	sort.Sort(rowsByKey{rows: snapshot.Rows, keys: keys})
+	for _, row := range snapshot.Rows {
+		for _, c := range snapshot.Columns {
+			if id, ok := row[c.Name].(snapshotUUIDValue); ok {
+				row[c.Name] = uuids.token(string(id))
+			}
+		}
+	}
+	return snapshot
+}
+
+// snapshotValue returns the value at index i of the column, or a placeholder if it changes between syncs
+func (s *SnapshotTest) snapshotValue(field arrow.Field, arr arrow.Array, i int) any {
+	if arr.IsNull(i) {
+		return nil
+	}
+	switch {
+	case slices.Contains(s.ignoreColumns, field.Name):
+		return snapshotIgnored
+	case arrow.TypeEqual(field.Type, types.ExtensionTypes.UUID):
+		return snapshotUUIDValue(arr.ValueStr(i))
+	case field.Type.ID() == arrow.TIMESTAMP:
+		return snapshotTimestamp
+	default:
+		return arr.GetOneForMarshal(i)
+	}
+}
+
+// snapshotUUIDValue is the value of a UUID column, replaced with its token once the rows are sorted
+type snapshotUUIDValue string
+
+// snapshotUUIDs maps UUIDs to tokens numbered in order of first appearance
+type snapshotUUIDs map[string]string
+
+// token returns the token of the UUID, adding a new token if it didn't have one
+func (u snapshotUUIDs) token(id string) string {
+	if token, ok := u[id]; ok {
+		return token
+	}
+	token := fmt.Sprintf("<uuid-%d>", len(u)+1)
+	u[id] = token
+	return token
+}
+
+// placeholder returns the token of the UUID, or a placeholder if it doesn't have one yet
+func (u snapshotUUIDs) placeholder(id string) string {
+	if token, ok := u[id]; ok {
+		return token
+	}
+	return snapshotUUID
+}
+
+type rowsByKey struct {
+	rows []map[string]any
+	keys []string
+}
+
+func (r rowsByKey) Len() int           { return len(r.rows) }
+func (r rowsByKey) Less(i, j int) bool { return r.keys[i] < r.keys[j] }
+func (r rowsByKey) Swap(i, j int) {
+	r.rows[i], r.rows[j] = r.rows[j], r.rows[i]
+	r.keys[i], r.keys[j] = r.keys[j], r.keys[i]
+}
@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+	"github.com/google/uuid"
+)
+
+func TestSyncSnapshotsRunner(t *testing.T) {
+	ctx := context.Background()
+	p := newTestSourcePlugin(testSourceTables())
+	if err := p.Init(ctx, nil, NewClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	TestSyncSnapshots(t, p, SyncOptions{Tables: []string{"*"}})
+}
+
+func TestTableSnapshot(t *testing.T) {
+	table := &schema.Table{
+		Name: "test_snapshot_table",
+		Columns: []schema.Column{
+			{Name: "name", Type: arrow.BinaryTypes.String},
+			{Name: "updated_at", Type: arrow.FixedWidthTypes.Timestamp_us},
+			{Name: "etag", Type: arrow.BinaryTypes.String},
+		},
+	}
+	bldr := array.NewRecordBuilder(memory.DefaultAllocator, table.ToArrowSchema())
+	defer bldr.Release()
+	for _, name := range []string{"b", "a"} {
+		bldr.Field(0).(*array.StringBuilder).Append(name)
+		bldr.Field(1).(*array.TimestampBuilder).Append(arrow.Timestamp(time.Now().UnixMicro()))
+		bldr.Field(2).(*array.StringBuilder).Append(name + "-etag")
+	}
+	bldr.Field(0).(*array.StringBuilder).Append("c")
+	bldr.Field(1).(*array.TimestampBuilder).AppendNull()
+	bldr.Field(2).(*array.StringBuilder).AppendNull()
+	record := bldr.NewRecord()
+	defer record.Release()
+
+	s := &SnapshotTest{ignoreColumns: []string{"etag"}}
+	snapshot := s.tableSnapshot(table, []arrow.Record{record}, make(snapshotUUIDs))
+	expected := []map[string]any{
+		{"name": "a", "updated_at": snapshotTimestamp, "etag": snapshotIgnored},
+		{"name": "b", "updated_at": snapshotTimestamp, "etag": snapshotIgnored},
+		{"name": "c", "updated_at": nil, "etag": nil},
+	}
+	if len(snapshot.Rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(snapshot.Rows))
+	}
+	for i, row := range expected {
+		for column, value := range row {
+			if snapshot.Rows[i][column] != value {
+				t.Fatalf("expected row %d column %s to be %v, got %v", i, column, value, snapshot.Rows[i][column])
+			}
+		}
+	}
+}
+
+func TestTableSnapshotUUIDs(t *testing.T) {
+	parent := &schema.Table{
+		Name:    "test_snapshot_parent",
+		Columns: []schema.Column{schema.CqIDColumn, {Name: "name", Type: arrow.BinaryTypes.String}},
+	}
+	child := &schema.Table{
+		Name:    "test_snapshot_child",
+		Columns: []schema.Column{schema.CqIDColumn, schema.CqParentIDColumn, {Name: "name", Type: arrow.BinaryTypes.String}},
+	}
+	a, b := uuid.New(), uuid.New()
+
+	parentBldr := array.NewRecordBuilder(memory.DefaultAllocator, parent.ToArrowSchema())
+	defer parentBldr.Release()
+	// appended out of order, the tokens follow the sorted rows
+	for _, row := range []struct {
+		id   uuid.UUID
+		name string
+	}{{b, "b"}, {a, "a"}} {
+		parentBldr.Field(0).(*types.UUIDBuilder).Append(row.id)
+		parentBldr.Field(1).(*array.StringBuilder).Append(row.name)
+	}
+	parentRecord := parentBldr.NewRecord()
+	defer parentRecord.Release()
+
+	childBldr := array.NewRecordBuilder(memory.DefaultAllocator, child.ToArrowSchema())
+	defer childBldr.Release()
+	childBldr.Field(0).(*types.UUIDBuilder).Append(uuid.New())
+	childBldr.Field(1).(*types.UUIDBuilder).Append(b)
+	childBldr.Field(2).(*array.StringBuilder).Append("child")
+	childRecord := childBldr.NewRecord()
+	defer childRecord.Release()
+
+	s := &SnapshotTest{}
+	uuids := make(snapshotUUIDs)
+	parentSnapshot := s.tableSnapshot(parent, []arrow.Record{parentRecord}, uuids)
+	childSnapshot := s.tableSnapshot(child, []arrow.Record{childRecord}, uuids)
+
+	if got := parentSnapshot.Rows[0][schema.CqIDColumn.Name]; got != "<uuid-1>" {
+		t.Fatalf("expected the first row to have token <uuid-1>, got %v", got)
+	}
+	if got := parentSnapshot.Rows[1][schema.CqIDColumn.Name]; got != "<uuid-2>" {
+		t.Fatalf("expected the second row to have token <uuid-2>, got %v", got)
+	}
+	if got := childSnapshot.Rows[0][schema.CqIDColumn.Name]; got != "<uuid-3>" {
+		t.Fatalf("expected the child row to have token <uuid-3>, got %v", got)
+	}
+	if got := childSnapshot.Rows[0][schema.CqParentIDColumn.Name]; got != "<uuid-2>" {
+		t.Fatalf("expected the child row to reference its parent with token <uuid-2>, got %v", got)
+	}
+}
@@ -0,0 +1,294 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/message"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"golang.org/x/exp/slices"
+)
+
+type SourceTestSuite struct {
+	tests SourceTestSuiteTests
+
+	plugin *Plugin
+
+	// spec is passed to the plugin Init
+	spec []byte
+
+	// syncOptions are the options of the tested syncs. Defaults to syncing all tables.
+	syncOptions SyncOptions
+
+	// validators validate the tables of the plugin. Defaults to schema.DefaultValidators.
+	validators *schema.Validators
+}
+
+type SourceTestSuiteTests struct {
+	// SkipTables skips validating the tables of the plugin.
+	SkipTables bool
+
+	// SkipSync skips all the tests of the sync output.
+	SkipSync bool
+
+	// SkipEmptyColumns skips checking that every column of every table has a value.
+	// Columns with IgnoreInTests are never checked.
+	SkipEmptyColumns bool
+
+	// SkipParentIDs skips checking that the _cq_parent_id of relations is the _cq_id of a parent resource.
+	SkipParentIDs bool
+}
+
+func WithSourceTestSpec(spec []byte) func(o *SourceTestSuite) {
+	return func(o *SourceTestSuite) {
+		o.spec = spec
+	}
+}
+
+// WithSourceTestSyncOptions sets the options of the tested syncs.
+// If DeterministicCQID is set, the plugin is synced twice to check that the _cq_id of tables with primary keys don't change.
+func WithSourceTestSyncOptions(options SyncOptions) func(o *SourceTestSuite) {
+	return func(o *SourceTestSuite) {
+		o.syncOptions = options
+	}
+}
+
+func WithSourceTestValidators(validators *schema.Validators) func(o *SourceTestSuite) {
+	return func(o *SourceTestSuite) {
+		o.validators = validators
+	}
+}
+
+// TestSourceSuiteRunner initializes the source plugin, validates its tables and checks the messages of a sync.
+func TestSourceSuiteRunner(t *testing.T, p *Plugin, tests SourceTestSuiteTests, opts ...func(o *SourceTestSuite)) {
+	suite := &SourceTestSuite{
+		tests:       tests,
+		plugin:      p,
+		spec:        []byte("{}"),
+		syncOptions: SyncOptions{Tables: []string{"*"}},
+		validators:  schema.DefaultValidators(),
+	}
+
+	for _, opt := range opts {
+		opt(suite)
+	}
+
+	ctx := context.Background()
+	if err := p.Init(ctx, suite.spec, NewClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := p.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	tables, err := p.Tables(ctx, TableOptions{
+		Tables:              suite.syncOptions.Tables,
+		SkipTables:          suite.syncOptions.SkipTables,
+		SkipDependentTables: suite.syncOptions.SkipDependentTables,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("TestTables", func(t *testing.T) {
+		if suite.tests.SkipTables {
+			t.Skip("skipping " + t.Name())
+		}
+		if err := suite.testTables(tables); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("TestSync", func(t *testing.T) {
+		if suite.tests.SkipSync {
+			t.Skip("skipping " + t.Name())
+		}
+		messages, err := p.SyncAll(ctx, suite.syncOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run("MigrateBeforeInsert", func(t *testing.T) {
+			if err := testMigrateBeforeInsert(messages); err != nil {
+				t.Fatal(err)
+			}
+		})
+		t.Run("RecordSchemas", func(t *testing.T) {
+			if err := testRecordSchemas(tables, messages); err != nil {
+				t.Fatal(err)
+			}
+		})
+		t.Run("EmptyColumns", func(t *testing.T) {
+			if suite.tests.SkipEmptyColumns {
+				t.Skip("skipping " + t.Name())
+			}
+			if err := testEmptyColumns(tables, messages); err != nil {
+				t.Fatal(err)
+			}
+		})
+		t.Run("ParentIDs", func(t *testing.T) {
+			if suite.tests.SkipParentIDs {
+				t.Skip("skipping " + t.Name())
+			}
+			if err := testParentIDs(tables, messages); err != nil {
+				t.Fatal(err)
+			}
+		})
+		t.Run("DeterministicCQID", func(t *testing.T) {
+			if !suite.syncOptions.DeterministicCQID {
+				t.Skip("skipping " + t.Name() + " as DeterministicCQID isn't set in the sync options")
+			}
+			again, err := p.SyncAll(ctx, suite.syncOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := testDeterministicCQIDs(tables, messages, again); err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+}
+
+// testTables validates the tables with the validators of the suite, and checks the table names are unique
+func (s *SourceTestSuite) testTables(tables schema.Tables) error {
+	if len(tables) == 0 {
+		return fmt.Errorf("plugin has no tables")
+	}
+	if err := tables.ValidateDuplicateTables(); err != nil {
+		return err
+	}
+	return s.validators.ValidateTables(tables).Err()
+}
+
+// testMigrateBeforeInsert checks that every table is migrated once, before its records are inserted
+func testMigrateBeforeInsert(messages message.SyncMessages) error {
+	migrated := make(map[string]bool)
+	for _, msg := range messages {
+		switch m := msg.(type) {
+		case *message.SyncMigrateTable:
+			if migrated[m.Table.Name] {
+				return fmt.Errorf("table %s was migrated more than once", m.Table.Name)
+			}
+			migrated[m.Table.Name] = true
+		case *message.SyncInsert:
+			name, _ := m.Record.Schema().Metadata().GetValue(schema.MetadataTableName)
+			if !migrated[name] {
+				return fmt.Errorf("record of table %s was inserted before the table was migrated", name)
+			}
+		}
+	}
+	return nil
+}
+
+// testRecordSchemas checks that the inserted records have the schemas of their tables
+func testRecordSchemas(tables schema.Tables, messages message.SyncMessages) error {
+	flattened := tables.FlattenTables()
+	for _, insert := range messages.GetInserts() {
+		name, ok := insert.Record.Schema().Metadata().GetValue(schema.MetadataTableName)
+		if !ok {
+			return fmt.Errorf("record schema has no table name: %s", insert.Record.Schema())
+		}
+		table := flattened.Get(name)
+		if table == nil {
+			return fmt.Errorf("record of table %s that isn't one of the plugin tables", name)
+		}
+		if err := compareSchemas(table.ToArrowSchema(), insert.Record.Schema()); err != nil {
+			return fmt.Errorf("record of table %s doesn't match the table schema: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func compareSchemas(expected, actual *arrow.Schema) error {
+	if len(expected.Fields()) != len(actual.Fields()) {
+		return fmt.Errorf("expected %d columns, got %d", len(expected.Fields()), len(actual.Fields()))
+	}
+	for i, field := range expected.Fields() {
+		if !field.Equal(actual.Field(i)) {
+			return fmt.Errorf("expected column %d to be %s, got %s", i, field, actual.Field(i))
+		}
+	}
+	return nil
+}
+
+// testEmptyColumns checks that every column of the tables that aren't ignored in tests has a value
+func testEmptyColumns(tables schema.Tables, messages message.SyncMessages) error {
+	inserts := messages.GetInserts()
+	for _, table := range tables.FlattenTables() {
+		if table.IgnoreInTests {
+			continue
+		}
+		emptyColumns := schema.FindEmptyColumns(table, inserts.GetRecordsForTable(table))
+		if len(emptyColumns) > 0 {
+			return fmt.Errorf("found empty column(s): %v in %s", emptyColumns, table.Name)
+		}
+	}
+	return nil
+}
+
+// testParentIDs checks that the _cq_parent_id of the relation records are the _cq_id of records of their parent table
+func testParentIDs(tables schema.Tables, messages message.SyncMessages) error {
+	inserts := messages.GetInserts()
+	var check func(parent *schema.Table) error
+	check = func(parent *schema.Table) error {
+		parentIDs := columnValues(inserts.GetRecordsForTable(parent), schema.CqIDColumn.Name)
+		for _, rel := range parent.Relations {
+			for _, id := range columnValues(inserts.GetRecordsForTable(rel), schema.CqParentIDColumn.Name) {
+				if !slices.Contains(parentIDs, id) {
+					return fmt.Errorf("%s %s of table %s isn't the %s of a record of parent table %s", schema.CqParentIDColumn.Name, id, rel.Name, schema.CqIDColumn.Name, parent.Name)
+				}
+			}
+			if err := check(rel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, table := range tables {
+		if err := check(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// testDeterministicCQIDs checks that two syncs have the same _cq_id for tables with primary keys
+func testDeterministicCQIDs(tables schema.Tables, first, second message.SyncMessages) error {
+	for _, table := range tables.FlattenTables() {
+		pks := table.PrimaryKeys()
+		if len(pks) == 0 || (len(pks) == 1 && pks[0] == schema.CqIDColumn.Name) {
+			// tables without primary keys have random ids
+			continue
+		}
+		firstIDs := columnValues(first.GetInserts().GetRecordsForTable(table), schema.CqIDColumn.Name)
+		secondIDs := columnValues(second.GetInserts().GetRecordsForTable(table), schema.CqIDColumn.Name)
+		sort.Strings(firstIDs)
+		sort.Strings(secondIDs)
+		if strings.Join(firstIDs, ",") != strings.Join(secondIDs, ",") {
+			return fmt.Errorf("%s of table %s changed between syncs: %v and %v", schema.CqIDColumn.Name, table.Name, firstIDs, secondIDs)
+		}
+	}
+	return nil
+}
+
+// columnValues returns the valid values of the column in the records
+func columnValues(records []arrow.Record, column string) []string {
+	var values []string
+	for _, record := range records {
+		indices := record.Schema().FieldIndices(column)
+		if len(indices) == 0 {
+			continue
+		}
+		arr := record.Column(indices[0])
+		for i := 0; i < arr.Len(); i++ {
+			if arr.IsValid(i) {
+				values = append(values, arr.ValueStr(i))
+			}
+		}
+	}
+	return values
+}
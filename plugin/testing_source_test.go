@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/message"
+	"github.com/cloudquery/plugin-sdk/v4/scheduler"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/rs/zerolog"
+)
+
+type testSourceClient struct {
+	tables    schema.Tables
+	scheduler *scheduler.Scheduler
+}
+
+func (*testSourceClient) ID() string {
+	return "test_source"
+}
+
+func (*testSourceClient) Close(context.Context) error {
+	return nil
+}
+
+func (c *testSourceClient) Tables(context.Context, TableOptions) (schema.Tables, error) {
+	return c.tables, nil
+}
+
+func (c *testSourceClient) Sync(ctx context.Context, options SyncOptions, res chan<- message.SyncMessage) error {
+	syncOptions, closeState, err := options.SchedulerOptions(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.scheduler.Sync(ctx, c, c.tables, res, syncOptions...); err != nil {
+		closeState()
+		return err
+	}
+	return closeState()
+}
+
+func testSourceTables() schema.Tables {
+	table := &schema.Table{
+		Name: "test_source_table",
+		Resolver: func(_ context.Context, _ schema.ClientMeta, _ *schema.Resource, res chan<- any) error {
+			res <- map[string]any{"ID": int64(1)}
+			res <- map[string]any{"ID": int64(2)}
+			return nil
+		},
+		Columns: []schema.Column{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64, PrimaryKey: true, Resolver: schema.PathResolver("ID")},
+		},
+		Relations: []*schema.Table{
+			{
+				Name: "test_source_relation",
+				Resolver: func(_ context.Context, _ schema.ClientMeta, parent *schema.Resource, res chan<- any) error {
+					res <- map[string]any{"ParentID": parent.Get("id").Get()}
+					return nil
+				},
+				Columns: []schema.Column{
+					{Name: "parent_id", Type: arrow.PrimitiveTypes.Int64, PrimaryKey: true, Resolver: schema.PathResolver("ParentID")},
+				},
+			},
+		},
+	}
+	schema.AddCqIDs(table)
+	table.Relations[0].Parent = table
+	return schema.Tables{table}
+}
+
+func newTestSourcePlugin(tables schema.Tables) *Plugin {
+	return NewSourcePlugin("test", "development", func(_ context.Context, logger zerolog.Logger, _ any) (SourceClient, error) {
+		return &testSourceClient{tables: tables, scheduler: scheduler.NewScheduler(scheduler.WithLogger(logger))}, nil
+	})
+}
+
+func TestSourceSuite(t *testing.T) {
+	TestSourceSuiteRunner(t, newTestSourcePlugin(testSourceTables()), SourceTestSuiteTests{},
+		WithSourceTestSyncOptions(SyncOptions{Tables: []string{"*"}, DeterministicCQID: true}))
+}
+
+func TestSourceSuiteChecks(t *testing.T) {
+	tables := testSourceTables()
+	p := newTestSourcePlugin(tables)
+	ctx := context.Background()
+	if err := p.Init(ctx, nil, NewClientOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	messages, err := p.SyncAll(ctx, SyncOptions{Tables: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inserts := messages.GetInserts()
+
+	if err := testMigrateBeforeInsert(message.SyncMessages{inserts[0]}); err == nil {
+		t.Fatal("expected an error for an insert before the migration")
+	}
+
+	changed := testSourceTables()
+	changed[0].Columns[2].Type = arrow.BinaryTypes.String
+	if err := testRecordSchemas(changed, messages); err == nil {
+		t.Fatal("expected an error for a record not matching the table schema")
+	}
+
+	withEmpty := testSourceTables()
+	withEmpty[0].Columns = append(withEmpty[0].Columns, schema.Column{Name: "empty", Type: arrow.BinaryTypes.String})
+	if err := testEmptyColumns(withEmpty, messages); err == nil {
+		t.Fatal("expected an error for an empty column")
+	}
+	withEmpty[0].Columns[3].IgnoreInTests = true
+	if err := testEmptyColumns(withEmpty, messages); err != nil {
+		t.Fatal(err)
+	}
+
+	var relationInserts message.SyncMessages
+	for _, insert := range inserts {
+		if name, _ := insert.Record.Schema().Metadata().GetValue(schema.MetadataTableName); name == "test_source_relation" {
+			relationInserts = append(relationInserts, insert)
+		}
+	}
+	if err := testParentIDs(tables, relationInserts); err == nil {
+		t.Fatal("expected an error for relations without parent records")
+	}
+
+	// ids are random without DeterministicCQID
+	again, err := p.SyncAll(ctx, SyncOptions{Tables: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testDeterministicCQIDs(tables, messages, again); err == nil {
+		t.Fatal("expected an error for random ids")
+	}
+}
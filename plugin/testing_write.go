@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"context"
+	"io"
 	"math/rand"
 	"testing"
 
@@ -32,6 +33,12 @@ type WriterTestSuite struct {
 
 	// rand.Rand
 	rand *rand.Rand
+
+	// unsupportedTypes are the names of the types of the TestTypes test that are expected to fail
+	unsupportedTypes []string
+
+	// typesReport is written the compatibility matrix of the TestTypes test
+	typesReport io.Writer
 }
 
 // SafeMigrations defines which migrations are supported by the plugin in safe migrate mode
@@ -57,6 +64,10 @@ type WriterTestSuiteTests struct {
 	// SkipMigrate skips testing migration
 	SkipMigrate bool
 
+	// TestTypes writes and reads back a table per data type, instead of a single table with all the types,
+	// so unsupported types fail separately. The results are reported as a TypeCompatibilityMatrix.
+	TestTypes bool
+
 	// SafeMigrations defines which tests should work with force migration
 	// and which should pass with safe migration
 	SafeMigrations SafeMigrations
@@ -88,6 +99,21 @@ func WithRandomSeed(seed int64) func(o *WriterTestSuite) {
 	}
 }
 
+// WithTestUnsupportedTypes skips the types of the TestTypes test that fail, instead of failing the test.
+// Types are named as in schema.TestTable, e.g. uint64 or string_int64_map.
+func WithTestUnsupportedTypes(names ...string) func(o *WriterTestSuite) {
+	return func(o *WriterTestSuite) {
+		o.unsupportedTypes = append(o.unsupportedTypes, names...)
+	}
+}
+
+// WithTestTypesReport writes the compatibility matrix of the TestTypes test to w, as a markdown table.
+func WithTestTypesReport(w io.Writer) func(o *WriterTestSuite) {
+	return func(o *WriterTestSuite) {
+		o.typesReport = w
+	}
+}
+
 func TestWriterSuiteRunner(t *testing.T, p *Plugin, tests WriterTestSuiteTests, opts ...func(o *WriterTestSuite)) {
 	suite := &WriterTestSuite{
 		tests:  tests,
@@ -150,4 +176,11 @@ func TestWriterSuiteRunner(t *testing.T, p *Plugin, tests WriterTestSuiteTests,
 		suite.testMigrate(ctx, t, false)
 		suite.testMigrate(ctx, t, true)
 	})
+
+	t.Run("TestTypes", func(t *testing.T) {
+		if !suite.tests.TestTypes {
+			t.Skip("skipping " + t.Name())
+		}
+		suite.testTypes(ctx, t)
+	})
 }
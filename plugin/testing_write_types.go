@@ -0,0 +1,162 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/message"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"golang.org/x/exp/slices"
+)
+
+// TypeCompatibility is the result of writing and reading back a table with a single column of a data type.
+type TypeCompatibility struct {
+	// Name is the name of the column of the type in schema.TestTable, e.g. int64 or string_int64_map
+	Name string
+	Type arrow.DataType
+	// Err is the reason the type isn't supported, or nil if it was read back as written
+	Err error
+}
+
+func (c TypeCompatibility) Supported() bool {
+	return c.Err == nil
+}
+
+// TypeCompatibilityMatrix is the result of the TestTypes test of the WriterTestSuite, with a row per data type.
+type TypeCompatibilityMatrix []TypeCompatibility
+
+// Markdown returns the matrix as a markdown table, e.g. for the documentation of a destination.
+func (m TypeCompatibilityMatrix) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("| Name | Type | Supported | Error |\n")
+	sb.WriteString("| --- | --- | --- | --- |\n")
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, c := range m {
+		supported, reason := "yes", ""
+		if !c.Supported() {
+			supported, reason = "no", escape.Replace(c.Err.Error())
+		}
+		fmt.Fprintf(&sb, "| %s | `%s` | %s | %s |\n", c.Name, escape.Replace(c.Type.String()), supported, reason)
+	}
+	return sb.String()
+}
+
+// typeColumns returns a column for every data type of schema.TestTable, and for the nested lists and structs it doesn't include
+func typeColumns(opts schema.TestSourceOptions) schema.ColumnList {
+	columns := schema.TestTable("types", opts).Columns
+	// skip the id column, which is part of every table of the test
+	columns = slices.Clone(columns[1:])
+	if !opts.SkipLists {
+		columns = append(columns, schema.Column{Name: "int64_list_list", Type: arrow.ListOf(arrow.ListOf(arrow.PrimitiveTypes.Int64))})
+	}
+	if !opts.SkipStructs {
+		columns = append(columns, schema.Column{Name: "nested_struct", Type: arrow.StructOf(
+			arrow.Field{Name: "inner", Type: arrow.StructOf(
+				arrow.Field{Name: "int64", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+				arrow.Field{Name: "string", Type: arrow.BinaryTypes.String, Nullable: true},
+			), Nullable: true},
+		)})
+	}
+	return columns
+}
+
+// testTypes writes and reads back a table per data type, so an unsupported type only fails its own subtest.
+// The results are logged, and written to the report writer of the suite, as a compatibility matrix.
+func (s *WriterTestSuite) testTypes(ctx context.Context, t *testing.T) {
+	var matrix TypeCompatibilityMatrix
+	for i, column := range typeColumns(s.genDatOptions) {
+		i, column := i, column
+		t.Run(column.Name, func(t *testing.T) {
+			err := s.testType(ctx, s.tableNameForTest(fmt.Sprintf("types_%03d", i)), column)
+			matrix = append(matrix, TypeCompatibility{Name: column.Name, Type: column.Type, Err: err})
+			switch {
+			case err != nil && slices.Contains(s.unsupportedTypes, column.Name):
+				t.Skipf("skipping unsupported type %s: %v", column.Name, err)
+			case err != nil:
+				t.Fatal(err)
+			case slices.Contains(s.unsupportedTypes, column.Name):
+				t.Logf("type %s is marked as unsupported, but was read back as written", column.Name)
+			}
+		})
+	}
+
+	report := matrix.Markdown()
+	t.Log("type compatibility matrix:\n" + report)
+	if s.typesReport != nil {
+		if _, err := s.typesReport.Write([]byte(report)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testType inserts regular values, edge cases and a null value of the column, and checks they're read back as written
+func (s *WriterTestSuite) testType(ctx context.Context, tableName string, column schema.Column) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	table := &schema.Table{
+		Name: tableName,
+		Columns: schema.ColumnList{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64, NotNull: true},
+			column,
+		},
+	}
+	if err := s.plugin.writeOne(ctx, &message.WriteMigrateTable{
+		Table: table,
+	}); err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+
+	opts := schema.GenTestDataOptions{MaxRows: 1, TimePrecision: s.genDatOptions.TimePrecision, Seed: s.randSeed}
+	edgeCases := opts
+	edgeCases.EdgeCaseRatio = 1
+	nulls := opts
+	nulls.NullRatio = 1
+	rowOptions := []schema.GenTestDataOptions{opts, edgeCases, edgeCases, edgeCases, nulls}
+
+	tg := schema.NewTestDataGenerator()
+	expected := make([]arrow.Record, len(rowOptions))
+	for i, rowOpts := range rowOptions {
+		// vary the seed, so the rows get different edge cases
+		rowOpts.Seed += int64(i)
+		record := tg.GenerateRecord(table, rowOpts)
+		if err := s.plugin.writeOne(ctx, &message.WriteInsert{
+			Record: record,
+		}); err != nil {
+			return fmt.Errorf("failed to insert record: %w", err)
+		}
+		expected[i] = s.handleNulls(record) // we process nulls after writing
+	}
+
+	readRecords, err := s.plugin.readAll(ctx, table)
+	if err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+	rows := splitRows(readRecords)
+	if len(rows) != len(expected) {
+		return fmt.Errorf("expected %d items, got %d", len(expected), len(rows))
+	}
+	sortRecords(table, rows, "id")
+	for i := range expected {
+		if diff := RecordDiff(rows[i], expected[i]); diff != "" {
+			return fmt.Errorf("record[%d] differs: %s", i, diff)
+		}
+	}
+	return nil
+}
+
+// splitRows returns a record per row of the records, as sortRecords sorts records by their first row
+func splitRows(records []arrow.Record) []arrow.Record {
+	var rows []arrow.Record
+	for _, record := range records {
+		for i := int64(0); i < record.NumRows(); i++ {
+			rows = append(rows, record.NewSlice(i, i+1))
+		}
+	}
+	return rows
+}
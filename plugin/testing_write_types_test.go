@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+)
+
+func TestTypeCompatibilityMatrixMarkdown(t *testing.T) {
+	matrix := TypeCompatibilityMatrix{
+		{Name: "int64", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "uint64", Type: arrow.PrimitiveTypes.Uint64, Err: errors.New("failed to insert record:\nvalue | out of range")},
+	}
+	expected := "| Name | Type | Supported | Error |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| int64 | `int64` | yes |  |\n" +
+		"| uint64 | `uint64` | no | failed to insert record: value \\| out of range |\n"
+	if got := matrix.Markdown(); got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestTypeColumns(t *testing.T) {
+	columns := typeColumns(schema.TestSourceOptions{})
+	seen := make(map[string]bool)
+	for _, c := range columns {
+		if c.Name == "id" || seen[c.Name] {
+			t.Fatalf("unexpected column %s", c.Name)
+		}
+		seen[c.Name] = true
+	}
+	for _, name := range []string{"int64", "uuid", "decimal128", "struct", "int64_list_list", "nested_struct"} {
+		if !seen[name] {
+			t.Fatalf("expected a column for %s", name)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+// Package replay records the HTTP interactions of source plugin tests into cassette files, and replays them,
+// so resolvers can be tested in CI without access to the real APIs.
+package replay
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// RecordEnvVariable is the environment variable that makes recorders created with NewForTest record the interactions
+// with the real API, instead of replaying the cassette.
+const RecordEnvVariable = "RECORD_CASSETTES"
+
+// Redacted replaces the scrubbed secrets in cassettes.
+const Redacted = "REDACTED"
+
+type Mode int
+
+const (
+	// ModeReplay replays the interactions of the cassette, and fails requests that weren't recorded.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests to the real API, and saves the interactions in the cassette when the recorder is stopped.
+	ModeRecord
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	default:
+		return fmt.Sprintf("unknown mode %d", int(m))
+	}
+}
+
+// Cassette is the file format of the recorded interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is saved as a string if it's valid UTF-8, so cassettes can be reviewed, and base64 encoded otherwise.
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+	var encoded map[string]string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded["base64"])
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Matcher reports whether a request matches a recorded request. Both requests are scrubbed.
+type Matcher func(request, recorded *Request) bool
+
+// DefaultMatcher matches requests with the same method, URL and body.
+func DefaultMatcher(request, recorded *Request) bool {
+	return request.Method == recorded.Method && request.URL == recorded.URL && bytes.Equal(request.Body, recorded.Body)
+}
+
+// DefaultScrubHeaders are the request and response headers redacted by default.
+var DefaultScrubHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// DefaultScrubQueryParams are the query parameters redacted by default.
+// Requests differing only by redacted parameters match the same interactions, so parameters like "token",
+// commonly used for pagination, aren't redacted by default to not rely on the order of the requests.
+var DefaultScrubQueryParams = []string{"api_key", "apikey", "access_token"}
+
+// Recorder is an http.RoundTripper recording or replaying the interactions of a cassette.
+type Recorder struct {
+	path             string
+	mode             Mode
+	transport        http.RoundTripper
+	matcher          Matcher
+	scrubHeaders     []string
+	scrubQueryParams []string
+	scrubbers        []func(*Interaction)
+
+	mu       sync.Mutex
+	cassette *Cassette
+	// used marks the replayed interactions
+	used []bool
+}
+
+type Option func(*Recorder)
+
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithTransport sets the transport of the requests to the real API in ModeRecord. Defaults to http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithMatcher sets how requests are matched with the recorded requests. Defaults to DefaultMatcher.
+func WithMatcher(matcher Matcher) Option {
+	return func(r *Recorder) {
+		r.matcher = matcher
+	}
+}
+
+// WithScrubHeaders redacts the request and response headers, in addition to DefaultScrubHeaders.
+func WithScrubHeaders(headers ...string) Option {
+	return func(r *Recorder) {
+		r.scrubHeaders = append(r.scrubHeaders, headers...)
+	}
+}
+
+// WithScrubQueryParams redacts the query parameters of the request URLs, in addition to DefaultScrubQueryParams.
+// Requests differing only by redacted parameters, e.g. pagination tokens, are replayed in the order they were recorded,
+// so the requests must be made in the same order.
+func WithScrubQueryParams(params ...string) Option {
+	return func(r *Recorder) {
+		r.scrubQueryParams = append(r.scrubQueryParams, params...)
+	}
+}
+
+// WithScrubber adds a function removing secrets from the interactions, e.g. tokens in response bodies.
+// Scrubbers are applied to the recorded interactions, and to the requests before they're matched, so they must be idempotent.
+func WithScrubber(scrubber func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, scrubber)
+	}
+}
+
+// New returns a recorder for the cassette at path. In ModeReplay, the cassette must exist.
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:             path,
+		transport:        http.DefaultTransport,
+		matcher:          DefaultMatcher,
+		scrubHeaders:     append([]string(nil), DefaultScrubHeaders...),
+		scrubQueryParams: append([]string(nil), DefaultScrubQueryParams...),
+		cassette:         &Cassette{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.mode == ModeRecord {
+		return r, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	if err := json.Unmarshal(b, r.cassette); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// NewForTest returns a recorder for the cassette testdata/cassettes/<name>.json, which replays it unless the
+// RECORD_CASSETTES environment variable is set. The recorder is stopped when the test finishes.
+func NewForTest(t testing.TB, name string, opts ...Option) *Recorder {
+	t.Helper()
+	mode := ModeReplay
+	if os.Getenv(RecordEnvVariable) != "" {
+		mode = ModeRecord
+	}
+	r, err := New(filepath.Join("testdata", "cassettes", name+".json"), append([]Option{WithMode(mode)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := r.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	return r
+}
+
+// Mode returns the mode of the recorder.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an HTTP client using the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records the interaction with the real API in ModeRecord, or returns the response of the first matching
+// interaction of the cassette that wasn't replayed yet in ModeReplay. Identical requests replay their interactions
+// in the recorded order (e.g. a retried request), and then the last one again, so a cassette can be synced more than once.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	request, err := r.request(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, request)
+	}
+	return r.replay(req, request)
+}
+
+func (r *Recorder) record(req *http.Request, request *Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := &Interaction{
+		Request: *request,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       body,
+		},
+	}
+	r.scrub(interaction)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, request *Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	match := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !r.matcher(request, &interaction.Request) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match == -1 {
+		return nil, fmt.Errorf("no interaction recorded in cassette %s for %s %s", r.path, request.Method, request.URL)
+	}
+	r.used[match] = true
+	response := r.cassette.Interactions[match].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        response.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(response.Body)),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// request returns the scrubbed request, leaving the body of req readable
+func (r *Recorder) request(req *http.Request) (*Request, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   body,
+		},
+	}
+	r.scrub(interaction)
+	return &interaction.Request, nil
+}
+
+// scrub redacts the secrets of the interaction
+func (r *Recorder) scrub(interaction *Interaction) {
+	for _, header := range r.scrubHeaders {
+		for _, h := range []http.Header{interaction.Request.Header, interaction.Response.Header} {
+			if h.Get(header) != "" {
+				h.Set(header, Redacted)
+			}
+		}
+	}
+	if u, err := url.Parse(interaction.Request.URL); err == nil {
+		query := u.Query()
+		scrubbed := false
+		for _, param := range r.scrubQueryParams {
+			if query.Has(param) {
+				query.Set(param, Redacted)
+				scrubbed = true
+			}
+		}
+		if scrubbed {
+			u.RawQuery = query.Encode()
+			interaction.Request.URL = u.String()
+		}
+	}
+	for _, scrubber := range r.scrubbers {
+		scrubber(interaction)
+	}
+}
+
+// Stop saves the recorded interactions to the cassette in ModeRecord.
+// In ModeReplay, it returns an error if interactions of the cassette weren't replayed.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mode == ModeReplay {
+		var unused []string
+		for i, interaction := range r.cassette.Interactions {
+			if !r.used[i] {
+				unused = append(unused, interaction.Request.Method+" "+interaction.Request.URL)
+			}
+		}
+		if len(unused) > 0 {
+			return fmt.Errorf("interactions of cassette %s weren't replayed: %s", r.path, strings.Join(unused, ", "))
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// static check
+var _ http.RoundTripper = (*Recorder)(nil)
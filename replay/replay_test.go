@@ -0,0 +1,219 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudquery/plugin-sdk/v4/message"
+	"github.com/cloudquery/plugin-sdk/v4/plugin"
+	"github.com/cloudquery/plugin-sdk/v4/rest"
+	"github.com/cloudquery/plugin-sdk/v4/scheduler"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/transformers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "secret-token"
+
+type testUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+type testRepo struct {
+	Name  string `json:"name"`
+	Stars int64  `json:"stars"`
+}
+
+// testAPI returns a server for users and their repos, rate limiting the first request
+func testAPI(t *testing.T) *httptest.Server {
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Set-Cookie", "session="+testToken)
+		_ = json.NewEncoder(w).Encode([]testUser{{ID: 1, Login: "alice"}, {ID: 2, Login: "bob"}})
+	})
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		login := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), "/repos")
+		_ = json.NewEncoder(w).Encode([]testRepo{{Name: login + "-repo", Stars: int64(len(login))}})
+	})
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken || r.URL.Query().Get("api_key") != testToken {
+			t.Errorf("unauthorized request %s", r.URL)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+type testClient struct {
+	*rest.Client
+}
+
+func testTables(t *testing.T) schema.Tables {
+	tables, err := rest.NewTables(rest.Endpoint{
+		Name:        "test_users",
+		Path:        "users?api_key=" + testToken,
+		Struct:      &testUser{},
+		PrimaryKeys: []string{"ID"},
+		Children: []rest.Endpoint{
+			{
+				Name:        "test_user_repos",
+				Path:        "users/{id}/repos?api_key=" + testToken,
+				Struct:      &testRepo{},
+				PrimaryKeys: []string{"Name"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, transformers.TransformTables(tables))
+	transformers.SetParents(tables, nil)
+	for _, table := range tables {
+		schema.AddCqIDs(table)
+	}
+	return tables
+}
+
+func testSync(t *testing.T, baseURL string, httpClient *http.Client) message.SyncMessages {
+	c, err := rest.NewClient("test", baseURL, rest.WithHTTPClient(httpClient), rest.WithAuth(rest.BearerToken(testToken)))
+	require.NoError(t, err)
+	sc := scheduler.NewScheduler(
+		scheduler.WithLogger(zerolog.New(zerolog.NewTestWriter(t))),
+		scheduler.WithRetryPolicy(scheduler.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+	msgs, err := sc.SyncAll(context.Background(), &testClient{Client: c}, testTables(t))
+	require.NoError(t, err)
+	return msgs
+}
+
+// record records a sync of the test API in a cassette, and returns its path and base URL
+func record(t *testing.T) (string, string) {
+	srv := testAPI(t)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassettes", "sync.json")
+	r, err := New(path, WithMode(ModeRecord), WithScrubber(func(i *Interaction) {
+		i.Response.Body = []byte(strings.ReplaceAll(string(i.Response.Body), "alice", "user1"))
+	}))
+	require.NoError(t, err)
+	msgs := testSync(t, srv.URL, r.Client())
+	assert.Equal(t, int64(4), msgs.InsertItems())
+	require.NoError(t, r.Stop())
+	return path, srv.URL
+}
+
+func TestRecordReplay(t *testing.T) {
+	path, baseURL := record(t)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), testToken)
+	assert.NotContains(t, string(b), "alice")
+	var cassette Cassette
+	require.NoError(t, json.Unmarshal(b, &cassette))
+	require.Len(t, cassette.Interactions, 4)
+	assert.Equal(t, http.StatusTooManyRequests, cassette.Interactions[0].Response.StatusCode)
+	assert.Equal(t, Redacted, cassette.Interactions[0].Request.Header.Get("Authorization"))
+
+	// the server is closed, so the sync only works with the replayed responses
+	r, err := New(path)
+	require.NoError(t, err)
+	msgs := testSync(t, baseURL, r.Client())
+	require.NoError(t, r.Stop())
+	assert.Equal(t, int64(4), msgs.InsertItems())
+	var logins []string
+	for _, insert := range msgs.GetInserts() {
+		if name, _ := insert.Record.Schema().Metadata().GetValue(schema.MetadataTableName); name == "test_users" {
+			logins = append(logins, insert.Record.Column(insert.Record.Schema().FieldIndices("login")[0]).ValueStr(0))
+		}
+	}
+	assert.ElementsMatch(t, []string{"user1", "bob"}, logins)
+
+	// requests that weren't recorded fail, and interactions that weren't replayed fail the stop
+	r, err = New(path)
+	require.NoError(t, err)
+	_, err = r.Client().Get(baseURL + "/unknown")
+	assert.ErrorContains(t, err, "no interaction recorded")
+	assert.ErrorContains(t, r.Stop(), "weren't replayed")
+}
+
+func TestReplaySourceSuite(t *testing.T) {
+	path, baseURL := record(t)
+	r, err := New(path)
+	require.NoError(t, err)
+	p := plugin.NewSourcePlugin("test", "development", func(_ context.Context, logger zerolog.Logger, _ any) (plugin.SourceClient, error) {
+		c, err := rest.NewClient("test", baseURL, rest.WithHTTPClient(r.Client()), rest.WithAuth(rest.BearerToken(testToken)))
+		if err != nil {
+			return nil, err
+		}
+		return &testSourceClient{
+			client:    &testClient{Client: c},
+			tables:    testTables(t),
+			scheduler: scheduler.NewScheduler(scheduler.WithLogger(logger), scheduler.WithRetryPolicy(scheduler.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})),
+		}, nil
+	})
+	plugin.TestSourceSuiteRunner(t, p, plugin.SourceTestSuiteTests{},
+		plugin.WithSourceTestSyncOptions(plugin.SyncOptions{Tables: []string{"*"}, DeterministicCQID: true}))
+	require.NoError(t, r.Stop())
+}
+
+type testSourceClient struct {
+	client    schema.ClientMeta
+	tables    schema.Tables
+	scheduler *scheduler.Scheduler
+}
+
+func (*testSourceClient) Close(context.Context) error {
+	return nil
+}
+
+func (c *testSourceClient) Tables(context.Context, plugin.TableOptions) (schema.Tables, error) {
+	return c.tables, nil
+}
+
+func (c *testSourceClient) Sync(ctx context.Context, options plugin.SyncOptions, res chan<- message.SyncMessage) error {
+	return c.scheduler.Sync(ctx, c.client, c.tables, res, scheduler.WithSyncDeterministicCQID(options.DeterministicCQID))
+}
+
+func TestScrubQueryParams(t *testing.T) {
+	r, err := New(filepath.Join(t.TempDir(), "cassette.json"), WithMode(ModeRecord), WithScrubQueryParams("secret"))
+	require.NoError(t, err)
+	interaction := &Interaction{Request: Request{URL: "https://example.com/users?api_key=key&secret=value&token=next"}}
+	r.scrub(interaction)
+	u, err := url.Parse(interaction.Request.URL)
+	require.NoError(t, err)
+	assert.Equal(t, Redacted, u.Query().Get("api_key"))
+	assert.Equal(t, Redacted, u.Query().Get("secret"))
+	// pagination tokens are kept, so the pages match their own interactions
+	assert.Equal(t, "next", u.Query().Get("token"))
+}
+
+func TestBody(t *testing.T) {
+	for _, body := range []Body{Body(`{"a": "b"}`), {0xff, 0x00, 0xfe}} {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		var decoded Body
+		require.NoError(t, json.Unmarshal(b, &decoded))
+		assert.Equal(t, body, decoded)
+	}
+}
+
+func TestNewMissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,230 @@
+// Package rest builds tables for REST APIs from declarative endpoint definitions, so sources for SaaS APIs
+// don't need to re-implement HTTP paging, auth and table wiring.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudquery/plugin-sdk/v4/scheduler"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+)
+
+const (
+	defaultTimeout = time.Minute
+	// maxErrorBodySize is the maximum number of bytes of the response body included in a StatusError
+	maxErrorBodySize = 1024
+)
+
+// Client makes the requests of the tables built with NewTable. It implements schema.ClientMeta,
+// so it can be used as the plugin client, or embedded in it.
+type Client struct {
+	id         string
+	baseURL    *url.URL
+	httpClient *http.Client
+	auth       Authenticator
+	header     http.Header
+}
+
+// Provider is implemented by plugin clients that make the requests of the tables built with NewTable with a Client.
+// Clients embedding *Client implement it.
+type Provider interface {
+	RESTClient() *Client
+}
+
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests, e.g. to use a custom transport. Defaults to a client with a 1 minute timeout.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAuth sets how requests are authenticated.
+func WithAuth(auth Authenticator) ClientOption {
+	return func(c *Client) {
+		c.auth = auth
+	}
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// NewClient returns a client making requests relative to baseURL. id is used as the ID of the schema.ClientMeta.
+func NewClient(id string, baseURL string, opts ...ClientOption) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if !u.IsAbs() {
+		return nil, fmt.Errorf("invalid base URL %q: must be absolute", baseURL)
+	}
+	// make sure paths are resolved relative to the full base path
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+		if u.RawPath != "" {
+			u.RawPath += "/"
+		}
+	}
+	c := &Client{
+		id:         id,
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		header:     make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+func (c *Client) ID() string {
+	return c.id
+}
+
+func (c *Client) RESTClient() *Client {
+	return c
+}
+
+// URL returns the URL of the path (which can include a query) relative to the base URL of the client.
+func (c *Client) URL(path string) (*url.URL, error) {
+	ref, err := url.Parse(strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	return c.baseURL.ResolveReference(ref), nil
+}
+
+// Response is a response with a JSON body.
+type Response struct {
+	// URL is the URL of the request.
+	URL        *url.URL
+	StatusCode int
+	Header     http.Header
+	// Body is the response body decoded with encoding/json, with numbers decoded as json.Number.
+	Body any
+}
+
+// StatusError is returned for responses with a non-2xx status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	// Body is the beginning of the response body.
+	Body       string
+	retryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request to %s failed with status %d: %s", e.URL, e.StatusCode, e.Body)
+}
+
+// RetryAfter returns the wait requested by the Retry-After header of the response, if any.
+func (e *StatusError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// Get requests the URL and decodes the JSON response body.
+// Requests wait for the rate limit of the scheduler, and failed requests (network errors, status 429 and 5xx) are retried
+// with its retry policy (see scheduler.WithRateLimit and scheduler.WithRetryPolicy).
+func (c *Client) Get(ctx context.Context, u *url.URL) (*Response, error) {
+	var resp *Response
+	err := scheduler.Retry(ctx, func(ctx context.Context) error {
+		if err := scheduler.WaitForRateLimit(ctx); err != nil {
+			return scheduler.Permanent(err)
+		}
+		var err error
+		resp, err = c.get(ctx, u)
+		return err
+	})
+	return resp, err
+}
+
+func (c *Client) get(ctx context.Context, u *url.URL) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, scheduler.Permanent(err)
+	}
+	for key, values := range c.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, scheduler.Permanent(fmt.Errorf("failed to authenticate request: %w", err))
+		}
+	}
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, scheduler.Permanent(err)
+		}
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrorBodySize))
+		statusErr := &StatusError{
+			URL:        u.String(),
+			StatusCode: httpResp.StatusCode,
+			Body:       string(bytes.TrimSpace(body)),
+			retryAfter: parseRetryAfter(httpResp.Header.Get("Retry-After")),
+		}
+		if httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode >= 500 {
+			return nil, statusErr
+		}
+		return nil, scheduler.Permanent(statusErr)
+	}
+
+	resp := &Response{URL: u, StatusCode: httpResp.StatusCode, Header: httpResp.Header}
+	dec := json.NewDecoder(httpResp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&resp.Body); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode response from %s: %w", u, err)
+	}
+	return resp, nil
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which can be a number of seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// clientFromMeta returns the client of the plugin client
+func clientFromMeta(meta schema.ClientMeta) (*Client, error) {
+	p, ok := meta.(Provider)
+	if !ok {
+		return nil, fmt.Errorf("client %s doesn't implement rest.Provider", meta.ID())
+	}
+	c := p.RESTClient()
+	if c == nil {
+		return nil, fmt.Errorf("client %s doesn't have a REST client", meta.ID())
+	}
+	return c, nil
+}
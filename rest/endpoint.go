@@ -0,0 +1,249 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/transformers"
+)
+
+// Endpoint is the declarative definition of a table synced from a REST API endpoint.
+type Endpoint struct {
+	// Name is the name of the table.
+	Name string
+	// Description is the description of the table.
+	Description string
+	// Path is the path of the endpoint relative to the base URL of the client, and can include a query.
+	// For child endpoints, {column} placeholders are replaced with the values of the columns of the parent resource,
+	// e.g. "/users/{id}/repos".
+	Path string
+	// Query are additional query parameters sent with every request.
+	Query url.Values
+	// ItemsPath is the path of the array of items in the response body, with keys separated by dots (e.g. "data.items").
+	// If empty, the response body is the array. A single object is treated as one item.
+	ItemsPath string
+	// Pagination requests the following pages of the endpoint. Only a single page is requested if nil.
+	Pagination Paginator
+	// PrimaryKeys are the fields of the items used as primary keys (see transformers.WithPrimaryKeys).
+	PrimaryKeys []string
+
+	// JSONSchema is the JSON Schema of the items, used to create the columns with transformers.TransformWithJSONSchema.
+	JSONSchema []byte
+	// Struct is a Go struct the items are decoded into, used to create the columns with transformers.TransformWithStruct.
+	// Exactly one of JSONSchema and Struct must be set.
+	Struct any
+	// TransformOptions are passed to the transformer creating the columns.
+	TransformOptions []transformers.StructTransformerOption
+
+	// Children are the endpoints of the relations of the table, requested for every item of this endpoint.
+	Children []Endpoint
+}
+
+var rePlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// NewTables returns the tables of the endpoints.
+func NewTables(endpoints ...Endpoint) (schema.Tables, error) {
+	tables := make(schema.Tables, len(endpoints))
+	for i, e := range endpoints {
+		table, err := NewTable(e)
+		if err != nil {
+			return nil, err
+		}
+		tables[i] = table
+	}
+	return tables, nil
+}
+
+// NewTable returns the table of the endpoint, with the child endpoints as relations.
+// The columns are added by the table transform (see transformers.TransformTables).
+// The plugin client passed to the resolvers must implement Provider.
+func NewTable(e Endpoint) (*schema.Table, error) {
+	if e.Name == "" {
+		return nil, errors.New("endpoint name is required")
+	}
+	if e.Path == "" {
+		return nil, fmt.Errorf("endpoint %s: path is required", e.Name)
+	}
+	if _, err := url.Parse(rePlaceholder.ReplaceAllString(e.Path, "x")); err != nil {
+		return nil, fmt.Errorf("endpoint %s: invalid path: %w", e.Name, err)
+	}
+
+	opts := append(e.TransformOptions[:len(e.TransformOptions):len(e.TransformOptions)], transformers.WithPrimaryKeys(e.PrimaryKeys...))
+	var transform schema.Transform
+	var itemType reflect.Type
+	switch {
+	case e.JSONSchema != nil && e.Struct != nil:
+		return nil, fmt.Errorf("endpoint %s: only one of JSONSchema and Struct can be set", e.Name)
+	case e.JSONSchema != nil:
+		transform = transformers.TransformWithJSONSchema(e.JSONSchema, opts...)
+	case e.Struct != nil:
+		itemType = reflect.TypeOf(e.Struct)
+		if itemType.Kind() == reflect.Pointer {
+			itemType = itemType.Elem()
+		}
+		if itemType.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("endpoint %s: expected struct, got %s", e.Name, itemType.Kind())
+		}
+		transform = transformers.TransformWithStruct(e.Struct, opts...)
+	default:
+		return nil, fmt.Errorf("endpoint %s: one of JSONSchema and Struct is required", e.Name)
+	}
+
+	relations, err := NewTables(e.Children...)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint %s: %w", e.Name, err)
+	}
+	return &schema.Table{
+		Name:        e.Name,
+		Description: e.Description,
+		Transform:   transform,
+		Resolver:    e.resolver(itemType),
+		Relations:   relations,
+	}, nil
+}
+
+// resolver returns the table resolver requesting all the pages of the endpoint.
+// The items are decoded into itemType if it's not nil, and sent as decoded by encoding/json otherwise.
+func (e Endpoint) resolver(itemType reflect.Type) schema.TableResolver {
+	return func(ctx context.Context, meta schema.ClientMeta, parent *schema.Resource, res chan<- any) error {
+		c, err := clientFromMeta(meta)
+		if err != nil {
+			return err
+		}
+		path, err := expandPath(e.Path, parent)
+		if err != nil {
+			return err
+		}
+		u, err := c.URL(path)
+		if err != nil {
+			return err
+		}
+		if len(e.Query) > 0 {
+			q := u.Query()
+			for key, values := range e.Query {
+				q[key] = append(q[key], values...)
+			}
+			u.RawQuery = q.Encode()
+		}
+		if e.Pagination != nil {
+			u = e.Pagination.FirstPage(u)
+		}
+
+		for u != nil {
+			resp, err := c.Get(ctx, u)
+			if err != nil {
+				return err
+			}
+			items, err := responseItems(resp.Body, e.ItemsPath)
+			if err != nil {
+				return fmt.Errorf("invalid response from %s: %w", u, err)
+			}
+			for _, item := range items {
+				v, err := decodeItem(item, itemType)
+				if err != nil {
+					return fmt.Errorf("failed to decode item from %s: %w", u, err)
+				}
+				select {
+				case res <- v:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			if e.Pagination == nil {
+				return nil
+			}
+			if u, err = e.Pagination.NextPage(resp, len(items)); err != nil {
+				return fmt.Errorf("failed to get next page of %s: %w", resp.URL, err)
+			}
+		}
+		return nil
+	}
+}
+
+// expandPath replaces the {column} placeholders of the path with the path escaped values of the parent resource
+func expandPath(path string, parent *schema.Resource) (string, error) {
+	var err error
+	expanded := rePlaceholder.ReplaceAllStringFunc(path, func(placeholder string) string {
+		column := placeholder[1 : len(placeholder)-1]
+		if parent == nil {
+			err = fmt.Errorf("path parameter %s requires a parent resource", column)
+			return ""
+		}
+		if parent.Table.Columns.Get(column) == nil {
+			err = fmt.Errorf("path parameter %s isn't a column of the parent table %s", column, parent.Table.Name)
+			return ""
+		}
+		v := parent.Get(column)
+		if !v.IsValid() {
+			err = fmt.Errorf("path parameter %s is null in the parent resource", column)
+			return ""
+		}
+		return url.PathEscape(v.String())
+	})
+	return expanded, err
+}
+
+// responseItems returns the items at the path of the response body
+func responseItems(body any, path string) ([]any, error) {
+	switch v := lookupPath(body, path).(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return v, nil
+	case map[string]any:
+		return []any{v}, nil
+	default:
+		return nil, fmt.Errorf("expected array of items at %q, got %T", path, v)
+	}
+}
+
+// decodeItem decodes the JSON item into a new value of itemType, or converts its numbers for the JSON Schema column resolvers
+func decodeItem(item any, itemType reflect.Type) (any, error) {
+	if itemType == nil {
+		return convertNumbers(item), nil
+	}
+	b, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.New(itemType)
+	if err := json.Unmarshal(b, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// convertNumbers replaces the json.Number values with int64 values, uint64 values if they're integers above the int64 range,
+// or float64 values if they aren't integers, as the scalars don't accept json.Number
+func convertNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, value := range v {
+			v[key] = convertNumbers(value)
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = convertNumbers(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
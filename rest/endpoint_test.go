@@ -0,0 +1,245 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/scheduler"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/transformers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "secret"
+
+const testUserSchema = `{
+  "type": "object",
+  "required": ["id"],
+  "properties": {
+    "id": {"type": "integer"},
+    "login": {"type": "string", "description": "The login of the user"}
+  }
+}`
+
+type testRepo struct {
+	Name  string `json:"name"`
+	Stars int64  `json:"stars"`
+}
+
+type testAPI struct {
+	eventsUnavailable int32
+	requests          int32
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (api *testAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+	// cursor pagination
+	mux.HandleFunc("/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "2" {
+			http.Error(w, "missing limit", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			writeJSON(w, map[string]any{
+				"data": []any{map[string]any{"id": 1, "login": "alice"}, map[string]any{"id": 9007199254740993, "login": "bob"}},
+				"meta": map[string]any{"next_cursor": "c2"},
+			})
+			return
+		}
+		writeJSON(w, map[string]any{
+			"data": []any{map[string]any{"id": 3, "login": "carol/c"}},
+			"meta": map[string]any{"next_cursor": nil},
+		})
+	})
+	// link header pagination, only user 1 has repos
+	mux.HandleFunc("/v1/users/1/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `</v1/users/1/repos?page=2>; rel="next", </v1/users/1/repos?page=2>; rel="last"`)
+			writeJSON(w, []any{map[string]any{"name": "r1", "stars": 10}})
+			return
+		}
+		writeJSON(w, []any{map[string]any{"name": "r2", "stars": 20}})
+	})
+	// offset pagination, unavailable on the first request
+	mux.HandleFunc("/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&api.eventsUnavailable, -1) >= 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var items []any
+		for i := offset; i < offset+2 && i < 3; i++ {
+			items = append(items, map[string]any{"id": i, "type": r.URL.Query().Get("type")})
+		}
+		writeJSON(w, map[string]any{"items": items, "total": 3})
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&api.requests, 1)
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func testEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Name:        "test_users",
+			Path:        "/users",
+			ItemsPath:   "data",
+			Pagination:  &CursorPagination{CursorParam: "cursor", CursorPath: "meta.next_cursor", LimitParam: "limit", Limit: 2},
+			PrimaryKeys: []string{"id"},
+			JSONSchema:  []byte(testUserSchema),
+			Children: []Endpoint{
+				{
+					Name:       "test_user_repos",
+					Path:       "/users/{id}/repos",
+					Pagination: &LinkHeaderPagination{},
+					Struct:     &testRepo{},
+				},
+			},
+		},
+		{
+			Name:       "test_events",
+			Path:       "events?type=push",
+			ItemsPath:  "items",
+			Pagination: &OffsetPagination{Limit: 2, TotalPath: "total"},
+			JSONSchema: []byte(`{"type": "object", "properties": {"id": {"type": "integer"}, "type": {"type": "string"}}}`),
+		},
+	}
+}
+
+type testPluginClient struct {
+	*Client
+}
+
+func TestNewTablesSync(t *testing.T) {
+	api := &testAPI{eventsUnavailable: 1}
+	srv := httptest.NewServer(api.handler())
+	defer srv.Close()
+
+	tables, err := NewTables(testEndpoints()...)
+	require.NoError(t, err)
+	require.NoError(t, transformers.TransformTables(tables))
+	transformers.SetParents(tables, nil)
+
+	users := tables.Get("test_users")
+	assert.Equal(t, []string{"id", "login"}, users.Columns.Names())
+	assert.Equal(t, []string{"id"}, users.PrimaryKeys())
+	assert.Equal(t, "The login of the user", users.Columns.Get("login").Description)
+	assert.Equal(t, []string{"name", "stars"}, tables.Get("test_user_repos").Columns.Names())
+
+	c, err := NewClient("test", srv.URL+"/v1", WithAuth(BearerToken(testToken)))
+	require.NoError(t, err)
+	sc := scheduler.NewScheduler(
+		scheduler.WithLogger(zerolog.New(zerolog.NewTestWriter(t))),
+		scheduler.WithRetryPolicy(scheduler.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+	msgs, err := sc.SyncAll(context.Background(), &testPluginClient{Client: c}, tables)
+	require.NoError(t, err)
+
+	values := make(map[string][]string)
+	for _, insert := range msgs.GetInserts() {
+		tableName, _ := insert.Record.Schema().Metadata().GetValue(schema.MetadataTableName)
+		rec := insert.Record
+		var row []string
+		for i := 0; i < int(rec.NumCols()); i++ {
+			row = append(row, rec.Column(i).ValueStr(0))
+		}
+		values[tableName] = append(values[tableName], fmt.Sprint(row))
+	}
+	assert.ElementsMatch(t, []string{"[1 alice]", "[9007199254740993 bob]", "[3 carol/c]"}, values["test_users"])
+	assert.ElementsMatch(t, []string{"[r1 10]", "[r2 20]"}, values["test_user_repos"])
+	assert.ElementsMatch(t, []string{"[0 push]", "[1 push]", "[2 push]"}, values["test_events"])
+}
+
+func TestEndpointResolverErrors(t *testing.T) {
+	api := &testAPI{}
+	srv := httptest.NewServer(api.handler())
+	defer srv.Close()
+
+	tables, err := NewTables(testEndpoints()...)
+	require.NoError(t, err)
+	require.NoError(t, transformers.TransformTables(tables))
+	users := tables.Get("test_users")
+	res := make(chan any, 10)
+
+	// status errors other than 429 and 5xx aren't retried
+	c, err := NewClient("test", srv.URL+"/v1")
+	require.NoError(t, err)
+	err = users.Resolver(context.Background(), c, nil, res)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+	assert.Equal(t, int32(1), api.requests)
+
+	err = users.Resolver(context.Background(), &testExecutionClient{}, nil, res)
+	require.ErrorContains(t, err, "doesn't implement rest.Provider")
+
+	// placeholders need a parent resource
+	c, err = NewClient("test", srv.URL+"/v1", WithAuth(BearerToken(testToken)))
+	require.NoError(t, err)
+	err = tables.Get("test_user_repos").Resolver(context.Background(), c, nil, res)
+	require.ErrorContains(t, err, "path parameter id requires a parent resource")
+}
+
+type testExecutionClient struct{}
+
+func (*testExecutionClient) ID() string {
+	return "test"
+}
+
+func TestNewTableValidation(t *testing.T) {
+	tests := []struct {
+		endpoint Endpoint
+		err      string
+	}{
+		{endpoint: Endpoint{Path: "/users", Struct: &testRepo{}}, err: "endpoint name is required"},
+		{endpoint: Endpoint{Name: "test", Struct: &testRepo{}}, err: "endpoint test: path is required"},
+		{endpoint: Endpoint{Name: "test", Path: "/users"}, err: "endpoint test: one of JSONSchema and Struct is required"},
+		{endpoint: Endpoint{Name: "test", Path: "/users", Struct: &testRepo{}, JSONSchema: []byte(testUserSchema)}, err: "only one of JSONSchema and Struct can be set"},
+		{endpoint: Endpoint{Name: "test", Path: "/users", Struct: "x"}, err: "endpoint test: expected struct, got string"},
+		{endpoint: Endpoint{Name: "test", Path: "/users", Struct: &testRepo{}, Children: []Endpoint{{Name: "child"}}}, err: "endpoint test: endpoint child: path is required"},
+	}
+	for _, tc := range tests {
+		_, err := NewTable(tc.endpoint)
+		assert.ErrorContains(t, err, tc.err)
+	}
+
+	table, err := NewTable(Endpoint{Name: "test", Path: "/users", Struct: &testRepo{}})
+	require.NoError(t, err)
+	require.NoError(t, table.Transform(table))
+	assert.True(t, arrow.TypeEqual(arrow.PrimitiveTypes.Int64, table.Columns.Get("stars").Type))
+}
+
+func TestConvertNumbers(t *testing.T) {
+	converted := convertNumbers(map[string]any{
+		"int":    json.Number("-42"),
+		"uint":   json.Number("18446744073709551615"),
+		"float":  json.Number("1.5"),
+		"nested": []any{json.Number("9223372036854775808")},
+	})
+	assert.Equal(t, map[string]any{
+		"int":    int64(-42),
+		"uint":   uint64(18446744073709551615),
+		"float":  1.5,
+		"nested": []any{uint64(9223372036854775808)},
+	}, converted)
+}
@@ -0,0 +1,202 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Paginator requests the pages of an endpoint.
+type Paginator interface {
+	// FirstPage returns the URL of the first page, e.g. with the page size parameter set.
+	FirstPage(u *url.URL) *url.URL
+	// NextPage returns the URL of the page following resp, which had the given number of items,
+	// or nil if resp is the last page.
+	NextPage(resp *Response, items int) (*url.URL, error)
+}
+
+// CursorPagination requests the next page with the cursor returned in the response body,
+// until the response doesn't have a cursor.
+type CursorPagination struct {
+	// CursorParam is the query parameter the cursor is sent in, e.g. "cursor".
+	CursorParam string
+	// CursorPath is the path of the next cursor in the response body, with keys separated by dots, e.g. "meta.next_cursor".
+	CursorPath string
+	// LimitParam is the query parameter the page size is sent in. It isn't sent if LimitParam or Limit is empty.
+	LimitParam string
+	Limit      int
+}
+
+func (p *CursorPagination) FirstPage(u *url.URL) *url.URL {
+	return withQuery(u, p.LimitParam, p.Limit)
+}
+
+func (p *CursorPagination) NextPage(resp *Response, _ int) (*url.URL, error) {
+	cursor, err := cursorString(lookupPath(resp.Body, p.CursorPath))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor at %q: %w", p.CursorPath, err)
+	}
+	if cursor == "" {
+		return nil, nil
+	}
+	next := *resp.URL
+	q := next.Query()
+	q.Set(p.CursorParam, cursor)
+	next.RawQuery = q.Encode()
+	return &next, nil
+}
+
+// OffsetPagination requests pages by offset, until a page has fewer than Limit items (or no items if Limit is 0).
+type OffsetPagination struct {
+	// OffsetParam is the query parameter the offset of the page is sent in. Defaults to "offset".
+	OffsetParam string
+	// LimitParam is the query parameter the page size is sent in. Defaults to "limit". It isn't sent if Limit is 0.
+	LimitParam string
+	Limit      int
+	// TotalPath is the optional path of the total number of items in the response body, with keys separated by dots.
+	// Pagination stops when the offset reaches the total.
+	TotalPath string
+}
+
+func (p *OffsetPagination) offsetParam() string {
+	if p.OffsetParam == "" {
+		return "offset"
+	}
+	return p.OffsetParam
+}
+
+func (p *OffsetPagination) limitParam() string {
+	if p.LimitParam == "" {
+		return "limit"
+	}
+	return p.LimitParam
+}
+
+func (p *OffsetPagination) FirstPage(u *url.URL) *url.URL {
+	return withQuery(u, p.limitParam(), p.Limit)
+}
+
+func (p *OffsetPagination) NextPage(resp *Response, items int) (*url.URL, error) {
+	if items == 0 || items < p.Limit {
+		return nil, nil
+	}
+	q := resp.URL.Query()
+	offset := 0
+	if v := q.Get(p.offsetParam()); v != "" {
+		var err error
+		if offset, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid offset %q: %w", v, err)
+		}
+	}
+	offset += items
+	if p.TotalPath != "" {
+		total, err := cursorString(lookupPath(resp.Body, p.TotalPath))
+		if err != nil {
+			return nil, fmt.Errorf("invalid total at %q: %w", p.TotalPath, err)
+		}
+		if n, err := strconv.Atoi(total); err == nil && offset >= n {
+			return nil, nil
+		}
+	}
+	next := *resp.URL
+	q.Set(p.offsetParam(), strconv.Itoa(offset))
+	next.RawQuery = q.Encode()
+	return &next, nil
+}
+
+// LinkHeaderPagination requests the next page from the rel="next" link of the Link header (RFC 8288),
+// until the response doesn't have one.
+type LinkHeaderPagination struct {
+	// LimitParam is the query parameter the page size is sent in on the first page. It isn't sent if LimitParam or Limit is empty.
+	LimitParam string
+	Limit      int
+}
+
+// reLink matches a link of a Link header and its parameters, which can have quoted strings with commas
+var reLink = regexp.MustCompile(`<([^>]*)>((?:[^,"]|"[^"]*")*)`)
+
+func (p *LinkHeaderPagination) FirstPage(u *url.URL) *url.URL {
+	return withQuery(u, p.LimitParam, p.Limit)
+}
+
+func (*LinkHeaderPagination) NextPage(resp *Response, _ int) (*url.URL, error) {
+	for _, header := range resp.Header.Values("Link") {
+		for _, link := range reLink.FindAllStringSubmatch(header, -1) {
+			if !isNextLink(link[2]) {
+				continue
+			}
+			ref, err := url.Parse(link[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid next link %q: %w", link[1], err)
+			}
+			return resp.URL.ResolveReference(ref), nil
+		}
+	}
+	return nil, nil
+}
+
+// isNextLink reports whether the link parameters (e.g. `; rel="next"`) have the "next" relation type
+func isNextLink(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+			if strings.EqualFold(rel, "next") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withQuery returns u with the query parameter set, if the parameter and value aren't empty
+func withQuery(u *url.URL, param string, value int) *url.URL {
+	if param == "" || value == 0 {
+		return u
+	}
+	next := *u
+	q := next.Query()
+	q.Set(param, strconv.Itoa(value))
+	next.RawQuery = q.Encode()
+	return &next
+}
+
+// lookupPath returns the value at the path of keys separated by dots in the decoded JSON value, or nil.
+// An empty path returns the value itself.
+func lookupPath(v any, path string) any {
+	if path == "" {
+		return v
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// cursorString returns a cursor value as a string. nil, false and empty strings mean there's no cursor.
+func cursorString(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		if v {
+			return "", fmt.Errorf("unexpected value %v", v)
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("unexpected type %T", v)
+	}
+}
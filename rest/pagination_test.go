@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResponse(t *testing.T, rawURL string, header http.Header, body string) *Response {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	resp := &Response{URL: u, Header: header}
+	if body != "" {
+		var v any
+		dec := json.NewDecoder(strings.NewReader(body))
+		dec.UseNumber()
+		require.NoError(t, dec.Decode(&v))
+		resp.Body = v
+	}
+	return resp
+}
+
+func nextURL(t *testing.T, p Paginator, resp *Response, items int) string {
+	t.Helper()
+	next, err := p.NextPage(resp, items)
+	require.NoError(t, err)
+	if next == nil {
+		return ""
+	}
+	return next.String()
+}
+
+func TestLinkHeaderPagination(t *testing.T) {
+	p := &LinkHeaderPagination{LimitParam: "per_page", Limit: 100}
+	u, _ := url.Parse("https://api.example.com/items?state=open")
+	assert.Equal(t, "https://api.example.com/items?per_page=100&state=open", p.FirstPage(u).String())
+
+	tests := []struct {
+		link string
+		next string
+	}{
+		{link: `<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=5>; rel="last"`, next: "https://api.example.com/items?page=2"},
+		{link: `<https://api.example.com/items?page=1>; rel="prev", </items?page=3>; rel=next`, next: "https://api.example.com/items?page=3"},
+		{link: `<https://api.example.com/items?page=3>; title="a, b"; rel="last next"`, next: "https://api.example.com/items?page=3"},
+		{link: `<https://api.example.com/items?page=1>; rel="prev"`, next: ""},
+		{link: ``, next: ""},
+	}
+	for _, tc := range tests {
+		header := http.Header{}
+		if tc.link != "" {
+			header.Set("Link", tc.link)
+		}
+		resp := testResponse(t, "https://api.example.com/items?page=2", header, "")
+		assert.Equal(t, tc.next, nextURL(t, p, resp, 1), tc.link)
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	p := &CursorPagination{CursorParam: "after", CursorPath: "paging.next"}
+	assert.Equal(t, "https://api.example.com/items?after=abc", nextURL(t, p, testResponse(t, "https://api.example.com/items?after=x", nil, `{"paging": {"next": "abc"}}`), 1))
+	assert.Equal(t, "https://api.example.com/items?after=42", nextURL(t, p, testResponse(t, "https://api.example.com/items", nil, `{"paging": {"next": 42}}`), 1))
+	assert.Equal(t, "", nextURL(t, p, testResponse(t, "https://api.example.com/items", nil, `{"paging": {"next": ""}}`), 1))
+	assert.Equal(t, "", nextURL(t, p, testResponse(t, "https://api.example.com/items", nil, `{"paging": {}}`), 1))
+	_, err := p.NextPage(testResponse(t, "https://api.example.com/items", nil, `{"paging": {"next": {}}}`), 1)
+	assert.ErrorContains(t, err, `invalid cursor at "paging.next"`)
+}
+
+func TestOffsetPagination(t *testing.T) {
+	p := &OffsetPagination{Limit: 10}
+	u, _ := url.Parse("https://api.example.com/items")
+	assert.Equal(t, "https://api.example.com/items?limit=10", p.FirstPage(u).String())
+	assert.Equal(t, "https://api.example.com/items?limit=10&offset=10", nextURL(t, p, testResponse(t, "https://api.example.com/items?limit=10", nil, "[]"), 10))
+	assert.Equal(t, "https://api.example.com/items?limit=10&offset=20", nextURL(t, p, testResponse(t, "https://api.example.com/items?limit=10&offset=10", nil, "[]"), 10))
+	assert.Equal(t, "", nextURL(t, p, testResponse(t, "https://api.example.com/items?limit=10&offset=10", nil, "[]"), 9))
+
+	p = &OffsetPagination{OffsetParam: "skip"}
+	assert.Equal(t, "https://api.example.com/items", p.FirstPage(u).String())
+	assert.Equal(t, "https://api.example.com/items?skip=3", nextURL(t, p, testResponse(t, "https://api.example.com/items", nil, "[]"), 3))
+	assert.Equal(t, "", nextURL(t, p, testResponse(t, "https://api.example.com/items?skip=3", nil, "[]"), 0))
+}
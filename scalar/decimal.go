@@ -1,6 +1,11 @@
 package scalar
 
 import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/apache/arrow/go/v13/arrow/decimal128"
 	"github.com/apache/arrow/go/v13/arrow/decimal256"
@@ -35,7 +40,7 @@ func (s *Decimal256) String() string {
 	if !s.Valid {
 		return nullValueStr
 	}
-	return s.Value.ToString(s.Type.Scale)
+	return formatDecimal(s.Value.BigInt(), s.Type.Scale)
 }
 
 func (s *Decimal256) Get() any {
@@ -94,11 +99,11 @@ func (s *Decimal256) Set(val any) error {
 	case uint64:
 		s.Value = decimal256.FromU64(value)
 	case string:
-		v, err := decimal256.FromString(value, s.Type.Precision, s.Type.Scale)
+		v, err := parseDecimal(value, s.Type.Precision, s.Type.Scale)
 		if err != nil {
 			return err
 		}
-		s.Value = v
+		s.Value = decimal256.FromBigInt(v)
 	case *int:
 		if value == nil {
 			s.Valid = false
@@ -165,7 +170,34 @@ func (s *Decimal256) Set(val any) error {
 			return nil
 		}
 		return s.Set(*value)
+	case big.Int:
+		return s.Set(&value)
+	case *big.Int:
+		if value == nil {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(value.String())
+	case big.Float:
+		return s.Set(&value)
+	case *big.Float:
+		if value == nil {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(value.Text('f', int(s.Type.Scale)))
+	case big.Rat:
+		return s.Set(&value)
+	case *big.Rat:
+		if value == nil {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(value.FloatString(int(s.Type.Scale)))
 	default:
+		if str, ok := decimalTypeString(val); ok {
+			return s.Set(str)
+		}
 		if originalSrc, ok := underlyingPtrType(val); ok {
 			return s.Set(originalSrc)
 		}
@@ -204,7 +236,7 @@ func (s *Decimal128) String() string {
 	if !s.Valid {
 		return nullValueStr
 	}
-	return s.Value.ToString(s.Type.Scale)
+	return formatDecimal(s.Value.BigInt(), s.Type.Scale)
 }
 
 func (s *Decimal128) Get() any {
@@ -255,11 +287,11 @@ func (s *Decimal128) Set(val any) error {
 	case uint64:
 		s.Value = decimal128.FromU64(value)
 	case string:
-		v, err := decimal128.FromString(value, s.Type.Precision, s.Type.Scale)
+		v, err := parseDecimal(value, s.Type.Precision, s.Type.Scale)
 		if err != nil {
 			return err
 		}
-		s.Value = v
+		s.Value = decimal128.FromBigInt(v)
 	case *int:
 		if value == nil {
 			s.Valid = false
@@ -326,7 +358,34 @@ func (s *Decimal128) Set(val any) error {
 			return nil
 		}
 		return s.Set(*value)
+	case big.Int:
+		return s.Set(&value)
+	case *big.Int:
+		if value == nil {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(value.String())
+	case big.Float:
+		return s.Set(&value)
+	case *big.Float:
+		if value == nil {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(value.Text('f', int(s.Type.Scale)))
+	case big.Rat:
+		return s.Set(&value)
+	case *big.Rat:
+		if value == nil {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(value.FloatString(int(s.Type.Scale)))
 	default:
+		if str, ok := decimalTypeString(val); ok {
+			return s.Set(str)
+		}
 		if originalSrc, ok := underlyingPtrType(val); ok {
 			return s.Set(originalSrc)
 		}
@@ -335,3 +394,84 @@ func (s *Decimal128) Set(val any) error {
 	s.Valid = true
 	return nil
 }
+
+// formatDecimal formats the unscaled value with scale fractional digits.
+// Unlike ToString of the arrow decimal types, it doesn't round through a limited precision big.Float.
+func formatDecimal(unscaled *big.Int, scale int32) string {
+	if scale <= 0 {
+		return new(big.Int).Mul(unscaled, pow10(-scale)).String()
+	}
+	neg := unscaled.Sign() < 0
+	digits := new(big.Int).Abs(unscaled).String()
+	if pad := int(scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	str := digits[:len(digits)-int(scale)] + "." + digits[len(digits)-int(scale):]
+	if neg {
+		return "-" + str
+	}
+	return str
+}
+
+// parseDecimal parses str to the unscaled value of a decimal with the given precision and scale,
+// rounding half away from zero like FromString of the arrow decimal types.
+// Unlike FromString, it's exact for large scales, as it doesn't scale the value with a float64 power of ten.
+func parseDecimal(str string, precision, scale int32) (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(str)
+	if !ok {
+		return nil, fmt.Errorf("cannot parse %q as decimal", str)
+	}
+	if scale >= 0 {
+		r.Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	} else {
+		r.Quo(r, new(big.Rat).SetInt(pow10(-scale)))
+	}
+	v, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Lsh(rem.Abs(rem), 1).Cmp(r.Denom()) >= 0 {
+		v.Add(v, big.NewInt(int64(r.Sign())))
+	}
+	if v.CmpAbs(pow10(precision)) >= 0 {
+		return nil, fmt.Errorf("value %s doesn't fit in precision %d", str, precision)
+	}
+	return v, nil
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// decimalTypes are the decimal types of popular packages (by package path and name), set from their String method
+var decimalTypes = map[string]bool{
+	"github.com/shopspring/decimal.Decimal":     true,
+	"github.com/cockroachdb/apd.Decimal":        true,
+	"github.com/cockroachdb/apd/v2.Decimal":     true,
+	"github.com/cockroachdb/apd/v3.Decimal":     true,
+	"github.com/ericlagergren/decimal.Big":      true,
+	"github.com/govalues/decimal.Decimal":       true,
+	"github.com/alpacahq/alpacadecimal.Decimal": true,
+}
+
+// decimalTypeString returns the String of a value of one of the decimalTypes, or a pointer to one.
+// Other types implementing fmt.Stringer aren't converted, as their String isn't necessarily a number.
+func decimalTypeString(val any) (string, bool) {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "", false
+		}
+	} else {
+		// the String method can have a pointer receiver
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr
+	}
+	t := rv.Type().Elem()
+	if !decimalTypes[t.PkgPath()+"."+t.Name()] {
+		return "", false
+	}
+	stringer, ok := rv.Interface().(fmt.Stringer)
+	if !ok {
+		return "", false
+	}
+	return stringer.String(), true
+}
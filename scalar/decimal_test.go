@@ -1,6 +1,9 @@
 package scalar
 
 import (
+	"math/big"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/apache/arrow/go/v13/arrow"
@@ -124,3 +127,103 @@ func TestDecimal256Set(t *testing.T) {
 		require.Equal(t, tt.expect, r, "Unexpected result for test %d", i)
 	}
 }
+
+func TestDecimalSetBigNumbers(t *testing.T) {
+	for _, typ := range []reflect.Type{reflect.TypeOf(decimalString{}), reflect.TypeOf(pointerDecimal{})} {
+		name := typ.PkgPath() + "." + typ.Name()
+		decimalTypes[name] = true
+		t.Cleanup(func() { delete(decimalTypes, name) })
+	}
+	bigInt, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	bigFloat := big.NewFloat(12.345)
+	bigRat := big.NewRat(1, 4)
+
+	cases := []struct {
+		source any
+		expect string
+	}{
+		{source: bigInt, expect: "123456789012345678901234567890.00"},
+		{source: *bigInt, expect: "123456789012345678901234567890.00"},
+		{source: bigFloat, expect: "12.35"},
+		{source: *bigFloat, expect: "12.35"},
+		{source: bigRat, expect: "0.25"},
+		{source: *bigRat, expect: "0.25"},
+		{source: decimalString{s: "99.5"}, expect: "99.50"},
+		{source: &decimalString{s: "99.5"}, expect: "99.50"},
+		{source: pointerDecimal{s: "-1.5"}, expect: "-1.50"},
+		{source: &pointerDecimal{s: "-1.5"}, expect: "-1.50"},
+	}
+	for _, tc := range cases {
+		d128 := &Decimal128{Type: &arrow.Decimal128Type{Precision: 38, Scale: 2}}
+		require.NoError(t, d128.Set(tc.source))
+		require.Equal(t, tc.expect, d128.String())
+
+		d256 := &Decimal256{Type: &arrow.Decimal256Type{Precision: 76, Scale: 2}}
+		require.NoError(t, d256.Set(tc.source))
+		require.Equal(t, tc.expect, d256.String())
+	}
+
+	d := &Decimal128{Type: &arrow.Decimal128Type{Precision: 38, Scale: 2}}
+	require.NoError(t, d.Set((*big.Int)(nil)))
+	require.False(t, d.IsValid())
+
+	// other types implementing fmt.Stringer aren't decimal numbers
+	require.Error(t, d.Set(notDecimal{s: "1"}))
+	require.Error(t, (&Decimal256{Type: &arrow.Decimal256Type{Precision: 76, Scale: 2}}).Set(notDecimal{s: "1"}))
+}
+
+func TestDecimalNegativeScale(t *testing.T) {
+	d128 := &Decimal128{Type: &arrow.Decimal128Type{Precision: 5, Scale: -2}}
+	require.NoError(t, d128.Set("-123456"))
+	require.Equal(t, "-123500", d128.String())
+	require.Equal(t, decimal128.FromI64(-1235), d128.Value)
+
+	d256 := &Decimal256{Type: &arrow.Decimal256Type{Precision: 5, Scale: -2}}
+	require.NoError(t, d256.Set("1200"))
+	require.Equal(t, "1200", d256.String())
+	require.NoError(t, d256.Set("0"))
+	require.Equal(t, "0", d256.String())
+}
+
+func TestDecimalSetLargeScale(t *testing.T) {
+	cases := []struct {
+		source string
+		expect string
+	}{
+		{source: "0.125", expect: "0.12500000000000000000000000000000000000"},
+		{source: "-1234.5", expect: "-1234.50000000000000000000000000000000000000"},
+		{source: "0.000000000000000000000000000000000000015", expect: "0.00000000000000000000000000000000000002"},
+		{source: "-0.000000000000000000000000000000000000015", expect: "-0.00000000000000000000000000000000000002"},
+		{source: "1e-38", expect: "0.00000000000000000000000000000000000001"},
+	}
+	for _, tc := range cases {
+		d := &Decimal256{Type: &arrow.Decimal256Type{Precision: 76, Scale: 38}}
+		require.NoError(t, d.Set(tc.source))
+		require.Equal(t, tc.expect, d.String())
+	}
+
+	d := &Decimal256{Type: &arrow.Decimal256Type{Precision: 76, Scale: 38}}
+	require.Error(t, d.Set("1"+strings.Repeat("0", 38)))
+	require.Error(t, d.Set("not a number"))
+}
+
+// decimalString is a decimal type formatting as a plain decimal number, like most decimal packages
+type decimalString struct {
+	s string
+}
+
+func (d decimalString) String() string { return d.s }
+
+// pointerDecimal is a decimal type with a String method with a pointer receiver
+type pointerDecimal struct {
+	s string
+}
+
+func (d *pointerDecimal) String() string { return d.s }
+
+// notDecimal implements fmt.Stringer, but isn't one of the known decimal types
+type notDecimal struct {
+	s string
+}
+
+func (d notDecimal) String() string { return d.s }
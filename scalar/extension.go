@@ -0,0 +1,141 @@
+package scalar
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+	"golang.org/x/exp/rand"
+)
+
+// Extension describes a plugin-defined arrow extension type (e.g. ARN, CIDR or semver)
+// together with everything the SDK needs to resolve, test and fake values of that type.
+type Extension struct {
+	// Type is the arrow extension type. Required.
+	Type arrow.ExtensionType
+	// New returns a new (null) scalar of the extension type. Required.
+	New func() Scalar
+	// Append appends a valid scalar of the extension type to the builder.
+	// If nil, the value is appended with the builder's AppendValueFromString using the scalar's String value.
+	Append func(bldr array.Builder, s Scalar)
+	// Example returns a JSON encoded example value, used by schema.TestDataGenerator.
+	// If nil, an example of the storage type is used.
+	Example func(rnd *rand.Rand) string
+	// GoType is the Go type that holds values of the extension type.
+	// If set, the struct transformer maps fields of this type to the extension type and
+	// faker.FakeObject uses Fake to create values for them.
+	GoType reflect.Type
+	// Fake returns a fake value convertible to GoType
+	Fake func() any
+}
+
+var (
+	extensionsMu sync.RWMutex
+	extensions   = make(map[string]Extension)
+)
+
+// RegisterExtension registers a plugin-defined extension type.
+// NewScalar, AppendToBuilder, schema.TestDataGenerator and types.RegisterAllExtensions consult the registered extensions,
+// so this should be called before the plugin is served (e.g. in an init function). Extensions registered after
+// types.RegisterAllExtensions are registered with arrow immediately.
+func RegisterExtension(ext Extension) error {
+	if ext.Type == nil {
+		return fmt.Errorf("extension type is required")
+	}
+	name := ext.Type.ExtensionName()
+	if ext.New == nil {
+		return fmt.Errorf("extension %s: New is required", name)
+	}
+	if dt := ext.New().DataType(); !arrow.TypeEqual(dt, ext.Type) {
+		return fmt.Errorf("extension %s: New returned a scalar of type %s", name, dt)
+	}
+	if ext.Fake != nil && ext.GoType == nil {
+		return fmt.Errorf("extension %s: Fake requires GoType", name)
+	}
+
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	if _, ok := extensions[name]; ok {
+		return fmt.Errorf("extension %s is already registered", name)
+	}
+	if ext.GoType != nil {
+		for _, e := range extensions {
+			if e.GoType == ext.GoType {
+				return fmt.Errorf("extension %s: Go type %s is already used by extension %s", name, ext.GoType, e.Type.ExtensionName())
+			}
+		}
+	}
+	if err := types.AddCustomExtension(ext.Type); err != nil {
+		return err
+	}
+	extensions[name] = ext
+	return nil
+}
+
+// UnregisterExtension removes an extension registered with RegisterExtension, unregistering it from arrow
+// if it was registered by types.RegisterAllExtensions.
+func UnregisterExtension(name string) error {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	delete(extensions, name)
+	return types.RemoveCustomExtension(name)
+}
+
+// Extensions returns the registered extensions, sorted by extension name
+func Extensions() []Extension {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	exts := make([]Extension, 0, len(extensions))
+	for _, ext := range extensions {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		return exts[i].Type.ExtensionName() < exts[j].Type.ExtensionName()
+	})
+	return exts
+}
+
+// ExtensionFor returns the registered extension for the given data type
+func ExtensionFor(dt arrow.DataType) (Extension, bool) {
+	extType, ok := dt.(arrow.ExtensionType)
+	if !ok {
+		return Extension{}, false
+	}
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	ext, ok := extensions[extType.ExtensionName()]
+	if !ok || !arrow.TypeEqual(ext.Type, dt) {
+		return Extension{}, false
+	}
+	return ext, true
+}
+
+// ExtensionForGoType returns the registered extension that holds values of the given Go type
+func ExtensionForGoType(t reflect.Type) (Extension, bool) {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	for _, ext := range extensions {
+		if ext.GoType != nil && ext.GoType == t {
+			return ext, true
+		}
+	}
+	return Extension{}, false
+}
+
+func appendExtensionToBuilder(bldr array.Builder, s Scalar) {
+	ext, ok := ExtensionFor(s.DataType())
+	if !ok {
+		panic("not implemented extension: " + s.DataType().Name())
+	}
+	if ext.Append != nil {
+		ext.Append(bldr, s)
+		return
+	}
+	if err := bldr.AppendValueFromString(s.String()); err != nil {
+		panic(fmt.Sprintf("failed to append extension %s value: %v", s.DataType().Name(), err))
+	}
+}
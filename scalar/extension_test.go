@@ -0,0 +1,176 @@
+package scalar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+)
+
+type arnArray struct {
+	array.ExtensionArrayBase
+}
+
+type arnType struct {
+	arrow.ExtensionBase
+}
+
+func newARNType() *arnType {
+	return &arnType{ExtensionBase: arrow.ExtensionBase{Storage: arrow.BinaryTypes.String}}
+}
+
+func (*arnType) ArrayType() reflect.Type { return reflect.TypeOf(arnArray{}) }
+
+func (*arnType) ExtensionName() string { return "test.arn" }
+
+func (*arnType) Serialize() string { return "test.arn" }
+
+func (*arnType) Deserialize(arrow.DataType, string) (arrow.ExtensionType, error) {
+	return newARNType(), nil
+}
+
+func (e *arnType) ExtensionEquals(other arrow.ExtensionType) bool {
+	return e.ExtensionName() == other.ExtensionName()
+}
+
+type arn struct {
+	value String
+}
+
+func (s *arn) IsValid() bool          { return s.value.IsValid() }
+func (*arn) DataType() arrow.DataType { return newARNType() }
+func (s *arn) String() string         { return s.value.String() }
+func (s *arn) Get() any               { return s.value.Get() }
+func (s *arn) Set(val any) error {
+	if err := s.value.Set(val); err != nil {
+		return err
+	}
+	if s.value.Valid && !strings.HasPrefix(s.value.Value, "arn:") {
+		return &ValidationError{Type: s.DataType(), Msg: "invalid arn", Value: val}
+	}
+	return nil
+}
+func (s *arn) Equal(rhs Scalar) bool {
+	r, ok := rhs.(*arn)
+	return ok && s.value.Equal(&r.value)
+}
+
+func registerARN(t *testing.T) {
+	t.Helper()
+	if err := RegisterExtension(Extension{
+		Type: newARNType(),
+		New:  func() Scalar { return &arn{} },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := UnregisterExtension("test.arn"); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestRegisterExtension(t *testing.T) {
+	registerARN(t)
+
+	if _, ok := ExtensionFor(newARNType()); !ok {
+		t.Fatal("expected extension to be registered")
+	}
+	if err := RegisterExtension(Extension{Type: newARNType(), New: func() Scalar { return &arn{} }}); err == nil {
+		t.Fatal("expected error registering the same extension twice")
+	}
+	if err := RegisterExtension(Extension{Type: types.NewUUIDType(), New: func() Scalar { return &UUID{} }}); err == nil {
+		t.Fatal("expected error registering a built-in extension")
+	}
+	if err := RegisterExtension(Extension{Type: newARNType(), New: func() Scalar { return &String{} }}); err == nil {
+		t.Fatal("expected error registering an extension with a mismatched scalar")
+	}
+	if len(Extensions()) != 1 {
+		t.Fatalf("expected 1 extension, got %d", len(Extensions()))
+	}
+
+	if err := types.RegisterAllExtensions(); err != nil {
+		t.Fatal(err)
+	}
+	if arrow.GetExtensionType("test.arn") == nil {
+		t.Fatal("expected extension to be registered with arrow")
+	}
+	if err := types.UnregisterAllExtensions(); err != nil {
+		t.Fatal(err)
+	}
+	if arrow.GetExtensionType("test.arn") != nil {
+		t.Fatal("expected extension to be unregistered from arrow")
+	}
+}
+
+func TestRegisterExtensionAfterRegisterAll(t *testing.T) {
+	if err := types.RegisterAllExtensions(); err != nil {
+		t.Fatal(err)
+	}
+	registerARN(t)
+	if arrow.GetExtensionType("test.arn") == nil {
+		t.Fatal("expected extension registered after RegisterAllExtensions to be registered with arrow")
+	}
+	if err := UnregisterExtension("test.arn"); err != nil {
+		t.Fatal(err)
+	}
+	if arrow.GetExtensionType("test.arn") != nil {
+		t.Fatal("expected unregistered extension to be unregistered from arrow")
+	}
+
+	registerARN(t)
+	if err := types.UnregisterAllExtensions(); err != nil {
+		t.Fatal(err)
+	}
+	if arrow.GetExtensionType("test.arn") != nil || arrow.GetExtensionType(types.ExtensionTypes.UUID.ExtensionName()) != nil {
+		t.Fatal("expected extensions to be unregistered from arrow")
+	}
+	// unregistering an extension that isn't registered with arrow anymore succeeds
+	if err := UnregisterExtension("test.arn"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtensionScalar(t *testing.T) {
+	registerARN(t)
+
+	dt := newARNType()
+	bldr := array.NewBuilder(memory.DefaultAllocator, dt)
+	defer bldr.Release()
+
+	s := NewScalar(dt)
+	if err := s.Set("not an arn"); err == nil {
+		t.Fatal("expected error setting an invalid arn")
+	}
+	if err := s.Set("arn:aws:s3:::bucket"); err != nil {
+		t.Fatal(err)
+	}
+	AppendToBuilder(bldr, s)
+	AppendToBuilder(bldr, NewScalar(dt))
+
+	arr := bldr.NewArray()
+	defer arr.Release()
+	if !arrow.TypeEqual(arr.DataType(), dt) {
+		t.Fatalf("unexpected array type %s", arr.DataType())
+	}
+	if arr.Len() != 2 || !arr.IsNull(1) {
+		t.Fatalf("unexpected array %v", arr)
+	}
+	if got := arr.(array.ExtensionArray).Storage().ValueStr(0); got != "arn:aws:s3:::bucket" {
+		t.Fatalf("unexpected value %s", got)
+	}
+
+	if err := UnregisterExtension("test.arn"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected NewScalar to panic for an unregistered extension")
+		}
+	}()
+	NewScalar(dt)
+}
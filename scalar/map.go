@@ -0,0 +1,209 @@
+package scalar
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+)
+
+// MapItem is a single key-value pair of a Map
+type MapItem struct {
+	Key   Scalar
+	Value Scalar
+}
+
+type Map struct {
+	Valid bool
+	// Value holds the map items sorted by key, so that maps set from Go maps are deterministic
+	Value []MapItem
+	Type  *arrow.MapType
+}
+
+func (s *Map) IsValid() bool {
+	return s.Valid
+}
+
+func (s *Map) DataType() arrow.DataType {
+	return s.Type
+}
+
+func (s *Map) String() string {
+	if !s.Valid {
+		return nullValueStr
+	}
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, item := range s.Value {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(item.Key.String())
+		sb.WriteString(": ")
+		sb.WriteString(item.Value.String())
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (s *Map) Equal(rhs Scalar) bool {
+	if rhs == nil {
+		return false
+	}
+	r, ok := rhs.(*Map)
+	if !ok {
+		return false
+	}
+	if s.Valid != r.Valid {
+		return false
+	}
+	if len(s.Value) != len(r.Value) {
+		return false
+	}
+	for i := range s.Value {
+		if !s.Value[i].Key.Equal(r.Value[i].Key) || !s.Value[i].Value.Equal(r.Value[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Map) Get() any {
+	return s.Value
+}
+
+func (s *Map) Set(val any) error {
+	if val == nil {
+		s.Valid = false
+		return nil
+	}
+	if s.Type == nil {
+		panic("Map type is nil")
+	}
+
+	if sc, ok := val.(Scalar); ok {
+		if !sc.IsValid() {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(sc.Get())
+	}
+
+	switch value := val.(type) {
+	case []MapItem:
+		items := make([]MapItem, len(value))
+		for i, item := range value {
+			k, v := NewScalar(s.Type.KeyType()), NewScalar(s.Type.ItemType())
+			if err := k.Set(item.Key); err != nil {
+				return err
+			}
+			if err := v.Set(item.Value); err != nil {
+				return err
+			}
+			items[i] = MapItem{Key: k, Value: v}
+		}
+		s.Value = items
+		s.Valid = true
+		return nil
+	case string:
+		return s.unmarshal([]byte(value))
+	case []byte:
+		if value == nil {
+			s.Valid = false
+			return nil
+		}
+		return s.unmarshal(value)
+	case []any:
+		// this is the format arrow uses for map values: [{"key": k, "value": v}, ...]
+		items := make([]MapItem, 0, len(value))
+		for _, elem := range value {
+			kv, ok := elem.(map[string]any)
+			if !ok {
+				return &ValidationError{Type: s.DataType(), Msg: "map entries must be objects with key and value", Value: val}
+			}
+			k, v := NewScalar(s.Type.KeyType()), NewScalar(s.Type.ItemType())
+			if err := k.Set(kv["key"]); err != nil {
+				return err
+			}
+			if !k.IsValid() {
+				return &ValidationError{Type: s.DataType(), Msg: "map keys can't be null", Value: val}
+			}
+			if err := v.Set(kv["value"]); err != nil {
+				return err
+			}
+			items = append(items, MapItem{Key: k, Value: v})
+		}
+		s.Value = items
+		s.Valid = true
+		return nil
+	case *string:
+		if value == nil {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(*value)
+	}
+
+	reflectedValue := reflect.ValueOf(val)
+	if reflectedValue.Kind() == reflect.Pointer {
+		if reflectedValue.IsNil() {
+			s.Valid = false
+			return nil
+		}
+		return s.Set(reflectedValue.Elem().Interface())
+	}
+	if reflectedValue.Kind() != reflect.Map {
+		return &ValidationError{Type: s.DataType(), Msg: noConversion, Value: val}
+	}
+	if reflectedValue.IsNil() {
+		s.Valid = false
+		return nil
+	}
+
+	items := make([]MapItem, 0, reflectedValue.Len())
+	iter := reflectedValue.MapRange()
+	for iter.Next() {
+		k, v := NewScalar(s.Type.KeyType()), NewScalar(s.Type.ItemType())
+		if err := k.Set(iter.Key().Interface()); err != nil {
+			return err
+		}
+		if !k.IsValid() {
+			return &ValidationError{Type: s.DataType(), Msg: "map keys can't be null", Value: val}
+		}
+		if err := v.Set(iter.Value().Interface()); err != nil {
+			return err
+		}
+		items = append(items, MapItem{Key: k, Value: v})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Key.String() < items[j].Key.String()
+	})
+	s.Value = items
+	s.Valid = true
+	return nil
+}
+
+// unmarshal accepts both a JSON object and the arrow JSON representation of a map (a list of key-value objects)
+func (s *Map) unmarshal(b []byte) error {
+	var x any
+	if err := json.Unmarshal(b, &x); err != nil {
+		return &ValidationError{Type: s.DataType(), Msg: "failed to unmarshal map", Err: err, Value: string(b)}
+	}
+	switch x.(type) {
+	case nil, map[string]any, []any:
+		return s.Set(x)
+	default:
+		return &ValidationError{Type: s.DataType(), Msg: noConversion, Value: string(b)}
+	}
+}
+
+func appendMapToBuilder(bldr *array.MapBuilder, s *Map) {
+	bldr.Append(true)
+	for _, item := range s.Value {
+		AppendToBuilder(bldr.KeyBuilder(), item.Key)
+		AppendToBuilder(bldr.ItemBuilder(), item.Value)
+	}
+}
@@ -0,0 +1,114 @@
+package scalar
+
+import (
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+)
+
+func TestMapSet(t *testing.T) {
+	mapType := arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Int64)
+	expected := Map{Value: []MapItem{
+		{Key: &String{Value: "a", Valid: true}, Value: &Int{Value: 1, Valid: true}},
+		{Key: &String{Value: "b", Valid: true}, Value: &Int{Value: 2, Valid: true}},
+	}, Valid: true, Type: mapType}
+
+	successfulTests := []struct {
+		source any
+		result Map
+	}{
+		{source: map[string]int{"b": 2, "a": 1}, result: expected},
+		{source: map[string]int64{"a": 1, "b": 2}, result: expected},
+		{source: &map[string]any{"a": 1, "b": "2"}, result: expected},
+		{source: `{"a": 1, "b": 2}`, result: expected},
+		{source: []byte(`{"b": 2, "a": 1}`), result: expected},
+		{source: `[{"key": "a", "value": 1}, {"key": "b", "value": 2}]`, result: expected},
+		{source: &expected, result: expected},
+		{source: map[string]int{}, result: Map{Value: []MapItem{}, Valid: true, Type: mapType}},
+		{source: map[string]int(nil), result: Map{Type: mapType}},
+		{source: nil, result: Map{Type: mapType}},
+	}
+
+	for i, tt := range successfulTests {
+		r := Map{
+			Type: mapType,
+		}
+		err := r.Set(tt.source)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
+
+		if !r.Equal(&tt.result) {
+			t.Errorf("%d: %v != %v", i, r.String(), tt.result.String())
+		}
+	}
+
+	failingTests := []any{
+		[]int{1, 2},
+		`{"a": "b"}`,
+		`not a map`,
+		`[1, 2]`,
+		`[{"key": null, "value": 1}]`,
+		map[string]any{"a": []int{1}},
+	}
+	for i, source := range failingTests {
+		r := Map{Type: mapType}
+		if err := r.Set(source); err == nil {
+			t.Errorf("%d: expected error for %v", i, source)
+		}
+	}
+}
+
+func TestMapString(t *testing.T) {
+	r := Map{Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Int64)}
+	if r.String() != nullValueStr {
+		t.Errorf("expected %q, got %q", nullValueStr, r.String())
+	}
+	if err := r.Set(map[string]int{"b": 2, "a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "{a: 1, b: 2}" {
+		t.Errorf("unexpected string %q", r.String())
+	}
+}
+
+func TestMapAppendToBuilder(t *testing.T) {
+	mapType := arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Int64)
+	bldr := array.NewBuilder(memory.DefaultAllocator, mapType)
+	defer bldr.Release()
+
+	for _, v := range []any{map[string]int{"a": 1, "b": 2}, nil, map[string]int{}} {
+		s := NewScalar(mapType)
+		if err := s.Set(v); err != nil {
+			t.Fatal(err)
+		}
+		AppendToBuilder(bldr, s)
+	}
+
+	arr := bldr.NewArray().(*array.Map)
+	defer arr.Release()
+	if arr.Len() != 3 {
+		t.Fatalf("expected 3 rows, got %d", arr.Len())
+	}
+	if !arr.IsNull(1) {
+		t.Errorf("expected row 1 to be null")
+	}
+	if got := arr.ValueStr(0); got != `[{"key":"a","value":1},{"key":"b","value":2}]` {
+		t.Errorf("unexpected value %s", got)
+	}
+
+	// round-trip through the array
+	expected := NewScalar(mapType)
+	if err := expected.Set(map[string]int{"a": 1, "b": 2}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewScalar(mapType)
+	if err := s.Set(arr.ValueStr(0)); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Equal(expected) {
+		t.Errorf("%v != %v", s, expected)
+	}
+}
@@ -0,0 +1,269 @@
+package scalar
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+)
+
+// FromArray returns the value at index i of the array as a scalar.
+// It supports every type NewScalar supports, including registered extension types.
+func FromArray(arr arrow.Array, i int) (Scalar, error) {
+	if i < 0 || i >= arr.Len() {
+		return nil, fmt.Errorf("index %d out of range [0, %d)", i, arr.Len())
+	}
+	s, err := tryNewScalar(arr.DataType())
+	if err != nil {
+		return nil, err
+	}
+	if arr.IsNull(i) {
+		return s, nil
+	}
+	if err := setFromArray(s, arr, i); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func tryNewScalar(dt arrow.DataType) (s Scalar, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unsupported type %s: %v", dt, r)
+		}
+	}()
+	return NewScalar(dt), nil
+}
+
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
+// setFromArray sets the scalar to the (non-null) value at index i of the array
+func setFromArray(s Scalar, arr arrow.Array, i int) error {
+	switch s := s.(type) {
+	case *Bool:
+		s.Value, s.Valid = arr.(*array.Boolean).Value(i), true
+	case *Int:
+		switch a := arr.(type) {
+		case *array.Int8:
+			s.Value = int64(a.Value(i))
+		case *array.Int16:
+			s.Value = int64(a.Value(i))
+		case *array.Int32:
+			s.Value = int64(a.Value(i))
+		case *array.Int64:
+			s.Value = a.Value(i)
+		default:
+			return fmt.Errorf("unexpected array %T for %s", arr, arr.DataType())
+		}
+		s.Valid = true
+	case *Uint:
+		switch a := arr.(type) {
+		case *array.Uint8:
+			s.Value = uint64(a.Value(i))
+		case *array.Uint16:
+			s.Value = uint64(a.Value(i))
+		case *array.Uint32:
+			s.Value = uint64(a.Value(i))
+		case *array.Uint64:
+			s.Value = a.Value(i)
+		default:
+			return fmt.Errorf("unexpected array %T for %s", arr, arr.DataType())
+		}
+		s.Valid = true
+	case *Float:
+		switch a := arr.(type) {
+		case *array.Float16:
+			s.Value = float64(a.Value(i).Float32())
+		case *array.Float32:
+			s.Value = float64(a.Value(i))
+		case *array.Float64:
+			s.Value = a.Value(i)
+		default:
+			return fmt.Errorf("unexpected array %T for %s", arr, arr.DataType())
+		}
+		s.Valid = true
+	case *String:
+		s.Value, s.Valid = arr.(*array.String).Value(i), true
+	case *LargeString:
+		return s.Set(arr.(*array.LargeString).Value(i))
+	case *Binary:
+		s.Value, s.Valid = copyBytes(arr.(*array.Binary).Value(i)), true
+	case *LargeBinary:
+		s.Value, s.Valid = copyBytes(arr.(*array.LargeBinary).Value(i)), true
+	case *Timestamp:
+		return s.Set(arr.(*array.Timestamp).Value(i).ToTime(s.Type.Unit))
+	case *Date32:
+		s.Value, s.Valid = arr.(*array.Date32).Value(i), true
+	case *Date64:
+		s.Value, s.Valid = arr.(*array.Date64).Value(i), true
+	case *Time:
+		switch a := arr.(type) {
+		case *array.Time32:
+			s.Value = int64(a.Value(i))
+		case *array.Time64:
+			s.Value = int64(a.Value(i))
+		default:
+			return fmt.Errorf("unexpected array %T for %s", arr, arr.DataType())
+		}
+		s.Valid = true
+	case *Duration:
+		s.Value, s.Valid = int64(arr.(*array.Duration).Value(i)), true
+	case *MonthInterval:
+		s.Value, s.Valid = int64(arr.(*array.MonthInterval).Value(i)), true
+	case *DayTimeInterval:
+		s.Value, s.Valid = arr.(*array.DayTimeInterval).Value(i), true
+	case *MonthDayNanoInterval:
+		s.Value, s.Valid = arr.(*array.MonthDayNanoInterval).Value(i), true
+	case *Decimal128:
+		s.Value, s.Valid = arr.(*array.Decimal128).Value(i), true
+	case *Decimal256:
+		s.Value, s.Valid = arr.(*array.Decimal256).Value(i), true
+	case *List:
+		a := arr.(*array.List)
+		start, end := a.ValueOffsets(i)
+		s.Value = make(Vector, 0, end-start)
+		for j := start; j < end; j++ {
+			v, err := FromArray(a.ListValues(), int(j))
+			if err != nil {
+				return err
+			}
+			s.Value = append(s.Value, v)
+		}
+		s.Valid = true
+	case *Map:
+		a := arr.(*array.Map)
+		start, end := a.ValueOffsets(i)
+		s.Value = make([]MapItem, 0, end-start)
+		for j := start; j < end; j++ {
+			k, err := FromArray(a.Keys(), int(j))
+			if err != nil {
+				return err
+			}
+			v, err := FromArray(a.Items(), int(j))
+			if err != nil {
+				return err
+			}
+			s.Value = append(s.Value, MapItem{Key: k, Value: v})
+		}
+		s.Valid = true
+	case *Struct:
+		a := arr.(*array.Struct)
+		m := make(map[string]any, a.NumField())
+		for f, field := range s.Type.Fields() {
+			v, err := FromArray(a.Field(f), i)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			switch {
+			case !v.IsValid():
+				m[field.Name] = nil
+			case arrow.TypeEqual(field.Type, types.ExtensionTypes.JSON):
+				// keep JSON values encoded as JSON (and not as base64) when the struct is encoded
+				m[field.Name] = json.RawMessage(v.(*JSON).Value)
+			default:
+				m[field.Name] = v.Get()
+			}
+		}
+		s.Value, s.Valid = m, true
+	case *UUID:
+		s.Value, s.Valid = arr.(*types.UUIDArray).Value(i), true
+	case *JSON:
+		s.Value, s.Valid = copyBytes(arr.(*types.JSONArray).Storage().(*array.Binary).Value(i)), true
+	case *Mac:
+		s.Value, s.Valid = copyBytes(arr.(*types.MACArray).Value(i)), true
+	case *Inet:
+		return s.Set(arr.(*types.InetArray).Value(i))
+	default:
+		// registered extension types are set from the value of the storage type
+		ext, ok := arr.(array.ExtensionArray)
+		if !ok {
+			return fmt.Errorf("unsupported scalar %T for %s", s, arr.DataType())
+		}
+		storage, err := FromArray(ext.Storage(), i)
+		if err != nil {
+			return err
+		}
+		return s.Set(storage.Get())
+	}
+	return nil
+}
+
+// VectorFromRecord returns the values of the given row of the record as a vector
+func VectorFromRecord(rec arrow.Record, row int) (Vector, error) {
+	v := make(Vector, rec.NumCols())
+	for i, col := range rec.Columns() {
+		s, err := FromArray(col, row)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", rec.ColumnName(i), err)
+		}
+		v[i] = s
+	}
+	return v, nil
+}
+
+// Values returns the Go values of the vector, with nil for null values
+func (v Vector) Values() []any {
+	values := make([]any, len(v))
+	for i, s := range v {
+		if s.IsValid() {
+			values[i] = s.Get()
+		}
+	}
+	return values
+}
+
+// RowIterator iterates over the rows of an arrow.Record, converting each row to a vector.
+//
+//	it := scalar.NewRowIterator(rec)
+//	for it.Next() {
+//		row := it.Vector()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RowIterator struct {
+	rec arrow.Record
+	row int
+	cur Vector
+	err error
+}
+
+func NewRowIterator(rec arrow.Record) *RowIterator {
+	return &RowIterator{rec: rec, row: -1}
+}
+
+// Next advances to the next row. It returns false when there are no more rows or a row failed to convert.
+func (it *RowIterator) Next() bool {
+	if it.err != nil || it.row+1 >= int(it.rec.NumRows()) {
+		it.cur = nil
+		return false
+	}
+	it.row++
+	it.cur, it.err = VectorFromRecord(it.rec, it.row)
+	return it.err == nil
+}
+
+// Row returns the index of the current row
+func (it *RowIterator) Row() int {
+	return it.row
+}
+
+// Vector returns the current row
+func (it *RowIterator) Vector() Vector {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *RowIterator) Err() error {
+	if it.err != nil {
+		return fmt.Errorf("row %d: %w", it.row, it.err)
+	}
+	return nil
+}
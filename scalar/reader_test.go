@@ -0,0 +1,79 @@
+package scalar
+
+import (
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromArray(t *testing.T) {
+	registerARN(t)
+
+	sc := arrow.NewSchema([]arrow.Field{
+		{Name: "int", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+		{Name: "string", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "list", Type: arrow.ListOf(arrow.PrimitiveTypes.Int64), Nullable: true},
+		{Name: "map", Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Int64), Nullable: true},
+		{Name: "arn", Type: newARNType(), Nullable: true},
+	}, nil)
+	rows := [][]any{
+		{int32(1), "a", []int{1, 2}, map[string]int{"k": 1}, "arn:aws:s3:::a"},
+		{nil, nil, nil, nil, nil},
+	}
+	bldr := array.NewRecordBuilder(memory.DefaultAllocator, sc)
+	defer bldr.Release()
+	expected := make([]Vector, len(rows))
+	for r, row := range rows {
+		expected[r] = make(Vector, len(row))
+		for i, v := range row {
+			s := NewScalar(sc.Field(i).Type)
+			require.NoError(t, s.Set(v))
+			expected[r][i] = s
+		}
+		AppendToRecordBuilder(bldr, expected[r])
+	}
+	rec := bldr.NewRecord()
+	defer rec.Release()
+
+	it := NewRowIterator(rec)
+	for it.Next() {
+		assert.Truef(t, expected[it.Row()].Equal(it.Vector()), "row %d: got %v, want %v", it.Row(), it.Vector(), expected[it.Row()])
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, 1, it.Row())
+	assert.Nil(t, it.Vector())
+
+	v, err := VectorFromRecord(rec, 0)
+	require.NoError(t, err)
+	values := v.Values()
+	assert.Equal(t, int64(1), values[0])
+	assert.Equal(t, "a", values[1])
+	assert.Equal(t, "arn:aws:s3:::a", values[4])
+	v, err = VectorFromRecord(rec, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []any{nil, nil, nil, nil, nil}, v.Values())
+
+	_, err = FromArray(rec.Column(0), 2)
+	assert.Error(t, err)
+}
+
+func TestFromArrayUnsupportedType(t *testing.T) {
+	bldr := array.NewBuilder(memory.DefaultAllocator, arrow.LargeListOf(arrow.PrimitiveTypes.Int64))
+	defer bldr.Release()
+	bldr.AppendNull()
+	arr := bldr.NewArray()
+	defer arr.Release()
+
+	_, err := FromArray(arr, 0)
+	assert.Error(t, err)
+
+	rec := array.NewRecord(arrow.NewSchema([]arrow.Field{{Name: "large_list", Type: arr.DataType(), Nullable: true}}, nil), []arrow.Array{arr}, 1)
+	defer rec.Release()
+	it := NewRowIterator(rec)
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
@@ -0,0 +1,53 @@
+package scalar_test
+
+import (
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFromArrayTestTable reads every value of every type of schema.TestTable with FromArray,
+// and checks that appending the scalars to a builder results in the same array.
+func TestFromArrayTestTable(t *testing.T) {
+	table := schema.TestTable("test_from_array", schema.TestSourceOptions{})
+	// the test table has no decimal256 column
+	table.Columns = append(table.Columns, schema.Column{Name: "decimal256", Type: &arrow.Decimal256Type{Precision: 40, Scale: 10}})
+	tg := schema.NewTestDataGenerator()
+	opts := schema.GenTestDataOptions{MaxRows: 2, Seed: 1}
+	edgeCases := opts
+	edgeCases.EdgeCaseRatio = 1
+	nulls := opts
+	nulls.NullRatio = 1
+	var records []arrow.Record
+	for _, o := range []schema.GenTestDataOptions{opts, edgeCases, nulls} {
+		rec := tg.GenerateRecord(table, o)
+		defer rec.Release()
+		records = append(records, rec)
+	}
+
+	for i, c := range table.Columns {
+		i := i
+		t.Run(c.Name, func(t *testing.T) {
+			for _, rec := range records {
+				arr := rec.Column(i)
+				bldr := array.NewBuilder(memory.DefaultAllocator, arr.DataType())
+				defer bldr.Release()
+				for row := 0; row < arr.Len(); row++ {
+					s, err := scalar.FromArray(arr, row)
+					require.NoError(t, err)
+					require.Truef(t, arrow.TypeEqual(arr.DataType(), s.DataType()), "expected type %s, got %s", arr.DataType(), s.DataType())
+					scalar.AppendToBuilder(bldr, s)
+				}
+				got := bldr.NewArray()
+				defer got.Release()
+				assert.Truef(t, array.Equal(arr, got), "expected %v, got %v", arr, got)
+			}
+		})
+	}
+}
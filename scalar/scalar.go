@@ -100,12 +100,19 @@ func NewScalar(dt arrow.DataType) Scalar {
 		case arrow.TypeEqual(dt, types.ExtensionTypes.Inet):
 			return &Inet{}
 		default:
+			if ext, ok := ExtensionFor(dt); ok {
+				return ext.New()
+			}
 			panic("not implemented extension: " + dt.Name())
 		}
 	case arrow.LIST:
 		return &List{
 			Type: dt,
 		}
+	case arrow.MAP:
+		return &Map{
+			Type: dt.(*arrow.MapType),
+		}
 	case arrow.DATE64:
 		return &Date64{}
 	case arrow.DATE32:
@@ -236,6 +243,8 @@ func AppendToBuilder(bldr array.Builder, s Scalar) {
 		for _, v := range s.(*List).Value {
 			AppendToBuilder(lb.ValueBuilder(), v)
 		}
+	case arrow.MAP:
+		appendMapToBuilder(bldr.(*array.MapBuilder), s.(*Map))
 	case arrow.EXTENSION:
 		switch {
 		case arrow.TypeEqual(s.DataType(), types.ExtensionTypes.UUID):
@@ -247,7 +256,7 @@ func AppendToBuilder(bldr array.Builder, s Scalar) {
 		case arrow.TypeEqual(s.DataType(), types.ExtensionTypes.Inet):
 			bldr.(*types.InetBuilder).Append(s.(*Inet).Value)
 		default:
-			panic("not implemented extension: " + s.DataType().Name())
+			appendExtensionToBuilder(bldr, s)
 		}
 	default:
 		panic("not implemented: " + s.DataType().String())
@@ -1,6 +1,7 @@
 package scalar
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"reflect"
@@ -42,6 +43,10 @@ func (s *Struct) Get() any {
 	return s.Value
 }
 
+// Set sets the value from a map[string]any, a JSON object (as a string or []byte), or a Go struct (or pointer to struct).
+// Go structs are converted to a map[string]any with their JSON encoding, so their JSON field names must match the
+// field names of the struct type. They used to be stored as-is, which failed when the scalar was appended to a record.
+// Any other value is stored as-is.
 func (s *Struct) Set(val any) error {
 	if val == nil {
 		s.Valid = false
@@ -62,27 +67,8 @@ func (s *Struct) Set(val any) error {
 		if err := json.Unmarshal([]byte(value), &x); err != nil {
 			return err
 		}
-		for name := range x {
-			if f, ok := s.Type.FieldByName(name); ok {
-				xs, ok := x[name].(string)
-				if !ok {
-					continue
-				}
-				switch {
-				case arrow.TypeEqual(f.Type, arrow.BinaryTypes.Binary):
-					v, err := base64.StdEncoding.DecodeString(xs)
-					if err != nil {
-						return err
-					}
-					x[name] = v
-				case arrow.TypeEqual(f.Type, arrow.BinaryTypes.LargeBinary):
-					v, err := base64.StdEncoding.DecodeString(xs)
-					if err != nil {
-						return err
-					}
-					x[name] = v
-				}
-			}
+		if err := decodeJSONFields(x, s.Type); err != nil {
+			return err
 		}
 		s.Value = x
 
@@ -101,6 +87,9 @@ func (s *Struct) Set(val any) error {
 		return s.Set(*value)
 
 	default:
+		if rv := reflect.Indirect(reflect.ValueOf(val)); rv.Kind() == reflect.Struct {
+			return s.setGoStruct(val)
+		}
 		s.Value = val
 	}
 
@@ -116,3 +105,68 @@ func (s *Struct) Set(val any) error {
 func (s *Struct) DataType() arrow.DataType {
 	return s.Type
 }
+
+// setGoStruct sets the value from a Go struct (or pointer to struct) using its JSON encoding,
+// so the fields of the struct type are expected to be named after the JSON fields (see transformers.WithArrowStructs).
+func (s *Struct) setGoStruct(val any) error {
+	if rv := reflect.ValueOf(val); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		s.Valid = false
+		return nil
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	// keep integers exact, the field scalars parse the numbers
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var x map[string]any
+	if err := dec.Decode(&x); err != nil {
+		return err
+	}
+	if err := decodeJSONFields(x, s.Type); err != nil {
+		return err
+	}
+	s.Value, s.Valid = x, true
+	return nil
+}
+
+// decodeJSONFields converts the JSON decoded values of binary fields (base64 strings) to bytes, including nested fields
+func decodeJSONFields(x map[string]any, st *arrow.StructType) error {
+	for name, v := range x {
+		f, ok := st.FieldByName(name)
+		if !ok {
+			continue
+		}
+		decoded, err := decodeJSONValue(v, f.Type)
+		if err != nil {
+			return err
+		}
+		x[name] = decoded
+	}
+	return nil
+}
+
+func decodeJSONValue(v any, dt arrow.DataType) (any, error) {
+	switch dt := dt.(type) {
+	case *arrow.BinaryType, *arrow.LargeBinaryType:
+		if str, ok := v.(string); ok {
+			return base64.StdEncoding.DecodeString(str)
+		}
+	case *arrow.StructType:
+		if m, ok := v.(map[string]any); ok {
+			return m, decodeJSONFields(m, dt)
+		}
+	case *arrow.ListType:
+		if l, ok := v.([]any); ok {
+			for i := range l {
+				decoded, err := decodeJSONValue(l[i], dt.Elem())
+				if err != nil {
+					return nil, err
+				}
+				l[i] = decoded
+			}
+		}
+	}
+	return v, nil
+}
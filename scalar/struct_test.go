@@ -2,6 +2,7 @@ package scalar
 
 import (
 	"encoding/json"
+	"math"
 	"strconv"
 	"testing"
 
@@ -101,3 +102,46 @@ func TestStructMissingKeys(t *testing.T) {
 		})
 	}
 }
+
+func TestStructSetGoStruct(t *testing.T) {
+	type inner struct {
+		Data []byte `json:"data"`
+	}
+	type item struct {
+		ID      uint64   `json:"id"`
+		Name    string   `json:"name,omitempty"`
+		Inner   *inner   `json:"inner"`
+		Inners  []inner  `json:"inners"`
+		Skipped string   `json:"-"`
+		Tags    []string `json:"tags"`
+	}
+	innerType := arrow.StructOf(arrow.Field{Name: "data", Type: arrow.BinaryTypes.Binary, Nullable: true})
+	dt := arrow.StructOf(
+		arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+		arrow.Field{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+		arrow.Field{Name: "inner", Type: innerType, Nullable: true},
+		arrow.Field{Name: "inners", Type: arrow.ListOf(innerType), Nullable: true},
+		arrow.Field{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String), Nullable: true},
+	)
+
+	s := NewScalar(dt)
+	assert.NoError(t, s.Set(&item{
+		ID:     math.MaxUint64,
+		Inner:  &inner{Data: []byte("abc")},
+		Inners: []inner{{Data: []byte{0xff}}},
+		Tags:   []string{"a"},
+	}))
+	assert.True(t, s.IsValid())
+
+	bldr := array.NewBuilder(memory.DefaultAllocator, dt)
+	defer bldr.Release()
+	AppendToBuilder(bldr, s)
+	arr := bldr.NewArray()
+	defer arr.Release()
+	b, err := json.Marshal(arr.GetOneForMarshal(0))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":18446744073709551615,"name":null,"inner":{"data":"YWJj"},"inners":[{"data":"/w=="}],"tags":["a"]}`, string(b))
+
+	assert.NoError(t, s.Set((*item)(nil)))
+	assert.False(t, s.IsValid())
+}
@@ -0,0 +1,294 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/state"
+	"golang.org/x/exp/constraints"
+)
+
+// WithSyncStateClient enables incremental syncs of top level tables with IsIncremental set:
+// the high watermarks of the incremental key columns are loaded from the state client before the table is resolved
+// (see HighWatermark), and saved at the end of the sync for the tables and clients that were synced successfully,
+// once all resources were delivered.
+// The incremental key columns must have an ordered type: numeric, decimal, date, time, timestamp, duration, string or binary.
+func WithSyncStateClient(st state.Client) SyncOption {
+	return func(s *syncClient) {
+		s.stateClient = st
+	}
+}
+
+// WithSyncFullResync ignores the saved high watermarks, so incremental tables are synced from scratch.
+// The high watermarks are still saved after the sync.
+func WithSyncFullResync(fullResync bool) SyncOption {
+	return func(s *syncClient) {
+		s.fullResync = fullResync
+	}
+}
+
+// WatermarkKey returns the state key the high watermarks of the table and client are saved under.
+func WatermarkKey(table string, clientID string) string {
+	return "watermark:" + table + ":" + clientID
+}
+
+type watermarksKey struct{}
+
+// HighWatermark returns the maximum value of the incremental key column emitted by the last successful sync
+// of the table and client, or nil if there isn't one (e.g. in the first sync, or a full resync).
+// Resolvers of incremental tables use it to only fetch the items that changed since.
+func HighWatermark(ctx context.Context, column string) scalar.Scalar {
+	w := watermarksFromContext(ctx)
+	if w == nil {
+		return nil
+	}
+	return w.loaded[column]
+}
+
+// watermarks tracks the high watermarks of an incremental table and client while it's synced
+type watermarks struct {
+	table   *schema.Table
+	client  string
+	key     string
+	columns schema.ColumnList
+	loaded  map[string]scalar.Scalar
+	mu      sync.Mutex
+	max     map[string]scalar.Scalar
+	// err is the first error comparing incremental key values, which prevents the high watermarks from being saved
+	err error
+}
+
+// loadWatermarks returns the watermarks of the table and client, or nil if the table isn't synced incrementally
+func (s *syncClient) loadWatermarks(ctx context.Context, table *schema.Table, client schema.ClientMeta) (*watermarks, error) {
+	if s.stateClient == nil || !table.IsIncremental {
+		return nil, nil
+	}
+	var columns schema.ColumnList
+	for _, c := range table.Columns {
+		if c.IncrementalKey {
+			columns = append(columns, c)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	w := &watermarks{
+		table:   table,
+		client:  client.ID(),
+		key:     WatermarkKey(table.Name, client.ID()),
+		columns: columns,
+		loaded:  make(map[string]scalar.Scalar),
+		max:     make(map[string]scalar.Scalar),
+	}
+	if s.fullResync {
+		return w, nil
+	}
+	var encoded map[string]string
+	if _, err := state.GetJSON(ctx, s.stateClient, w.key, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to get high watermarks: %w", err)
+	}
+	for _, c := range columns {
+		v, ok := encoded[c.Name]
+		if !ok {
+			continue
+		}
+		sc := scalar.NewScalar(c.Type)
+		if err := sc.Set(v); err != nil {
+			return nil, fmt.Errorf("failed to decode high watermark of column %s: %w", c.Name, err)
+		}
+		w.loaded[c.Name] = sc
+		w.max[c.Name] = sc
+	}
+	return w, nil
+}
+
+// completeWatermarks adds the watermarks of a table and client that was synced successfully, to be saved by saveWatermarks
+func (s *syncClient) completeWatermarks(w *watermarks) {
+	s.watermarksMu.Lock()
+	defer s.watermarksMu.Unlock()
+	s.completedWatermarks = append(s.completedWatermarks, w)
+}
+
+// saveWatermarks saves the watermarks of the tables and clients that were synced successfully.
+// It's called after all resources were delivered, so the watermarks never get ahead of the synced resources.
+func (s *syncClient) saveWatermarks(ctx context.Context) {
+	s.watermarksMu.Lock()
+	defer s.watermarksMu.Unlock()
+	for _, w := range s.completedWatermarks {
+		if err := w.save(ctx, s.stateClient); err != nil {
+			s.logger.Error().Err(err).Str("table", w.table.Name).Str("client", w.client).Msg("failed to save incremental table state")
+			atomic.AddUint64(&s.metrics.TableClient[w.table.Name][w.client].Errors, 1)
+		}
+	}
+	s.completedWatermarks = nil
+}
+
+// update includes the incremental key values of the resource in the high watermarks
+func (w *watermarks) update(resource *schema.Resource) {
+	if w == nil || resource.Table != w.table {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, c := range w.columns {
+		v := resource.Get(c.Name)
+		if v == nil || !v.IsValid() {
+			continue
+		}
+		current, ok := w.max[c.Name]
+		if !ok {
+			w.max[c.Name] = v
+			continue
+		}
+		cmp, err := compareScalars(v, current)
+		if err != nil {
+			if w.err == nil {
+				w.err = fmt.Errorf("failed to compare values of incremental key column %s: %w", c.Name, err)
+			}
+			continue
+		}
+		if cmp > 0 {
+			w.max[c.Name] = v
+		}
+	}
+}
+
+// save writes the high watermarks to the state client and flushes it, if they changed
+func (w *watermarks) save(ctx context.Context, st state.Client) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	changed := len(w.max) != len(w.loaded)
+	encoded := make(map[string]string, len(w.max))
+	for column, v := range w.max {
+		encoded[column] = encodeWatermark(v)
+		if loaded, ok := w.loaded[column]; !ok || !loaded.Equal(v) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := state.SetJSON(ctx, st, w.key, encoded); err != nil {
+		return fmt.Errorf("failed to set high watermarks: %w", err)
+	}
+	if err := st.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to flush high watermarks: %w", err)
+	}
+	return nil
+}
+
+// encodeWatermark returns the value as a string that can be set on a scalar of the same type without losing precision
+func encodeWatermark(v scalar.Scalar) string {
+	if t, ok := v.Get().(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return v.String()
+}
+
+// compareScalars compares two valid scalars of the same type.
+// It returns an error for types without a defined order (e.g. UUIDs, lists and structs).
+func compareScalars(a, b scalar.Scalar) (int, error) {
+	switch av := a.(type) {
+	case *scalar.Timestamp:
+		if bv, ok := b.(*scalar.Timestamp); ok {
+			switch {
+			case av.Value.Before(bv.Value):
+				return -1, nil
+			case av.Value.After(bv.Value):
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	case *scalar.Int:
+		if bv, ok := b.(*scalar.Int); ok {
+			return compareOrdered(av.Value, bv.Value), nil
+		}
+	case *scalar.Uint:
+		if bv, ok := b.(*scalar.Uint); ok {
+			return compareOrdered(av.Value, bv.Value), nil
+		}
+	case *scalar.Float:
+		if bv, ok := b.(*scalar.Float); ok {
+			return compareOrdered(av.Value, bv.Value), nil
+		}
+	case *scalar.Date32:
+		if bv, ok := b.(*scalar.Date32); ok {
+			return compareOrdered(av.Value, bv.Value), nil
+		}
+	case *scalar.Date64:
+		if bv, ok := b.(*scalar.Date64); ok {
+			return compareOrdered(av.Value, bv.Value), nil
+		}
+	case *scalar.Time:
+		if bv, ok := b.(*scalar.Time); ok {
+			return compareOrdered(av.Int.Value, bv.Int.Value), nil
+		}
+	case *scalar.Duration:
+		if bv, ok := b.(*scalar.Duration); ok {
+			return compareOrdered(av.Int.Value, bv.Int.Value), nil
+		}
+	case *scalar.Decimal128:
+		if bv, ok := b.(*scalar.Decimal128); ok {
+			return av.Value.Cmp(bv.Value), nil
+		}
+	case *scalar.Decimal256:
+		if bv, ok := b.(*scalar.Decimal256); ok {
+			return av.Value.Cmp(bv.Value), nil
+		}
+	case *scalar.String:
+		if bv, ok := b.(*scalar.String); ok {
+			return strings.Compare(av.Value, bv.Value), nil
+		}
+	case *scalar.LargeString:
+		if bv, ok := b.(*scalar.LargeString); ok {
+			return strings.Compare(av.String(), bv.String()), nil
+		}
+	case *scalar.Binary:
+		if bv, ok := b.(*scalar.Binary); ok {
+			return bytes.Compare(av.Value, bv.Value), nil
+		}
+	case *scalar.LargeBinary:
+		if bv, ok := b.(*scalar.LargeBinary); ok {
+			return bytes.Compare(av.Value, bv.Value), nil
+		}
+	default:
+		return 0, fmt.Errorf("type %s has no defined order", a.DataType())
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", a.DataType(), b.DataType())
+}
+
+func compareOrdered[T constraints.Ordered](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// withWatermarks adds the watermarks to the context of the table resolver
+func withWatermarks(ctx context.Context, w *watermarks) context.Context {
+	if w == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, watermarksKey{}, w)
+}
+
+// watermarksFromContext returns the watermarks of the incremental table being resolved, or nil
+func watermarksFromContext(ctx context.Context) *watermarks {
+	w, _ := ctx.Value(watermarksKey{}).(*watermarks)
+	return w
+}
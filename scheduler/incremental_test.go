@@ -0,0 +1,327 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/message"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+	"github.com/rs/zerolog"
+)
+
+type testStateClient struct {
+	mu      sync.Mutex
+	keys    map[string]string
+	flushes int
+}
+
+func (c *testStateClient) SetKey(_ context.Context, key string, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[key] = value
+	return nil
+}
+
+func (c *testStateClient) GetKey(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keys[key], nil
+}
+
+func (c *testStateClient) DeleteKey(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.keys, key)
+	return nil
+}
+
+func (c *testStateClient) ListKeys(_ context.Context, prefix string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0)
+	for k := range c.keys {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *testStateClient) Flush(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushes++
+	return nil
+}
+
+type incrementalItem struct {
+	ID        int64
+	UpdatedAt time.Time
+}
+
+// testIncrementalTable returns an incremental table emitting the items updated after the high watermark
+func testIncrementalTable(items *[]incrementalItem, watermarks *[]scalar.Scalar, resolverErr error) *schema.Table {
+	return &schema.Table{
+		Name:          "test_incremental_table",
+		IsIncremental: true,
+		Resolver: func(ctx context.Context, _ schema.ClientMeta, _ *schema.Resource, res chan<- any) error {
+			watermark := HighWatermark(ctx, "updated_at")
+			*watermarks = append(*watermarks, watermark)
+			for _, item := range *items {
+				if watermark == nil || item.UpdatedAt.After(watermark.Get().(time.Time)) {
+					res <- item
+				}
+			}
+			return resolverErr
+		},
+		Columns: []schema.Column{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64, PrimaryKey: true, Resolver: schema.PathResolver("ID")},
+			{Name: "updated_at", Type: arrow.FixedWidthTypes.Timestamp_us, IncrementalKey: true, Resolver: schema.PathResolver("UpdatedAt")},
+		},
+		Relations: []*schema.Table{
+			{
+				Name: "test_incremental_relation",
+				Resolver: func(ctx context.Context, _ schema.ClientMeta, parent *schema.Resource, res chan<- any) error {
+					if HighWatermark(ctx, "updated_at") != nil {
+						return errors.New("relations shouldn't have high watermarks")
+					}
+					res <- map[string]any{"ParentID": parent.Get("id").Get()}
+					return nil
+				},
+				Columns: []schema.Column{{Name: "parent_id", Type: arrow.PrimitiveTypes.Int64}},
+			},
+		},
+	}
+}
+
+func TestSchedulerIncrementalTables(t *testing.T) {
+	base := time.Date(2023, 7, 1, 10, 0, 0, 123456000, time.UTC)
+	items := []incrementalItem{
+		{ID: 1, UpdatedAt: base},
+		{ID: 2, UpdatedAt: base.Add(time.Hour)},
+		{ID: 3, UpdatedAt: base.Add(time.Minute)},
+	}
+	st := &testStateClient{keys: make(map[string]string)}
+	var watermarks []scalar.Scalar
+	table := testIncrementalTable(&items, &watermarks, nil)
+	sc := NewScheduler(WithLogger(zerolog.New(zerolog.NewTestWriter(t))))
+	syncTable := func(opts ...SyncOption) (inserted int, relations int) {
+		t.Helper()
+		msgs, err := sc.SyncAll(context.Background(), &testExecutionClient{}, schema.Tables{table}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, insert := range msgs.GetInserts() {
+			name, _ := insert.Record.Schema().Metadata().GetValue(schema.MetadataTableName)
+			if name == table.Name {
+				inserted++
+			} else {
+				relations++
+			}
+		}
+		return inserted, relations
+	}
+	key := WatermarkKey(table.Name, "test")
+
+	// first sync, all items are synced
+	if inserted, relations := syncTable(WithSyncStateClient(st)); inserted != 3 || relations != 3 {
+		t.Fatalf("expected 3 resources and relations, got %d and %d", inserted, relations)
+	}
+	if watermarks[0] != nil {
+		t.Fatalf("expected no high watermark in the first sync, got %v", watermarks[0])
+	}
+	expectedState := `{"updated_at":"2023-07-01T11:00:00.123456Z"}`
+	if st.keys[key] != expectedState || st.flushes != 1 {
+		t.Fatalf("expected state %s to be flushed, got %s (%d flushes)", expectedState, st.keys[key], st.flushes)
+	}
+
+	// nothing changed
+	if inserted, _ := syncTable(WithSyncStateClient(st)); inserted != 0 {
+		t.Fatalf("expected no resources, got %d", inserted)
+	}
+	if got := watermarks[1].Get().(time.Time); !got.Equal(base.Add(time.Hour)) {
+		t.Fatalf("expected high watermark %v, got %v", base.Add(time.Hour), got)
+	}
+	if st.flushes != 1 {
+		t.Fatalf("expected unchanged state not to be flushed, got %d flushes", st.flushes)
+	}
+
+	// a new item
+	items = append(items, incrementalItem{ID: 4, UpdatedAt: base.Add(2 * time.Hour)})
+	if inserted, _ := syncTable(WithSyncStateClient(st)); inserted != 1 {
+		t.Fatalf("expected 1 resource, got %d", inserted)
+	}
+	expectedState = `{"updated_at":"2023-07-01T12:00:00.123456Z"}`
+	if st.keys[key] != expectedState {
+		t.Fatalf("expected state %s, got %s", expectedState, st.keys[key])
+	}
+
+	// full resync
+	if inserted, _ := syncTable(WithSyncStateClient(st), WithSyncFullResync(true)); inserted != 4 {
+		t.Fatalf("expected 4 resources, got %d", inserted)
+	}
+	if watermarks[3] != nil {
+		t.Fatalf("expected no high watermark in a full resync, got %v", watermarks[3])
+	}
+
+	// without a state client, the table is synced in full
+	if inserted, _ := syncTable(); inserted != 4 {
+		t.Fatalf("expected 4 resources, got %d", inserted)
+	}
+}
+
+func TestSchedulerIncrementalTablesFailure(t *testing.T) {
+	items := []incrementalItem{{ID: 1, UpdatedAt: time.Now()}}
+	st := &testStateClient{keys: make(map[string]string)}
+	var watermarks []scalar.Scalar
+	table := testIncrementalTable(&items, &watermarks, errors.New("resolver failed"))
+	sc := NewScheduler(WithLogger(zerolog.New(zerolog.NewTestWriter(t))))
+	msgs, err := sc.SyncAll(context.Background(), &testExecutionClient{}, schema.Tables{table}, WithSyncStateClient(st))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs.GetInserts()) == 0 {
+		t.Fatal("expected resources to be synced")
+	}
+	if len(st.keys) != 0 || st.flushes != 0 {
+		t.Fatalf("expected state not to be saved after a failure, got %v", st.keys)
+	}
+}
+
+func TestSchedulerIncrementalTablesFailedWrite(t *testing.T) {
+	base := time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC)
+	items := []incrementalItem{{ID: 1, UpdatedAt: base}, {ID: 2, UpdatedAt: base.Add(time.Hour)}, {ID: 3, UpdatedAt: base.Add(time.Minute)}}
+	st := &testStateClient{keys: make(map[string]string)}
+	var watermarks []scalar.Scalar
+	table := testIncrementalTable(&items, &watermarks, nil)
+	sc := NewScheduler(WithLogger(zerolog.New(zerolog.NewTestWriter(t))))
+
+	// the receiver stops at the first insert, like the plugin server does when a write fails
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	res := make(chan message.SyncMessage)
+	done := make(chan error, 1)
+	go func() {
+		done <- sc.Sync(ctx, &testExecutionClient{}, schema.Tables{table}, res, WithSyncStateClient(st))
+	}()
+	for msg := range res {
+		if _, ok := msg.(*message.SyncInsert); ok {
+			cancel()
+			break
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(st.keys) != 0 || st.flushes != 0 {
+		t.Fatalf("expected state not to be saved after a failed write, got %v", st.keys)
+	}
+
+	// the state is only flushed after the last resource was delivered
+	res = make(chan message.SyncMessage)
+	go func() {
+		defer close(res)
+		done <- sc.Sync(context.Background(), &testExecutionClient{}, schema.Tables{table}, res, WithSyncStateClient(st))
+	}()
+	var flushesPerInsert []int
+	for msg := range res {
+		if _, ok := msg.(*message.SyncInsert); !ok {
+			continue
+		}
+		st.mu.Lock()
+		flushesPerInsert = append(flushesPerInsert, st.flushes)
+		st.mu.Unlock()
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// the state can be flushed while the last insert is handled
+	for i, flushes := range flushesPerInsert[:len(flushesPerInsert)-1] {
+		if flushes != 0 {
+			t.Fatalf("expected state not to be flushed before all resources were delivered, got a flush before insert %d", i+1)
+		}
+	}
+	if st.flushes != 1 {
+		t.Fatalf("expected state to be flushed once, got %d", st.flushes)
+	}
+}
+
+func TestCompareScalars(t *testing.T) {
+	decimal := func(v string) scalar.Scalar {
+		s := scalar.NewScalar(&arrow.Decimal128Type{Precision: 10, Scale: 2})
+		if err := s.Set(v); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	decimal256 := func(v string) scalar.Scalar {
+		s := scalar.NewScalar(&arrow.Decimal256Type{Precision: 40, Scale: 2})
+		if err := s.Set(v); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	cases := []struct {
+		a, b scalar.Scalar
+		want int
+	}{
+		{a: &scalar.Int{Value: 2, Valid: true}, b: &scalar.Int{Value: 10, Valid: true}, want: -1},
+		{a: &scalar.String{Value: "b", Valid: true}, b: &scalar.String{Value: "a", Valid: true}, want: 1},
+		{a: &scalar.Timestamp{Value: time.Unix(5, 0), Valid: true}, b: &scalar.Timestamp{Value: time.Unix(5, 0), Valid: true}, want: 0},
+		{a: &scalar.Float{Value: 1.5, Valid: true}, b: &scalar.Float{Value: 1.25, Valid: true}, want: 1},
+		// numeric types are compared as numbers, not as text
+		{a: decimal("9.50"), b: decimal("10.00"), want: -1},
+		{a: decimal("-1.00"), b: decimal("-2.00"), want: 1},
+		{a: decimal256("9.50"), b: decimal256("10.00"), want: -1},
+		{a: &scalar.Date32{Value: 9, Valid: true}, b: &scalar.Date32{Value: 10, Valid: true}, want: -1},
+		{a: &scalar.Date64{Value: 864000000, Valid: true}, b: &scalar.Date64{Value: 86400000, Valid: true}, want: 1},
+	}
+	for _, tc := range cases {
+		got, err := compareScalars(tc.a, tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("compare %v and %v: expected %d, got %d", tc.a, tc.b, tc.want, got)
+		}
+	}
+
+	uuid := scalar.NewScalar(types.ExtensionTypes.UUID)
+	if _, err := compareScalars(uuid, uuid); err == nil {
+		t.Fatal("expected an error comparing types without a defined order")
+	}
+	if _, err := compareScalars(&scalar.Int{Value: 1, Valid: true}, &scalar.String{Value: "1", Valid: true}); err == nil {
+		t.Fatal("expected an error comparing different types")
+	}
+}
+
+func TestWatermarksUnorderedType(t *testing.T) {
+	table := &schema.Table{
+		Name:    "test_unordered",
+		Columns: []schema.Column{{Name: "id", Type: types.ExtensionTypes.UUID, IncrementalKey: true}},
+	}
+	w := &watermarks{table: table, columns: table.Columns, loaded: map[string]scalar.Scalar{}, max: map[string]scalar.Scalar{}}
+	for i := 0; i < 2; i++ {
+		resource := schema.NewResourceData(table, nil, nil)
+		if err := resource.Set("id", "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"); err != nil {
+			t.Fatal(err)
+		}
+		w.update(resource)
+	}
+	st := &testStateClient{keys: make(map[string]string)}
+	if err := w.save(context.Background(), st); err == nil {
+		t.Fatal("expected an error saving the high watermark of a column without a defined order")
+	}
+	if len(st.keys) != 0 {
+		t.Fatalf("expected state not to be saved, got %v", st.keys)
+	}
+}
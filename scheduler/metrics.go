@@ -16,12 +16,14 @@ type TableClientMetrics struct {
 	Resources uint64
 	Errors    uint64
 	Panics    uint64
-	StartTime time.Time
-	EndTime   time.Time
+	// ValidationErrors is the number of resources that failed validation (regardless of the validation policy)
+	ValidationErrors uint64
+	StartTime        time.Time
+	EndTime          time.Time
 }
 
 func (s *TableClientMetrics) Equal(other *TableClientMetrics) bool {
-	return s.Resources == other.Resources && s.Errors == other.Errors && s.Panics == other.Panics
+	return s.Resources == other.Resources && s.Errors == other.Errors && s.Panics == other.Panics && s.ValidationErrors == other.ValidationErrors
 }
 
 // Equal compares to stats. Mostly useful in testing
@@ -105,6 +107,26 @@ func (s *Metrics) TotalPanicsAtomic() uint64 {
 	return total
 }
 
+func (s *Metrics) TotalValidationErrors() uint64 {
+	var total uint64
+	for _, clientMetrics := range s.TableClient {
+		for _, metrics := range clientMetrics {
+			total += metrics.ValidationErrors
+		}
+	}
+	return total
+}
+
+func (s *Metrics) TotalValidationErrorsAtomic() uint64 {
+	var total uint64
+	for _, clientMetrics := range s.TableClient {
+		for _, metrics := range clientMetrics {
+			total += atomic.LoadUint64(&metrics.ValidationErrors)
+		}
+	}
+	return total
+}
+
 func (s *Metrics) TotalResources() uint64 {
 	var total uint64
 	for _, clientMetrics := range s.TableClient {
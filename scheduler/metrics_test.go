@@ -34,4 +34,9 @@ func TestMetrics(t *testing.T) {
 	if !s.Equal(other) {
 		t.Fatal("expected metrics to be equal")
 	}
+
+	other.TableClient["test_table"]["testExecutionClient"].ValidationErrors = 1
+	if s.Equal(other) {
+		t.Fatal("expected metrics with different validation errors to not be equal")
+	}
 }
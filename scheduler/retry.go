@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy controls how operations run with Retry (e.g. API requests made by table resolvers) are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the operation is run, including the first attempt.
+	// Values below 1 are treated as 1 (no retries).
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It's doubled after every retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum wait between retries. Zero means no maximum.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used when the scheduler isn't configured with WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// RetryAfterError can be implemented by errors that know when the operation can be retried (e.g. from a Retry-After header).
+// The returned duration is used instead of the backoff of the retry policy when it's positive, up to MaxBackoff.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so Retry returns it without retrying the operation.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Backoff returns the wait before the given retry (1 for the first retry).
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// Retry runs fn until it succeeds, returns an error wrapped with Permanent, the attempts of the policy are exhausted
+// or the context is done. The error of the last attempt is returned, unwrapped from Permanent, or the error of the context
+// if it's done while waiting for a retry.
+func (p RetryPolicy) Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= p.MaxAttempts {
+			return err
+		}
+		wait := p.Backoff(attempt)
+		var retryAfter RetryAfterError
+		if errors.As(err, &retryAfter) && retryAfter.RetryAfter() > 0 {
+			wait = retryAfter.RetryAfter()
+			if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+				wait = p.MaxBackoff
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w while waiting to retry after: %v", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+type retryPolicyKey struct{}
+
+// RetryPolicyFromContext returns the retry policy of the scheduler syncing the table,
+// or DefaultRetryPolicy if the context doesn't come from a scheduler.
+func RetryPolicyFromContext(ctx context.Context) RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return p
+	}
+	return DefaultRetryPolicy
+}
+
+// Retry runs fn with the retry policy of the scheduler syncing the table (see RetryPolicy.Retry).
+func Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RetryPolicyFromContext(ctx).Retry(ctx, fn)
+}
@@ -0,0 +1,185 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/rs/zerolog"
+)
+
+type retryAfterError struct {
+	after time.Duration
+}
+
+func (*retryAfterError) Error() string {
+	return "rate limited"
+}
+
+func (e *retryAfterError) RetryAfter() time.Duration {
+	return e.after
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := p.Backoff(i + 1); got != want {
+			t.Fatalf("retry %d: expected backoff %s, got %s", i+1, want, got)
+		}
+	}
+}
+
+func TestRetryPolicyRetry(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	errFailed := errors.New("failed")
+
+	attempts := 0
+	err := p.Retry(context.Background(), func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errFailed
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("expected success after 3 attempts, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = p.Retry(context.Background(), func(context.Context) error {
+		attempts++
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) || attempts != 3 {
+		t.Fatalf("expected %v after 3 attempts, got %v after %d attempts", errFailed, err, attempts)
+	}
+
+	attempts = 0
+	err = p.Retry(context.Background(), func(context.Context) error {
+		attempts++
+		return Permanent(errFailed)
+	})
+	if err != errFailed || attempts != 1 {
+		t.Fatalf("expected unwrapped %v after 1 attempt, got %v after %d attempts", errFailed, err, attempts)
+	}
+
+	attempts = 0
+	start := time.Now()
+	err = RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}.Retry(context.Background(), func(context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return &retryAfterError{after: time.Millisecond}
+		}
+		return nil
+	})
+	if err != nil || time.Since(start) > time.Minute {
+		t.Fatalf("expected retry after error to override the backoff, got %v after %s", err, time.Since(start))
+	}
+
+	attempts = 0
+	start = time.Now()
+	err = RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}.Retry(context.Background(), func(context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return &retryAfterError{after: time.Hour}
+		}
+		return nil
+	})
+	if err != nil || time.Since(start) > time.Minute {
+		t.Fatalf("expected retry after to be capped at the max backoff, got %v after %s", err, time.Since(start))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}.Retry(ctx, func(context.Context) error {
+		return errFailed
+	})
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), errFailed.Error()) {
+		t.Fatalf("expected %v with the last error when the context is done, got %v", context.Canceled, err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	var nilLimiter *RateLimiter
+	if err := nilLimiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewRateLimiter(100, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the burst is immediate, the other 2 requests wait for 10ms each
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Fatalf("expected requests beyond the burst to be limited, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewRateLimiter(0.001, 1).Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestRateLimiterCancelledWaitersReturnTokens(t *testing.T) {
+	l := NewRateLimiter(10, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		err := l.Wait(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	}
+	// without giving the tokens back, this request would wait for the 5 cancelled ones (600ms)
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Fatalf("expected cancelled requests to not delay the following ones, waited %s", elapsed)
+	}
+}
+
+func TestSchedulerRetryAndRateLimitContext(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 7}
+	var gotPolicy RetryPolicy
+	var gotLimiter *RateLimiter
+	table := &schema.Table{
+		Name: "test_table_context",
+		Resolver: func(ctx context.Context, _ schema.ClientMeta, _ *schema.Resource, _ chan<- any) error {
+			gotPolicy = RetryPolicyFromContext(ctx)
+			gotLimiter = RateLimiterFromContext(ctx)
+			return nil
+		},
+		Columns: []schema.Column{{Name: "test_column", Type: arrow.PrimitiveTypes.Int64}},
+	}
+	sc := NewScheduler(
+		WithLogger(zerolog.New(zerolog.NewTestWriter(t))),
+		WithRetryPolicy(policy),
+		WithRateLimit(10, 1),
+	)
+	if _, err := sc.SyncAll(context.Background(), &testExecutionClient{}, schema.Tables{table}); err != nil {
+		t.Fatal(err)
+	}
+	if gotPolicy != policy {
+		t.Fatalf("expected retry policy %v, got %v", policy, gotPolicy)
+	}
+	if gotLimiter == nil {
+		t.Fatal("expected rate limiter in the resolver context")
+	}
+	if RetryPolicyFromContext(context.Background()) != DefaultRetryPolicy {
+		t.Fatal("expected default retry policy outside of the scheduler")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime/debug"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/cloudquery/plugin-sdk/v4/message"
 	"github.com/cloudquery/plugin-sdk/v4/scalar"
 	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/state"
 	"github.com/getsentry/sentry-go"
 	"github.com/rs/zerolog"
 	"github.com/thoas/go-funk"
@@ -24,7 +26,7 @@ import (
 
 const (
 	DefaultConcurrency     = 50000
-	DefaultMaxDepth        = 4
+	DefaultMaxDepth        = schema.DefaultMaxRelationDepth
 	minTableConcurrency    = 1
 	minResourceConcurrency = 100
 )
@@ -136,6 +138,35 @@ func WithStrategy(strategy Strategy) Option {
 	}
 }
 
+// WithConversionMode sets how values that can't be converted to the column type are handled. Defaults to ConversionModeStrict.
+func WithConversionMode(mode ConversionMode) Option {
+	return func(s *Scheduler) {
+		s.conversionMode = mode
+	}
+}
+
+// WithValidationPolicy sets what to do with resources that fail validation. Defaults to ValidationPolicyDropRow.
+func WithValidationPolicy(policy ValidationPolicy) Option {
+	return func(s *Scheduler) {
+		s.validationPolicy = policy
+	}
+}
+
+// WithRetryPolicy sets the retry policy table resolvers use with Retry. Defaults to DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *Scheduler) {
+		s.retryPolicy = policy
+	}
+}
+
+// WithRateLimit limits the requests table resolvers make (see WaitForRateLimit) to requestsPerSecond on average,
+// with bursts of up to burst requests. The limit is shared by all tables and clients.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(s *Scheduler) {
+		s.rateLimiter = NewRateLimiter(requestsPerSecond, burst)
+	}
+}
+
 type SyncOption func(*syncClient)
 
 func WithSyncDeterministicCQID(deterministicCQID bool) SyncOption {
@@ -149,9 +180,13 @@ type Client interface {
 }
 
 type Scheduler struct {
-	caser    *caser.Caser
-	strategy Strategy
-	maxDepth uint64
+	caser            *caser.Caser
+	strategy         Strategy
+	maxDepth         uint64
+	validationPolicy ValidationPolicy
+	conversionMode   ConversionMode
+	retryPolicy      RetryPolicy
+	rateLimiter      *RateLimiter
 	// resourceSem is a semaphore that limits the number of concurrent resources being fetched
 	resourceSem *semaphore.Weighted
 	// tableSem is a semaphore that limits the number of concurrent tables being fetched
@@ -166,6 +201,11 @@ type syncClient struct {
 	client            schema.ClientMeta
 	scheduler         *Scheduler
 	deterministicCQID bool
+	stateClient       state.Client
+	fullResync        bool
+	// completedWatermarks are the watermarks of incremental tables synced successfully, saved at the end of the sync
+	completedWatermarks []*watermarks
+	watermarksMu        sync.Mutex
 	// status sync metrics
 	metrics *Metrics
 	logger  zerolog.Logger
@@ -176,6 +216,7 @@ func NewScheduler(opts ...Option) *Scheduler {
 		caser:       caser.New(),
 		concurrency: DefaultConcurrency,
 		maxDepth:    DefaultMaxDepth,
+		retryPolicy: DefaultRetryPolicy,
 	}
 	for _, opt := range opts {
 		opt(&s)
@@ -196,12 +237,12 @@ func NewScheduler(opts ...Option) *Scheduler {
 
 // SyncAll is mostly used for testing as it will sync all tables and can run out of memory
 // in the real world. Should use Sync for production.
-func (s *Scheduler) SyncAll(ctx context.Context, client schema.ClientMeta, tables schema.Tables) (message.SyncMessages, error) {
+func (s *Scheduler) SyncAll(ctx context.Context, client schema.ClientMeta, tables schema.Tables, opts ...SyncOption) (message.SyncMessages, error) {
 	res := make(chan message.SyncMessage)
 	var err error
 	go func() {
 		defer close(res)
-		err = s.Sync(ctx, client, tables, res)
+		err = s.Sync(ctx, client, tables, res, opts...)
 	}()
 	// nolint:prealloc
 	var messages message.SyncMessages
@@ -231,6 +272,10 @@ func (s *Scheduler) Sync(ctx context.Context, client schema.ClientMeta, tables s
 		return fmt.Errorf("max depth exceeded, max depth is %d", s.maxDepth)
 	}
 
+	// make the retry policy and rate limiter available to the table resolvers
+	ctx = context.WithValue(ctx, retryPolicyKey{}, s.retryPolicy)
+	ctx = context.WithValue(ctx, rateLimiterKey{}, s.rateLimiter)
+
 	// send migrate messages first
 	for _, table := range tables.FlattenTables() {
 		res <- &message.SyncMigrateTable{
@@ -251,11 +296,24 @@ func (s *Scheduler) Sync(ctx context.Context, client schema.ClientMeta, tables s
 		}
 	}()
 	for resource := range resources {
+		if ctx.Err() != nil {
+			// keep draining the channel so the resolvers can exit
+			continue
+		}
 		vector := resource.GetValues()
 		bldr := array.NewRecordBuilder(memory.DefaultAllocator, resource.Table.ToArrowSchema())
 		scalar.AppendToRecordBuilder(bldr, vector)
 		rec := bldr.NewRecord()
-		res <- &message.SyncInsert{Record: rec}
+		select {
+		case res <- &message.SyncInsert{Record: rec}:
+		case <-ctx.Done():
+			// the receiver stopped, e.g. because a write failed
+			rec.Release()
+		}
+	}
+	// the high watermarks are saved once all resources were delivered, so resources that weren't aren't skipped in the next sync
+	if ctx.Err() == nil {
+		syncClient.saveWatermarks(ctx)
 	}
 	return nil
 }
@@ -324,7 +382,6 @@ func (s *syncClient) resolveResource(ctx context.Context, table *schema.Table, c
 }
 
 func (s *syncClient) resolveColumn(ctx context.Context, logger zerolog.Logger, tableMetrics *TableClientMetrics, client schema.ClientMeta, resource *schema.Resource, c schema.Column) {
-	var validationErr *schema.ValidationError
 	columnStartTime := time.Now()
 	defer func() {
 		if err := recover(); err != nil {
@@ -341,36 +398,45 @@ func (s *syncClient) resolveColumn(ctx context.Context, logger zerolog.Logger, t
 
 	if c.Resolver != nil {
 		if err := c.Resolver(ctx, client, resource, c); err != nil {
-			logger.Error().Err(err).Msg("column resolver finished with error")
-			atomic.AddUint64(&tableMetrics.Errors, 1)
-			if errors.As(err, &validationErr) {
-				sentry.WithScope(func(scope *sentry.Scope) {
-					scope.SetTag("table", resource.Table.Name)
-					scope.SetTag("column", c.Name)
-					sentry.CurrentHub().CaptureMessage(validationErr.MaskedError())
-				})
-			}
+			s.handleColumnError(logger, tableMetrics, resource, c, err)
 		}
 	} else {
 		// base use case: try to get column with CamelCase name
 		v := funk.Get(resource.GetItem(), s.scheduler.caser.ToPascal(c.Name), funk.WithAllowZero())
 		if v != nil {
-			err := resource.Set(c.Name, v)
-			if err != nil {
-				logger.Error().Err(err).Msg("column resolver finished with error")
-				atomic.AddUint64(&tableMetrics.Errors, 1)
-				if errors.As(err, &validationErr) {
-					sentry.WithScope(func(scope *sentry.Scope) {
-						scope.SetTag("table", resource.Table.Name)
-						scope.SetTag("column", c.Name)
-						sentry.CurrentHub().CaptureMessage(validationErr.MaskedError())
-					})
-				}
+			if err := resource.Set(c.Name, v); err != nil {
+				s.handleColumnError(logger, tableMetrics, resource, c, err)
 			}
 		}
 	}
 }
 
+// handleColumnError logs and records an error returned while resolving a column.
+// In lenient conversion mode, conversion errors leave the column null and are only logged as warnings.
+func (s *syncClient) handleColumnError(logger zerolog.Logger, tableMetrics *TableClientMetrics, resource *schema.Resource, c schema.Column, err error) {
+	var validationErr *schema.ValidationError
+	isValidationErr := errors.As(err, &validationErr)
+	if isValidationErr && s.scheduler.conversionMode == ConversionModeLenient && !errors.Is(err, schema.ErrColumnNotFound) {
+		column := c.Name
+		if validationErr.Column != "" {
+			column = validationErr.Column
+		}
+		// Resource.Set keeps the previous value on failure, so the column is set to null explicitly
+		_ = resource.Set(column, nil)
+		logger.Warn().Str("column", column).Str("error", validationErr.MaskedError()).Msg("column value could not be converted, setting to null")
+		return
+	}
+	logger.Error().Err(err).Msg("column resolver finished with error")
+	atomic.AddUint64(&tableMetrics.Errors, 1)
+	if isValidationErr {
+		sentry.WithScope(func(scope *sentry.Scope) {
+			scope.SetTag("table", resource.Table.Name)
+			scope.SetTag("column", c.Name)
+			sentry.CurrentHub().CaptureMessage(validationErr.MaskedError())
+		})
+	}
+}
+
 func maxDepth(tables schema.Tables) uint64 {
 	var depth uint64
 	if len(tables) == 0 {
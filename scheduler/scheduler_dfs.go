@@ -77,10 +77,30 @@ func (s *syncClient) resolveTableDfs(ctx context.Context, table *schema.Table, c
 	}
 	tableMetrics := s.metrics.TableClient[table.Name][clientName]
 
+	var w *watermarks
+	if parent == nil {
+		var err error
+		if w, err = s.loadWatermarks(ctx, table, client); err != nil {
+			logger.Error().Err(err).Msg("failed to load incremental table state")
+			atomic.AddUint64(&tableMetrics.Errors, 1)
+			return
+		}
+		ctx = withWatermarks(ctx, w)
+	} else if watermarksFromContext(ctx) != nil {
+		// relations of incremental tables aren't synced incrementally
+		ctx = context.WithValue(ctx, watermarksKey{}, (*watermarks)(nil))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	failure := &tableFailure{cancel: cancel}
+
 	res := make(chan any)
+	var resolverFailed bool
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
+				resolverFailed = true
 				stack := fmt.Sprintf("%s\n%s", err, string(debug.Stack()))
 				sentry.WithScope(func(scope *sentry.Scope) {
 					scope.SetTag("table", table.Name)
@@ -92,6 +112,7 @@ func (s *syncClient) resolveTableDfs(ctx context.Context, table *schema.Table, c
 			close(res)
 		}()
 		if err := table.Resolver(ctx, client, parent, res); err != nil {
+			resolverFailed = true
 			logger.Error().Err(err).Msg("table resolver finished with error")
 			atomic.AddUint64(&tableMetrics.Errors, 1)
 			if errors.As(err, &validationErr) {
@@ -105,7 +126,17 @@ func (s *syncClient) resolveTableDfs(ctx context.Context, table *schema.Table, c
 	}()
 
 	for r := range res {
-		s.resolveResourcesDfs(ctx, table, client, parent, r, resolvedResources, depth)
+		if failure.failed.Load() {
+			// keep draining the channel so the resolver can exit
+			continue
+		}
+		s.resolveResourcesDfs(ctx, table, client, parent, r, resolvedResources, depth, failure)
+	}
+
+	// the high watermarks are only saved if the table was synced completely, so items aren't skipped in the next sync.
+	// They are saved at the end of the sync, once all resources were delivered.
+	if w != nil && !resolverFailed && !failure.failed.Load() && ctx.Err() == nil {
+		s.completeWatermarks(w)
 	}
 
 	// we don't need any waitgroups here because we are waiting for the channel to close
@@ -115,7 +146,7 @@ func (s *syncClient) resolveTableDfs(ctx context.Context, table *schema.Table, c
 	}
 }
 
-func (s *syncClient) resolveResourcesDfs(ctx context.Context, table *schema.Table, client schema.ClientMeta, parent *schema.Resource, resources any, resolvedResources chan<- *schema.Resource, depth int) {
+func (s *syncClient) resolveResourcesDfs(ctx context.Context, table *schema.Table, client schema.ClientMeta, parent *schema.Resource, resources any, resolvedResources chan<- *schema.Resource, depth int, failure *tableFailure) {
 	resourcesSlice := helpers.InterfaceSlice(resources)
 	if len(resourcesSlice) == 0 {
 		return
@@ -158,19 +189,14 @@ func (s *syncClient) resolveResourcesDfs(ctx context.Context, table *schema.Tabl
 					return
 				}
 				if err := resolvedResource.Validate(); err != nil {
-					tableMetrics := s.metrics.TableClient[table.Name][client.ID()]
-					s.logger.Error().Err(err).Str("table", table.Name).Str("client", client.ID()).Msg("resource resolver finished with validation error")
-					if _, found := sentValidationErrors.LoadOrStore(table.Name, struct{}{}); !found {
-						// send resource validation errors to Sentry only once per table,
-						// to avoid sending too many duplicate messages
-						sentry.WithScope(func(scope *sentry.Scope) {
-							scope.SetTag("table", table.Name)
-							sentry.CurrentHub().CaptureMessage(err.Error())
-						})
+					if !s.handleValidationError(table, client, resolvedResource, err, &sentValidationErrors, failure) {
+						return
 					}
-					atomic.AddUint64(&tableMetrics.Errors, 1)
+				}
+				if failure.failed.Load() {
 					return
 				}
+				watermarksFromContext(ctx).update(resolvedResource)
 				resourcesChan <- resolvedResource
 			}()
 		}
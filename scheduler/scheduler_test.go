@@ -255,3 +255,119 @@ func testSyncTable(t *testing.T, tc syncTestCase, strategy Strategy, determinist
 		t.Fatalf("expected %d resources. got %d", len(tc.data), i)
 	}
 }
+
+func testResolverValidation(_ context.Context, _ schema.ClientMeta, _ *schema.Resource, res chan<- any) error {
+	// items are sent one by one, so they are resolved in order
+	res <- map[string]any{"Id": 1, "Value": 5}
+	res <- map[string]any{"Id": 2, "Value": 500}
+	res <- map[string]any{"Id": 3, "Value": 50}
+	res <- map[string]any{"Value": 5}
+	return nil
+}
+
+func testTableValidation() *schema.Table {
+	return &schema.Table{
+		Name:     "test_table_validation",
+		Resolver: testResolverValidation,
+		Columns: []schema.Column{
+			{
+				Name:    "id",
+				Type:    arrow.PrimitiveTypes.Int64,
+				NotNull: true,
+			},
+			{
+				Name:      "value",
+				Type:      arrow.PrimitiveTypes.Int64,
+				Validator: schema.ValidateRange(0, 100),
+			},
+		},
+	}
+}
+
+func TestSchedulerValidationPolicy(t *testing.T) {
+	cases := []struct {
+		policy ValidationPolicy
+		data   []scalar.Vector
+	}{
+		{
+			policy: ValidationPolicyDropRow,
+			data: []scalar.Vector{
+				{&scalar.Int{Value: 1, Valid: true}, &scalar.Int{Value: 5, Valid: true}},
+				{&scalar.Int{Value: 3, Valid: true}, &scalar.Int{Value: 50, Valid: true}},
+			},
+		},
+		{
+			policy: ValidationPolicyNullField,
+			data: []scalar.Vector{
+				{&scalar.Int{Value: 1, Valid: true}, &scalar.Int{Value: 5, Valid: true}},
+				{&scalar.Int{Value: 2, Valid: true}, &scalar.Int{}},
+				{&scalar.Int{Value: 3, Valid: true}, &scalar.Int{Value: 50, Valid: true}},
+			},
+		},
+		{
+			policy: ValidationPolicyFailTable,
+			data: []scalar.Vector{
+				{&scalar.Int{Value: 1, Valid: true}, &scalar.Int{Value: 5, Valid: true}},
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			sc := NewScheduler(
+				WithLogger(zerolog.New(zerolog.NewTestWriter(t))),
+				WithValidationPolicy(tc.policy),
+			)
+			msgs, err := sc.SyncAll(context.Background(), &testExecutionClient{}, schema.Tables{testTableValidation()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			inserts := msgs.GetInserts()
+			if len(inserts) != len(tc.data) {
+				t.Fatalf("expected %d resources. got %d", len(tc.data), len(inserts))
+			}
+			for i, insert := range inserts {
+				rec := tc.data[i].ToArrowRecord(insert.Record.Schema())
+				if !array.RecordEqual(rec, insert.Record) {
+					t.Fatalf("expected at i=%d: %v. got %v", i, tc.data[i], insert.Record)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerConversionMode(t *testing.T) {
+	table := &schema.Table{
+		Name:    "test_table_conversion",
+		Columns: []schema.Column{{Name: "test_column", Type: arrow.PrimitiveTypes.Int64}},
+	}
+	for _, mode := range []ConversionMode{ConversionModeStrict, ConversionModeLenient} {
+		mode := mode
+		t.Run(mode.String(), func(t *testing.T) {
+			s := &syncClient{
+				scheduler: NewScheduler(WithConversionMode(mode)),
+				logger:    zerolog.New(zerolog.NewTestWriter(t)),
+				metrics:   &Metrics{TableClient: make(map[string]map[string]*TableClientMetrics)},
+			}
+			client := &testExecutionClient{}
+			s.metrics.initWithClients(table, []schema.ClientMeta{client})
+			tableMetrics := s.metrics.TableClient[table.Name][client.ID()]
+			resource := schema.NewResourceData(table, nil, map[string]any{"TestColumn": "not a number"})
+			s.resolveColumn(context.Background(), s.logger, tableMetrics, client, resource, table.Columns[0])
+
+			if resource.Get("test_column").IsValid() {
+				t.Fatal("expected column to be null")
+			}
+			wantErrors := uint64(1)
+			if mode == ConversionModeLenient {
+				wantErrors = 0
+			}
+			if tableMetrics.Errors != wantErrors {
+				t.Fatalf("expected %d errors. got %d", wantErrors, tableMetrics.Errors)
+			}
+			if tableMetrics.Panics != 0 {
+				t.Fatalf("expected no panics. got %d", tableMetrics.Panics)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/getsentry/sentry-go"
+)
+
+// ValidationPolicy controls what the scheduler does with resources that fail schema.Resource.Validate
+type ValidationPolicy int
+
+const (
+	// ValidationPolicyDropRow drops the invalid resource (default)
+	ValidationPolicyDropRow ValidationPolicy = iota
+	// ValidationPolicyNullField sets the values that failed a column validator to null.
+	// Resources with missing primary keys or null NotNull columns can't be fixed this way and are dropped.
+	ValidationPolicyNullField
+	// ValidationPolicyFailTable drops the invalid resource and stops resolving the table (and its relations) for the client
+	ValidationPolicyFailTable
+)
+
+var AllValidationPolicyNames = [...]string{
+	ValidationPolicyDropRow:   "drop-row",
+	ValidationPolicyNullField: "null-field",
+	ValidationPolicyFailTable: "fail-table",
+}
+
+func (p ValidationPolicy) String() string {
+	if p < 0 || int(p) >= len(AllValidationPolicyNames) {
+		return fmt.Sprintf("unknown(%d)", int(p))
+	}
+	return AllValidationPolicyNames[p]
+}
+
+func ValidationPolicyForName(s string) (ValidationPolicy, error) {
+	for i, name := range AllValidationPolicyNames {
+		if name == s {
+			return ValidationPolicy(i), nil
+		}
+	}
+	return ValidationPolicyDropRow, fmt.Errorf("unknown validation policy: %s", s)
+}
+
+// ConversionMode controls how the scheduler handles column values that can't be converted to the column type
+type ConversionMode int
+
+const (
+	// ConversionModeStrict reports conversion errors as column resolver errors (default)
+	ConversionModeStrict ConversionMode = iota
+	// ConversionModeLenient sets the column to null and logs a warning
+	ConversionModeLenient
+)
+
+var AllConversionModeNames = [...]string{
+	ConversionModeStrict:  "strict",
+	ConversionModeLenient: "lenient",
+}
+
+func (m ConversionMode) String() string {
+	if m < 0 || int(m) >= len(AllConversionModeNames) {
+		return fmt.Sprintf("unknown(%d)", int(m))
+	}
+	return AllConversionModeNames[m]
+}
+
+func ConversionModeForName(s string) (ConversionMode, error) {
+	for i, name := range AllConversionModeNames {
+		if name == s {
+			return ConversionMode(i), nil
+		}
+	}
+	return ConversionModeStrict, fmt.Errorf("unknown conversion mode: %s", s)
+}
+
+// tableFailure is used by ValidationPolicyFailTable to stop resolving a table
+type tableFailure struct {
+	cancel context.CancelFunc
+	failed atomic.Bool
+}
+
+func (f *tableFailure) fail() {
+	f.failed.Store(true)
+	f.cancel()
+}
+
+// handleValidationError applies the validation policy to a resource that failed validation.
+// It returns true if the resource should still be sent.
+func (s *syncClient) handleValidationError(table *schema.Table, client schema.ClientMeta, resource *schema.Resource, err error, sentValidationErrors *sync.Map, failure *tableFailure) bool {
+	tableMetrics := s.metrics.TableClient[table.Name][client.ID()]
+	logger := s.logger.With().Str("table", table.Name).Str("client", client.ID()).Logger()
+	atomic.AddUint64(&tableMetrics.ValidationErrors, 1)
+
+	var resourceErr *schema.ResourceValidationError
+	if s.scheduler.validationPolicy == ValidationPolicyNullField && errors.As(err, &resourceErr) && nullInvalidFields(resource, resourceErr) {
+		logger.Warn().Err(err).Msg("resource resolver finished with validation error, invalid values set to null")
+		return true
+	}
+
+	logger.Error().Err(err).Msg("resource resolver finished with validation error")
+	if _, found := sentValidationErrors.LoadOrStore(table.Name, struct{}{}); !found {
+		// send resource validation errors to Sentry only once per table,
+		// to avoid sending too many duplicate messages
+		sentry.WithScope(func(scope *sentry.Scope) {
+			scope.SetTag("table", table.Name)
+			sentry.CurrentHub().CaptureMessage(err.Error())
+		})
+	}
+	atomic.AddUint64(&tableMetrics.Errors, 1)
+	if s.scheduler.validationPolicy == ValidationPolicyFailTable && !failure.failed.Load() {
+		logger.Error().Msg("table resolver stopped due to validation error")
+		failure.fail()
+	}
+	return false
+}
+
+// nullInvalidFields sets the values that failed a column validator to null.
+// It returns false, leaving the resource untouched, if any violation can't be fixed by setting a null value.
+func nullInvalidFields(resource *schema.Resource, err *schema.ResourceValidationError) bool {
+	for _, v := range err.Violations {
+		c := resource.Table.Columns.Get(v.Column)
+		if v.Kind != schema.ViolationColumnValidator || c.PrimaryKey || c.NotNull {
+			return false
+		}
+	}
+	for _, v := range err.Violations {
+		if setErr := resource.Set(v.Column, nil); setErr != nil {
+			return false
+		}
+	}
+	return true
+}
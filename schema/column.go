@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
 )
 
 type ColumnList []Column
@@ -14,6 +15,10 @@ type ColumnList []Column
 // resource holds the current row we are resolving the column for.
 type ColumnResolver func(ctx context.Context, meta ClientMeta, resource *Resource, c Column) error
 
+// ColumnValidator is called by Resource.Validate for each non-null value of the column.
+// Returning an error marks the value as invalid. See ValidateRegex, ValidateLength and ValidateRange for built-in validators.
+type ColumnValidator func(c Column, value scalar.Scalar) error
+
 // Column definition for Table
 type Column struct {
 	// Name of column
@@ -42,6 +47,9 @@ type Column struct {
 	IncrementalKey bool
 	// Unique requires the destinations supporting this to mark this column as unique
 	Unique bool
+	// Validator is an optional hook to validate the resolved (non-null) values of this column.
+	// It is called after all the column resolvers, as part of Resource.Validate.
+	Validator ColumnValidator
 }
 
 // NewColumnFromArrowField creates a new Column from an arrow.Field
@@ -63,6 +71,8 @@ func NewColumnFromArrowField(f arrow.Field) Column {
 	v, ok = f.Metadata.GetValue(MetadataIncremental)
 	column.IncrementalKey = ok && v == MetadataTrue
 
+	column.Description, _ = f.Metadata.GetValue(MetadataDescription)
+
 	return column
 }
 
@@ -81,6 +91,9 @@ func (c Column) ToArrowField() arrow.Field {
 	if c.IncrementalKey {
 		mdKV[MetadataIncremental] = MetadataTrue
 	}
+	if c.Description != "" {
+		mdKV[MetadataDescription] = c.Description
+	}
 
 	return arrow.Field{
 		Name:     c.Name,
@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+)
+
+// ValidateRegex returns a column validator that requires the string representation of the value to match re
+func ValidateRegex(re *regexp.Regexp) ColumnValidator {
+	return func(_ Column, value scalar.Scalar) error {
+		if !re.MatchString(value.String()) {
+			return fmt.Errorf("value does not match %s", re.String())
+		}
+		return nil
+	}
+}
+
+// ValidateLength returns a column validator that requires the length of the value to be in [minLength, maxLength].
+// Strings are measured in characters, binary values in bytes and lists in elements.
+// A negative maxLength means there is no upper bound.
+func ValidateLength(minLength, maxLength int) ColumnValidator {
+	return func(c Column, value scalar.Scalar) error {
+		var length int
+		switch v := value.Get().(type) {
+		case string:
+			length = utf8.RuneCountInString(v)
+		case []byte:
+			length = len(v)
+		case scalar.Vector:
+			length = len(v)
+		default:
+			return fmt.Errorf("length validation is not supported for type %s", c.Type)
+		}
+		if length < minLength || (maxLength >= 0 && length > maxLength) {
+			return fmt.Errorf("length %d is out of range [%d, %d]", length, minLength, maxLength)
+		}
+		return nil
+	}
+}
+
+// ValidateRange returns a column validator that requires a numeric value to be in [minValue, maxValue]
+func ValidateRange(minValue, maxValue float64) ColumnValidator {
+	return func(c Column, value scalar.Scalar) error {
+		var n float64
+		switch v := value.Get().(type) {
+		case int64:
+			n = float64(v)
+		case uint64:
+			n = float64(v)
+		case float64:
+			n = v
+		default:
+			return fmt.Errorf("range validation is not supported for type %s", c.Type)
+		}
+		if n < minValue || n > maxValue {
+			return fmt.Errorf("value %v is out of range [%v, %v]", value, minValue, maxValue)
+		}
+		return nil
+	}
+}
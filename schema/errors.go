@@ -2,7 +2,9 @@
 package schema
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/apache/arrow/go/v13/arrow"
 )
@@ -17,28 +19,97 @@ const (
 	cannotSetIndex              = "cannot set index %d"
 )
 
+// ErrColumnNotFound is wrapped by the *ValidationError returned when setting a column that is not part of the table
+var ErrColumnNotFound = errors.New("column not found")
+
 type ValidationError struct {
 	Err   error
 	Msg   string
 	Type  arrow.DataType
 	Value any
+	// Table and Column are set when the error is returned by Resource.Set
+	Table  string
+	Column string
 }
 
 func (e *ValidationError) Error() string {
-	if e.Err == nil {
-		return fmt.Sprintf("cannot set `%s` with value `%v`: %s", e.Type, e.Value, e.Msg)
-	}
-	return fmt.Sprintf("cannot set `%s` with value `%v`: %s (%s)", e.Type, e.Value, e.Msg, e.Err)
+	return e.format(true)
 }
 
 // this prints the error without the value
 func (e *ValidationError) MaskedError() string {
-	if e.Err == nil {
-		return fmt.Sprintf("cannot set `%s`: %s", e.Type, e.Msg)
+	return e.format(false)
+}
+
+func (e *ValidationError) format(withValue bool) string {
+	var sb strings.Builder
+	sb.WriteString("cannot set ")
+	if e.Column != "" {
+		fmt.Fprintf(&sb, "column `%s` ", e.Column)
+		if e.Type != nil {
+			fmt.Fprintf(&sb, "(`%s`) ", e.Type)
+		}
+	} else {
+		fmt.Fprintf(&sb, "`%s` ", e.Type)
+	}
+	if withValue {
+		fmt.Fprintf(&sb, "with value `%v`", e.Value)
 	}
-	return fmt.Sprintf("cannot set `%s`: %s (%s)", e.Type, e.Msg, e.Err)
+	s := strings.TrimSuffix(sb.String(), " ") + ": " + e.Msg
+	var masked interface{ MaskedError() string }
+	switch {
+	case e.Err == nil:
+	case !withValue && errors.As(e.Err, &masked):
+		s += fmt.Sprintf(" (%s)", masked.MaskedError())
+	default:
+		s += fmt.Sprintf(" (%s)", e.Err)
+	}
+	return s
 }
 
 func (e *ValidationError) Unwrap() error {
 	return e.Err
 }
+
+type ViolationKind int
+
+const (
+	ViolationMissingPrimaryKey ViolationKind = iota
+	ViolationNotNull
+	ViolationColumnValidator
+)
+
+// ColumnViolation is a single column of a resource that failed validation
+type ColumnViolation struct {
+	Column string
+	Kind   ViolationKind
+	// Err is the error returned by the column validator (only set for ViolationColumnValidator)
+	Err error
+}
+
+// ResourceValidationError is returned by Resource.Validate
+type ResourceValidationError struct {
+	Table      string
+	Violations []ColumnViolation
+}
+
+func (e *ResourceValidationError) Error() string {
+	var missingPks, nullColumns, msgs []string
+	for _, v := range e.Violations {
+		switch v.Kind {
+		case ViolationMissingPrimaryKey:
+			missingPks = append(missingPks, v.Column)
+		case ViolationNotNull:
+			nullColumns = append(nullColumns, v.Column)
+		case ViolationColumnValidator:
+			msgs = append(msgs, fmt.Sprintf("invalid value on column %s: %s", v.Column, v.Err))
+		}
+	}
+	if len(nullColumns) > 0 {
+		msgs = append([]string{fmt.Sprintf("null value on not null columns: %v", nullColumns)}, msgs...)
+	}
+	if len(missingPks) > 0 {
+		msgs = append([]string{fmt.Sprintf("missing primary key on columns: %v", missingPks)}, msgs...)
+	}
+	return strings.Join(msgs, "; ")
+}
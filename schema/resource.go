@@ -2,7 +2,6 @@ package schema
 
 import (
 	"crypto/sha256"
-	"fmt"
 
 	"github.com/cloudquery/plugin-sdk/v4/scalar"
 	"github.com/google/uuid"
@@ -49,18 +48,39 @@ func (r *Resource) Get(columnName string) scalar.Scalar {
 }
 
 // Set sets a column with value. This does validation and conversion to
-// one of concrete  it returns an error just for backward compatibility
-// and panics in case it fails
+// the column type. If the column doesn't exist or the value can't be converted,
+// a *ValidationError is returned and the column keeps its previous value.
 func (r *Resource) Set(columnName string, value any) error {
 	index := r.Table.Columns.Index(columnName)
 	if index == -1 {
-		// we panic because we want to distinguish between code error and api error
-		// this also saves additional checks in our testing code
-		panic(columnName + " column not found")
+		return &ValidationError{
+			Err:    ErrColumnNotFound,
+			Msg:    "failed to set column",
+			Value:  value,
+			Table:  r.Table.Name,
+			Column: columnName,
+		}
 	}
-	if err := r.data[index].Set(value); err != nil {
-		panic(fmt.Errorf("failed to set column %s: %w", columnName, err))
+	s, overwrite := r.data[index], r.data[index].IsValid()
+	if overwrite {
+		// convert into a new scalar, so a failed conversion keeps the current value
+		s = scalar.NewScalar(r.Table.Columns[index].Type)
+	}
+	if err := s.Set(value); err != nil {
+		if !overwrite {
+			// the column was null, don't leave a partially set value behind
+			r.data[index] = scalar.NewScalar(r.Table.Columns[index].Type)
+		}
+		return &ValidationError{
+			Err:    err,
+			Msg:    "failed to set column",
+			Type:   r.Table.Columns[index].Type,
+			Value:  value,
+			Table:  r.Table.Name,
+			Column: columnName,
+		}
 	}
+	r.data[index] = s
 	return nil
 }
 
@@ -109,18 +129,25 @@ func (r *Resource) storeCQID(value uuid.UUID) error {
 	return r.Set(CqIDColumn.Name, b)
 }
 
-// Validates that all primary keys have values.
+// Validate checks that all primary keys and NotNull columns have values,
+// and runs the column validators (if any) against the non-null values.
+// It returns a *ResourceValidationError listing every violation found.
 func (r *Resource) Validate() error {
-	var missingPks []string
+	var violations []ColumnViolation
 	for i, c := range r.Table.Columns {
-		if c.PrimaryKey {
-			if !r.data[i].IsValid() {
-				missingPks = append(missingPks, c.Name)
+		switch {
+		case !r.data[i].IsValid() && c.PrimaryKey:
+			violations = append(violations, ColumnViolation{Column: c.Name, Kind: ViolationMissingPrimaryKey})
+		case !r.data[i].IsValid() && c.NotNull:
+			violations = append(violations, ColumnViolation{Column: c.Name, Kind: ViolationNotNull})
+		case r.data[i].IsValid() && c.Validator != nil:
+			if err := c.Validator(c, r.data[i]); err != nil {
+				violations = append(violations, ColumnViolation{Column: c.Name, Kind: ViolationColumnValidator, Err: err})
 			}
 		}
 	}
-	if len(missingPks) > 0 {
-		return fmt.Errorf("missing primary key on columns: %v", missingPks)
+	if len(violations) > 0 {
+		return &ResourceValidationError{Table: r.Table.Name, Violations: violations}
 	}
 	return nil
 }
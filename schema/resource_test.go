@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceValidate(t *testing.T) {
+	table := &Table{
+		Name: "test_table",
+		Columns: ColumnList{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64, PrimaryKey: true},
+			{Name: "name", Type: arrow.BinaryTypes.String, NotNull: true},
+			{Name: "code", Type: arrow.BinaryTypes.String, Validator: ValidateRegex(regexp.MustCompile(`^[A-Z]{3}$`))},
+			{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String), Validator: ValidateLength(1, 2)},
+			{Name: "percent", Type: arrow.PrimitiveTypes.Float64, Validator: ValidateRange(0, 100)},
+		},
+	}
+
+	r := NewResourceData(table, nil, nil)
+	require.NoError(t, r.Set("id", 1))
+	require.NoError(t, r.Set("name", "test"))
+	require.NoError(t, r.Set("code", "ABC"))
+	require.NoError(t, r.Set("tags", []string{"a"}))
+	require.NoError(t, r.Set("percent", 50.5))
+	require.NoError(t, r.Validate())
+
+	// null values are not passed to column validators
+	require.NoError(t, r.Set("code", nil))
+	require.NoError(t, r.Validate())
+
+	r = NewResourceData(table, nil, nil)
+	require.NoError(t, r.Set("code", "abc"))
+	require.NoError(t, r.Set("tags", []string{"a", "b", "c"}))
+	require.NoError(t, r.Set("percent", 101.0))
+	err := r.Validate()
+	var validationErr *ResourceValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "test_table", validationErr.Table)
+	kinds := make(map[string]ViolationKind)
+	for _, v := range validationErr.Violations {
+		kinds[v.Column] = v.Kind
+	}
+	assert.Equal(t, map[string]ViolationKind{
+		"id":      ViolationMissingPrimaryKey,
+		"name":    ViolationNotNull,
+		"code":    ViolationColumnValidator,
+		"tags":    ViolationColumnValidator,
+		"percent": ViolationColumnValidator,
+	}, kinds)
+	assert.Equal(t, "missing primary key on columns: [id]; null value on not null columns: [name]; "+
+		"invalid value on column code: value does not match ^[A-Z]{3}$; "+
+		"invalid value on column tags: length 3 is out of range [1, 2]; "+
+		"invalid value on column percent: value 101 is out of range [0, 100]", err.Error())
+}
+
+func TestResourceSetErrors(t *testing.T) {
+	table := &Table{
+		Name:    "test_table",
+		Columns: ColumnList{{Name: "id", Type: arrow.PrimitiveTypes.Int64}},
+	}
+	r := NewResourceData(table, nil, nil)
+
+	err := r.Set("unknown", 1)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.ErrorIs(t, err, ErrColumnNotFound)
+	assert.Equal(t, "unknown", validationErr.Column)
+
+	require.NoError(t, r.Set("id", 1))
+	err = r.Set("id", "not a number")
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "test_table", validationErr.Table)
+	assert.Equal(t, "id", validationErr.Column)
+	assert.Equal(t, arrow.PrimitiveTypes.Int64, validationErr.Type)
+	assert.NotContains(t, validationErr.MaskedError(), "not a number")
+	// the column keeps its previous value on failure
+	assert.Equal(t, "1", r.Get("id").String())
+
+	r = NewResourceData(table, nil, nil)
+	require.Error(t, r.Set("id", "not a number"))
+	assert.False(t, r.Get("id").IsValid())
+
+	// list conversions failing on a later element don't leave the earlier elements behind
+	listTable := &Table{
+		Name:    "test_table",
+		Columns: ColumnList{{Name: "ids", Type: arrow.ListOf(arrow.PrimitiveTypes.Int64)}},
+	}
+	r = NewResourceData(listTable, nil, nil)
+	require.Error(t, r.Set("ids", []any{1, "not a number"}))
+	assert.False(t, r.Get("ids").IsValid())
+	require.NoError(t, r.Set("ids", []int64{1, 2}))
+	require.Error(t, r.Set("ids", []any{3, "not a number"}))
+	assert.Equal(t, "[1, 2]", r.Get("ids").String())
+}
+
+func TestResourceSetAllocs(t *testing.T) {
+	table := &Table{
+		Name:    "test_table",
+		Columns: ColumnList{{Name: "id", Type: arrow.PrimitiveTypes.Int64}},
+	}
+	resources := make([]*Resource, 100)
+	for i := range resources {
+		resources[i] = NewResourceData(table, nil, nil)
+	}
+	i := 0
+	// setting a null column converts into its existing scalar
+	allocs := testing.AllocsPerRun(len(resources)-1, func() {
+		if err := resources[i].Set("id", 1); err != nil {
+			t.Fatal(err)
+		}
+		i++
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestResourceSetStruct(t *testing.T) {
+	type item struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	table := &Table{
+		Name: "test_table",
+		Columns: ColumnList{{Name: "item", Type: arrow.StructOf(
+			arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+			arrow.Field{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+		)}},
+	}
+	want := map[string]any{"id": json.Number("1"), "name": "a"}
+	for _, value := range []any{item{ID: 1, Name: "a"}, &item{ID: 1, Name: "a"}} {
+		r := NewResourceData(table, nil, nil)
+		require.NoError(t, r.Set("item", value))
+		// Go structs are converted with their JSON encoding, so they can be appended to a record
+		assert.Equal(t, want, r.Get("item").Get())
+		rec := r.GetValues().ToArrowRecord(table.ToArrowSchema())
+		assert.Equal(t, `{"id":1,"name":"a"}`, rec.Column(0).ValueStr(0))
+		rec.Release()
+	}
+
+	// maps and JSON strings are set as before
+	r := NewResourceData(table, nil, nil)
+	m := map[string]any{"id": int64(2), "name": "b"}
+	require.NoError(t, r.Set("item", m))
+	assert.Equal(t, m, r.Get("item").Get())
+	require.NoError(t, r.Set("item", `{"id":3,"name":"c"}`))
+	assert.Equal(t, map[string]any{"id": float64(3), "name": "c"}, r.Get("item").Get())
+	require.NoError(t, r.Set("item", (*item)(nil)))
+	assert.False(t, r.Get("item").IsValid())
+}
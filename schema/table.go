@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/cloudquery/plugin-sdk/v4/glob"
@@ -201,51 +202,42 @@ func (tt Tables) ToArrowSchemas() Schemas {
 	return schemas
 }
 
+// FilterDfs filters the tables (and their relations) by the given glob patterns.
+// Patterns prefixed with "!" exempt matching tables from the rest of the list, so skipTables with only negated patterns
+// skip nothing. See glob.Glob for the supported syntax.
 func (tt Tables) FilterDfs(tables, skipTables []string, skipDependentTables bool) (Tables, error) {
 	flattenedTables := tt.FlattenTables()
 	for _, includePattern := range tables {
-		matched := false
-		for _, table := range flattenedTables {
-			if glob.Glob(includePattern, table.Name) {
-				matched = true
-				break
-			}
-		}
-		if !matched {
+		if !flattenedTables.anyMatches(includePattern) {
 			return nil, fmt.Errorf("tables include a pattern %s with no matches", includePattern)
 		}
 	}
 	for _, excludePattern := range skipTables {
-		matched := false
-		for _, table := range flattenedTables {
-			if glob.Glob(excludePattern, table.Name) {
-				matched = true
-				break
-			}
-		}
-		if !matched {
+		if !flattenedTables.anyMatches(excludePattern) {
 			return nil, fmt.Errorf("skip_tables include a pattern %s with no matches", excludePattern)
 		}
 	}
 	include := func(t *Table) bool {
-		for _, includePattern := range tables {
-			if glob.Glob(includePattern, t.Name) {
-				return true
-			}
-		}
-		return false
+		return glob.Match(tables, t.Name)
 	}
 	exclude := func(t *Table) bool {
-		for _, skipPattern := range skipTables {
-			if glob.Glob(skipPattern, t.Name) {
-				return true
-			}
-		}
-		return false
+		return glob.Skipped(skipTables, t.Name)
 	}
 	return tt.FilterDfsFunc(include, exclude, skipDependentTables), nil
 }
 
+// anyMatches reports whether any table name matches the pattern.
+// Negated patterns are checked against the pattern they negate, as negating a pattern that matches nothing is a no-op.
+func (tt Tables) anyMatches(pattern string) bool {
+	pattern = strings.TrimPrefix(pattern, glob.NEGATE)
+	for _, table := range tt {
+		if glob.Glob(pattern, table.Name) {
+			return true
+		}
+	}
+	return false
+}
+
 func (tt Tables) FlattenTables() Tables {
 	tables := make(Tables, 0, len(tt))
 	for _, t := range tt {
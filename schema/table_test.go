@@ -239,6 +239,33 @@ func TestTablesFilterDFS(t *testing.T) {
 			skipDependentTables:     true,
 			want:                    []string{"main_table_1", "main_table_2", "sub_table_2"},
 		},
+		{
+			name: "brace alternation and negation",
+			tables: []*Table{
+				{Name: "aws_ec2_instances"},
+				{Name: "aws_ec2_volumes"},
+				{Name: "aws_ec2_logs"},
+				{Name: "aws_s3_buckets"},
+			},
+			configurationTables:     []string{"aws_ec2_{instances,volumes,logs}", "!aws_*_logs"},
+			configurationSkipTables: []string{},
+			want:                    []string{"aws_ec2_instances", "aws_ec2_volumes"},
+		},
+		{
+			name:                    "skip tables with only negated patterns skip nothing",
+			tables:                  []*Table{{Name: "table1"}, {Name: "table2"}},
+			configurationTables:     []string{"*"},
+			configurationSkipTables: []string{"!table1"},
+			want:                    []string{"table1", "table2"},
+		},
+		{
+			name:                    "negated pattern with no matches",
+			tables:                  []*Table{{Name: "main_table"}},
+			configurationTables:     []string{"*", "!other_table"},
+			configurationSkipTables: []string{},
+			want:                    []string{},
+			err:                     "tables include a pattern !other_table with no matches",
+		},
 	}
 
 	for _, tt := range tests {
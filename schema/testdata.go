@@ -3,6 +3,7 @@ package schema
 import (
 	"encoding/base64"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/apache/arrow/go/v13/arrow/array"
 	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
 	"github.com/cloudquery/plugin-sdk/v4/types"
 	"github.com/google/uuid"
 	"golang.org/x/exp/rand"
@@ -41,7 +43,6 @@ func listOfColumns(baseColumns []Column) []Column {
 }
 
 // mapOfColumns returns a list of columns that are maps of the given columns.
-// nolint:unused
 func mapOfColumns(baseColumns []Column) []Column {
 	columns := make([]Column, len(baseColumns)*2)
 	for i := 0; i < len(columns); i += 2 {
@@ -200,6 +201,14 @@ type GenTestDataOptions struct {
 	Seed int64
 	// NullRows indicates whether to generate rows with all null values.
 	NullRows bool
+	// NullRatio is the probability of the values of nullable columns to be null,
+	// in the rows generated by GenerateRecord and GenerateTables.
+	NullRatio float64
+	// ColumnNullRatios overrides NullRatio for the columns with the given names.
+	ColumnNullRatios map[string]float64
+	// EdgeCaseRatio is the probability of the values generated by GenerateRecord and GenerateTables to be edge cases,
+	// such as the minimum and maximum integers, unicode and empty strings, and empty lists.
+	EdgeCaseRatio float64
 }
 
 type TestDataGenerator struct {
@@ -248,33 +257,234 @@ func (tg *TestDataGenerator) Generate(table *Table, opts GenTestDataOptions) []a
 	return records
 }
 
+// GenerateRecord generates a record with opts.MaxRows rows of the table. The rows are generated from opts.Seed,
+// including the UUIDs and times that aren't set with StableUUID and StableTime, so the same seed generates the same record
+// with a new generator. Nullable columns are null with the probability set in the options, and values are edge cases
+// with the probability opts.EdgeCaseRatio.
+func (tg *TestDataGenerator) GenerateRecord(table *Table, opts GenTestDataOptions) arrow.Record {
+	return tg.generateRecord(newValueGenerator(opts), table, opts, nil)
+}
+
+// GenerateTables generates a record with opts.MaxRows rows for every table and relation, in depth-first order
+// (see GenerateRecord). The _cq_parent_id of the rows of relations are the _cq_id of the rows generated for their parent,
+// assigned in turn.
+func (tg *TestDataGenerator) GenerateTables(tables Tables, opts GenTestDataOptions) []arrow.Record {
+	g := newValueGenerator(opts)
+	var records []arrow.Record
+	var generate func(table *Table, parentIDs []string)
+	generate = func(table *Table, parentIDs []string) {
+		record := tg.generateRecord(g, table, opts, parentIDs)
+		records = append(records, record)
+		var ids []string
+		if indices := record.Schema().FieldIndices(CqIDColumn.Name); len(indices) > 0 {
+			arr := record.Column(indices[0])
+			for i := 0; i < arr.Len(); i++ {
+				if arr.IsValid(i) {
+					ids = append(ids, arr.ValueStr(i))
+				}
+			}
+		}
+		for _, rel := range table.Relations {
+			generate(rel, ids)
+		}
+	}
+	for _, table := range tables {
+		generate(table, nil)
+	}
+	return records
+}
+
+func (tg *TestDataGenerator) generateRecord(g *valueGenerator, table *Table, opts GenTestDataOptions, parentIDs []string) arrow.Record {
+	bldr := array.NewRecordBuilder(memory.DefaultAllocator, table.ToArrowSchema())
+	defer bldr.Release()
+	for j := 0; j < opts.MaxRows; j++ {
+		tg.counter++
+		for i, c := range table.Columns {
+			var example string
+			switch {
+			case c.Name == CqParentIDColumn.Name:
+				if len(parentIDs) == 0 {
+					bldr.Field(i).AppendNull()
+					continue
+				}
+				example = `"` + parentIDs[j%len(parentIDs)] + `"`
+			case g.null(c, opts):
+				bldr.Field(i).AppendNull()
+				continue
+			default:
+				example = tg.exampleJSON(g, c.Name, c.Type, opts)
+			}
+			l := `[` + example + `]`
+			if err := bldr.Field(i).UnmarshalJSON([]byte(l)); err != nil {
+				panic(fmt.Sprintf("failed to unmarshal json `%v` for column %v: %v", l, c.Name, err))
+			}
+		}
+	}
+	return bldr.NewRecord()
+}
+
+// valueGenerator generates the values of GenerateRecord and GenerateTables from a single seeded source
+type valueGenerator struct {
+	rnd           *rand.Rand
+	edgeCaseRatio float64
+}
+
+// baseTestTime is the earliest time generated by valueGenerator
+var baseTestTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newValueGenerator(opts GenTestDataOptions) *valueGenerator {
+	return &valueGenerator{
+		rnd:           rand.New(rand.NewSource(uint64(opts.Seed))),
+		edgeCaseRatio: opts.EdgeCaseRatio,
+	}
+}
+
+// withoutEdgeCases returns a generator using the same source that doesn't generate edge cases, e.g. for map keys
+func (g *valueGenerator) withoutEdgeCases() *valueGenerator {
+	if g == nil {
+		return nil
+	}
+	return &valueGenerator{rnd: g.rnd}
+}
+
+// null reports whether the next value of the column is null
+func (g *valueGenerator) null(c Column, opts GenTestDataOptions) bool {
+	if c.NotNull || c.PrimaryKey || c.Name == CqIDColumn.Name || c.Name == CqSourceNameColumn.Name || c.Name == CqSyncTimeColumn.Name {
+		return false
+	}
+	ratio, ok := opts.ColumnNullRatios[c.Name]
+	if !ok {
+		ratio = opts.NullRatio
+	}
+	return ratio > 0 && g.rnd.Float64() < ratio
+}
+
+func (g *valueGenerator) uuid() uuid.UUID {
+	var b [16]byte
+	g.rnd.Read(b[:])
+	// set the version 4 and variant bits
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return uuid.UUID(b)
+}
+
+func (g *valueGenerator) time() time.Time {
+	return baseTestTime.Add(time.Duration(g.rnd.Int63n(int64(365 * 24 * time.Hour))))
+}
+
+// edgeCaseJSON returns a random edge case value of the type, if it has edge cases
+func edgeCaseJSON(rnd *rand.Rand, dataType arrow.DataType) (string, bool) {
+	pick := func(values ...string) (string, bool) {
+		return values[rnd.Intn(len(values))], true
+	}
+	if arrow.TypeEqual(dataType, types.ExtensionTypes.JSON) {
+		return pick(`{}`, `[]`, `{"unicode":"héllo 世界 🚀"}`)
+	}
+	if _, ok := scalar.ExtensionFor(dataType); ok {
+		return "", false
+	}
+	switch dataType.ID() {
+	case arrow.INT8:
+		return pick(strconv.Itoa(math.MinInt8), strconv.Itoa(math.MaxInt8), "0")
+	case arrow.INT16:
+		return pick(strconv.Itoa(math.MinInt16), strconv.Itoa(math.MaxInt16), "0")
+	case arrow.INT32:
+		return pick(strconv.Itoa(math.MinInt32), strconv.Itoa(math.MaxInt32), "0")
+	case arrow.INT64:
+		return pick(strconv.FormatInt(math.MinInt64, 10), strconv.FormatInt(math.MaxInt64, 10), "0")
+	case arrow.UINT8:
+		return pick(strconv.Itoa(math.MaxUint8), "0")
+	case arrow.UINT16:
+		return pick(strconv.Itoa(math.MaxUint16), "0")
+	case arrow.UINT32:
+		return pick(strconv.FormatUint(math.MaxUint32, 10), "0")
+	case arrow.UINT64:
+		return pick(strconv.FormatUint(math.MaxUint64, 10), "0")
+	case arrow.FLOAT32:
+		return pick(strconv.FormatFloat(math.MaxFloat32, 'g', -1, 32), strconv.FormatFloat(-math.MaxFloat32, 'g', -1, 32), "0")
+	case arrow.FLOAT64:
+		return pick(strconv.FormatFloat(math.MaxFloat64, 'g', -1, 64), strconv.FormatFloat(-math.MaxFloat64, 'g', -1, 64), "0")
+	case arrow.DECIMAL128, arrow.DECIMAL256:
+		dec := dataType.(arrow.DecimalType)
+		largest := strings.Repeat("9", int(dec.GetPrecision()-dec.GetScale()))
+		if dec.GetScale() > 0 {
+			largest += "." + strings.Repeat("9", int(dec.GetScale()))
+		}
+		// quoted, as numbers are parsed as floats and lose precision
+		return pick(`"`+largest+`"`, `"-`+largest+`"`, `"0"`)
+	case arrow.STRING, arrow.LARGE_STRING:
+		return pick(`""`, `"héllo 世界 🚀"`, `"line\nbreak \"quoted\" \\ back\tslash"`)
+	case arrow.BINARY, arrow.LARGE_BINARY:
+		return `""`, true
+	case arrow.LIST, arrow.LARGE_LIST, arrow.MAP:
+		return `[]`, true
+	default:
+		return "", false
+	}
+}
+
 func (tg TestDataGenerator) getExampleJSON(colName string, dataType arrow.DataType, opts GenTestDataOptions) string {
-	src := rand.NewSource(uint64(opts.Seed))
-	rnd := rand.New(src)
+	return tg.exampleJSON(nil, colName, dataType, opts)
+}
+
+// exampleJSON returns an example value of the type. If g is nil, the values are generated with a new source
+// seeded with opts.Seed, so the same column gets the same values.
+func (tg TestDataGenerator) exampleJSON(g *valueGenerator, colName string, dataType arrow.DataType, opts GenTestDataOptions) string {
+	var rnd *rand.Rand
+	if g != nil {
+		rnd = g.rnd
+	} else {
+		rnd = rand.New(rand.NewSource(uint64(opts.Seed)))
+	}
 
 	// special case for auto-incrementing id column, used for to determine ordering in tests
 	if arrow.IsInteger(dataType.ID()) && colName == "id" {
 		return `` + strconv.Itoa(tg.counter) + ``
 	}
 
+	// the internal columns are left alone, e.g. _cq_source_name
+	if g != nil && g.edgeCaseRatio > 0 && !strings.HasPrefix(colName, "_cq_") && rnd.Float64() < g.edgeCaseRatio {
+		if v, ok := edgeCaseJSON(rnd, dataType); ok {
+			return v
+		}
+	}
+
 	// handle lists (including maps)
 	if arrow.IsListLike(dataType.ID()) {
 		if dataType.ID() == arrow.MAP {
-			k := tg.getExampleJSON(colName, dataType.(*arrow.MapType).KeyType(), opts)
-			v := tg.getExampleJSON(colName, dataType.(*arrow.MapType).ItemType(), opts)
+			keyType, itemType := dataType.(*arrow.MapType).KeyType(), dataType.(*arrow.MapType).ItemType()
+			k := tg.exampleJSON(g.withoutEdgeCases(), colName, keyType, opts)
+			v := tg.exampleJSON(g, colName, itemType, opts)
 			opts.Seed++
-			k2 := tg.getExampleJSON(colName, dataType.(*arrow.MapType).KeyType(), opts)
-			v2 := tg.getExampleJSON(colName, dataType.(*arrow.MapType).ItemType(), opts)
+			k2 := tg.exampleJSON(g.withoutEdgeCases(), colName, keyType, opts)
+			// keys must be unique
+			for i := 0; g != nil && k2 == k && i < 10; i++ {
+				k2 = tg.exampleJSON(g.withoutEdgeCases(), colName, keyType, opts)
+			}
+			if k2 == k {
+				return fmt.Sprintf(`[{"key": %s,"value": %s}]`, k, v)
+			}
+			v2 := tg.exampleJSON(g, colName, itemType, opts)
 			return fmt.Sprintf(`[{"key": %s,"value": %s},{"key": %s,"value": %s}]`, k, v, k2, v2)
 		}
 		inner := dataType.(*arrow.ListType).Elem()
-		return `[` + tg.getExampleJSON(colName, inner, opts) + `,null,` + tg.getExampleJSON(colName, inner, opts) + `]`
+		return `[` + tg.exampleJSON(g, colName, inner, opts) + `,null,` + tg.exampleJSON(g, colName, inner, opts) + `]`
+	}
+	// handle plugin-defined extension types
+	if ext, ok := scalar.ExtensionFor(dataType); ok {
+		if ext.Example != nil {
+			return ext.Example(rnd)
+		}
+		return tg.exampleJSON(g, colName, ext.Type.StorageType(), opts)
 	}
 	// handle extension types
 	if arrow.TypeEqual(dataType, types.ExtensionTypes.UUID) {
 		u := uuid.New()
-		if opts.StableUUID != uuid.Nil {
+		switch {
+		case opts.StableUUID != uuid.Nil:
 			u = opts.StableUUID
+		case g != nil:
+			u = g.uuid()
 		}
 		return `"` + u.String() + `"`
 	}
@@ -369,7 +579,7 @@ func (tg TestDataGenerator) getExampleJSON(colName string, dataType arrow.DataTy
 	if dataType.ID() == arrow.STRUCT {
 		var columns []string
 		for _, field := range dataType.(*arrow.StructType).Fields() {
-			v := tg.getExampleJSON(field.Name, field.Type, opts)
+			v := tg.exampleJSON(g, field.Name, field.Type, opts)
 			columns = append(columns, fmt.Sprintf(`"%s": %v`, field.Name, v))
 		}
 		return `{` + strings.Join(columns, ",") + `}`
@@ -389,10 +599,13 @@ func (tg TestDataGenerator) getExampleJSON(colName string, dataType arrow.DataTy
 	for _, timestampType := range timestampTypes {
 		if arrow.TypeEqual(dataType, timestampType) {
 			t := time.Now()
-			if colName == CqSyncTimeColumn.Name {
+			switch {
+			case colName == CqSyncTimeColumn.Name:
 				t = opts.SyncTime.UTC()
-			} else if !opts.StableTime.IsZero() {
+			case !opts.StableTime.IsZero():
 				t = opts.StableTime
+			case g != nil:
+				t = g.time()
 			}
 			t = t.Truncate(opts.TimePrecision)
 
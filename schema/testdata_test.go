@@ -1,6 +1,17 @@
 package schema
 
-import "testing"
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+	"golang.org/x/exp/rand"
+)
 
 func TestTestSourceColumns_Default(t *testing.T) {
 	// basic sanity check for tested columns
@@ -9,7 +20,7 @@ func TestTestSourceColumns_Default(t *testing.T) {
 		t.Fatalf("expected at least 73 columns by default got: %d ", len(table.Columns))
 	}
 	// test some specific columns
-	checkColumnsExist(t, table.Columns, []string{"int64", "date32", "timestamp_us", "string", "struct", "string_list"})
+	checkColumnsExist(t, table.Columns, []string{"int64", "date32", "timestamp_us", "string", "struct", "string_list", "string_int64_map", "int_string_map"})
 }
 
 func TestTestSourceColumns_SkipAll(t *testing.T) {
@@ -27,7 +38,7 @@ func TestTestSourceColumns_SkipAll(t *testing.T) {
 
 	// test some specific columns
 	checkColumnsExist(t, table.Columns, []string{"int64", "string"})
-	checkColumnsDontExist(t, table.Columns, []string{"date32", "struct", "string_map"})
+	checkColumnsDontExist(t, table.Columns, []string{"date32", "struct", "string_int64_map", "int_string_map"})
 }
 
 func checkColumnsExist(t *testing.T, list ColumnList, cols []string) {
@@ -52,3 +63,228 @@ func TestGenTestData(*testing.T) {
 	tg := NewTestDataGenerator()
 	_ = tg.Generate(table, GenTestDataOptions{})
 }
+
+func TestGenTestDataMaps(t *testing.T) {
+	table := TestTable("test", TestSourceOptions{SkipStructs: true, SkipLists: true})
+	tg := NewTestDataGenerator()
+	record := tg.Generate(table, GenTestDataOptions{MaxRows: 1})[0]
+	defer record.Release()
+
+	for i, c := range table.Columns {
+		if c.Type.ID() != arrow.MAP {
+			continue
+		}
+		// binary values are base64 encoded in the JSON representation, so they don't round-trip through strings
+		if id := c.Type.(*arrow.MapType).ItemType().ID(); id == arrow.BINARY || id == arrow.LARGE_BINARY {
+			continue
+		}
+		arr := record.Column(i)
+		if arr.Len() != 1 || arr.IsNull(0) {
+			t.Fatalf("expected a non-null value for column %s", c.Name)
+		}
+		// maps have to round-trip through scalars, as that is how resolved resources are converted to records
+		s := scalar.NewScalar(c.Type)
+		if err := s.Set(arr.ValueStr(0)); err != nil {
+			t.Fatalf("failed to set column %s: %v", c.Name, err)
+		}
+		bldr := array.NewBuilder(memory.DefaultAllocator, c.Type)
+		scalar.AppendToBuilder(bldr, s)
+		got := bldr.NewArray()
+		bldr.Release()
+		if !array.Equal(arr, got) {
+			t.Errorf("column %s does not round-trip. got %v, want %v", c.Name, got, arr)
+		}
+		got.Release()
+	}
+}
+
+type semverArray struct {
+	array.ExtensionArrayBase
+}
+
+type semverType struct {
+	arrow.ExtensionBase
+}
+
+func (*semverType) ArrayType() reflect.Type { return reflect.TypeOf(semverArray{}) }
+
+func (*semverType) ExtensionName() string { return "test.semver" }
+
+func (*semverType) Serialize() string { return "test.semver" }
+
+func (*semverType) Deserialize(storage arrow.DataType, _ string) (arrow.ExtensionType, error) {
+	return &semverType{ExtensionBase: arrow.ExtensionBase{Storage: storage}}, nil
+}
+
+func (e *semverType) ExtensionEquals(other arrow.ExtensionType) bool {
+	return e.ExtensionName() == other.ExtensionName()
+}
+
+// embedded via an alias, so the field name doesn't shadow the String method
+type stringScalar = scalar.String
+
+type semver struct {
+	stringScalar
+}
+
+func (*semver) DataType() arrow.DataType {
+	return &semverType{ExtensionBase: arrow.ExtensionBase{Storage: arrow.BinaryTypes.String}}
+}
+
+func TestGenTestDataExtensions(t *testing.T) {
+	dt := &semverType{ExtensionBase: arrow.ExtensionBase{Storage: arrow.BinaryTypes.String}}
+	for _, tc := range []struct {
+		name     string
+		example  func(rnd *rand.Rand) string
+		expected string
+	}{
+		{name: "example", example: func(*rand.Rand) string { return `"v1.2.3"` }, expected: "v1.2.3"},
+		{name: "storage_fallback", expected: "AString"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if err := scalar.RegisterExtension(scalar.Extension{
+				Type:    dt,
+				New:     func() scalar.Scalar { return &semver{} },
+				Example: tc.example,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				if err := scalar.UnregisterExtension(dt.ExtensionName()); err != nil {
+					t.Error(err)
+				}
+			})
+
+			table := &Table{Name: "test", Columns: ColumnList{{Name: "version", Type: dt}}}
+			record := NewTestDataGenerator().Generate(table, GenTestDataOptions{MaxRows: 1})[0]
+			defer record.Release()
+			storage := record.Column(0).(array.ExtensionArray).Storage()
+			if got := storage.ValueStr(0); !strings.HasPrefix(got, tc.expected) {
+				t.Fatalf("expected value starting with %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGenTestDataFromArrayRoundTrip(t *testing.T) {
+	table := TestTable("test", TestSourceOptions{})
+	tg := NewTestDataGenerator()
+	records := tg.Generate(table, GenTestDataOptions{MaxRows: 2})
+	records = append(records, tg.Generate(table, GenTestDataOptions{MaxRows: 1, NullRows: true})...)
+	sc := table.ToArrowSchema()
+	for _, record := range records {
+		bldr := array.NewRecordBuilder(memory.DefaultAllocator, sc)
+		it := scalar.NewRowIterator(record)
+		for it.Next() {
+			scalar.AppendToRecordBuilder(bldr, it.Vector())
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		got := bldr.NewRecord()
+		bldr.Release()
+		for i, c := range table.Columns {
+			if !array.Equal(record.Column(i), got.Column(i)) {
+				t.Errorf("column %s does not round-trip. got %v, want %v", c.Name, got.Column(i), record.Column(i))
+			}
+		}
+		got.Release()
+		record.Release()
+	}
+}
+
+func testGeneratorTables() Tables {
+	table := &Table{
+		Name:    "test_parent",
+		Columns: ColumnList{{Name: "name", Type: arrow.BinaryTypes.String, PrimaryKey: true}},
+		Relations: []*Table{
+			{Name: "test_child", Columns: ColumnList{{Name: "value", Type: arrow.PrimitiveTypes.Int64}}},
+		},
+	}
+	AddCqIDs(table)
+	return Tables{table}
+}
+
+func TestGenerateRecordDeterministic(t *testing.T) {
+	table := TestTable("test", TestSourceOptions{})
+	AddCqIDs(table)
+	opts := GenTestDataOptions{MaxRows: 5, Seed: 42, NullRatio: 0.3, EdgeCaseRatio: 0.3}
+	first := NewTestDataGenerator().GenerateRecord(table, opts)
+	defer first.Release()
+	second := NewTestDataGenerator().GenerateRecord(table, opts)
+	defer second.Release()
+	if first.NumRows() != 5 {
+		t.Fatalf("expected 5 rows, got %d", first.NumRows())
+	}
+	if !array.RecordEqual(first, second) {
+		t.Fatal("expected records generated with the same seed to be equal")
+	}
+
+	opts.Seed++
+	third := NewTestDataGenerator().GenerateRecord(table, opts)
+	defer third.Release()
+	if array.RecordEqual(first, third) {
+		t.Fatal("expected records generated with different seeds to differ")
+	}
+}
+
+func TestGenerateRecordNullsAndEdgeCases(t *testing.T) {
+	table := &Table{
+		Name: "test",
+		Columns: ColumnList{
+			{Name: "int64", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "string", Type: arrow.BinaryTypes.String},
+			{Name: "list", Type: arrow.ListOf(arrow.PrimitiveTypes.Int64)},
+			{Name: "not_null", Type: arrow.BinaryTypes.String, NotNull: true},
+		},
+	}
+	record := NewTestDataGenerator().GenerateRecord(table, GenTestDataOptions{
+		MaxRows:          20,
+		NullRatio:        1,
+		ColumnNullRatios: map[string]float64{"int64": 0, "list": 0},
+		EdgeCaseRatio:    1,
+	})
+	defer record.Release()
+	ints := record.Column(0).(*array.Int64)
+	for i := 0; i < ints.Len(); i++ {
+		if v := ints.Value(i); ints.IsNull(i) || (v != math.MinInt64 && v != math.MaxInt64 && v != 0) {
+			t.Fatalf("expected an edge case int64, got %v", ints.ValueStr(i))
+		}
+	}
+	if record.Column(1).NullN() != 20 {
+		t.Fatalf("expected all strings to be null, got %d nulls", record.Column(1).NullN())
+	}
+	lists := record.Column(2).(*array.List)
+	for i := 0; i < lists.Len(); i++ {
+		if start, end := lists.ValueOffsets(i); lists.IsNull(i) || start != end {
+			t.Fatalf("expected an empty list, got %v", lists.ValueStr(i))
+		}
+	}
+	if record.Column(3).NullN() != 0 {
+		t.Fatal("expected not null column to have no nulls")
+	}
+}
+
+func TestGenerateTables(t *testing.T) {
+	tables := testGeneratorTables()
+	records := NewTestDataGenerator().GenerateTables(tables, GenTestDataOptions{MaxRows: 3, Seed: 1})
+	if len(records) != 2 {
+		t.Fatalf("expected a record per table, got %d", len(records))
+	}
+	for _, record := range records {
+		defer record.Release()
+	}
+	if records[0].Column(1).NullN() != 3 {
+		t.Fatal("expected top level table to have no parent ids")
+	}
+	parentIDs := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		parentIDs[records[0].Column(0).ValueStr(i)] = true
+	}
+	for i := 0; i < 3; i++ {
+		if id := records[1].Column(1).ValueStr(i); !parentIDs[id] {
+			t.Fatalf("expected parent id %s to reference a generated parent", id)
+		}
+	}
+}
@@ -3,57 +3,350 @@ package schema
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/apache/arrow/go/v13/arrow"
 )
 
+// TableValidator validates a table. Validators are responsible for validating the table relations as well.
+// To report more than one violation, or to report warnings, return TableValidationErrors or *TableValidationError.
+// Any other error is reported as a single error-level violation.
 type TableValidator interface {
 	Validate(t *Table) error
 }
 
-type LengthTableValidator struct{}
+type ValidationSeverity int
 
 const (
-	maxTableName  = 63 // maximum allowed identifier length is 63 bytes https://www.postgresql.org/docs/13/limits.html
-	maxColumnName = 63
+	ValidationSeverityError ValidationSeverity = iota
+	ValidationSeverityWarning
 )
 
-var defaultValidators = []TableValidator{
-	LengthTableValidator{},
+func (s ValidationSeverity) String() string {
+	switch s {
+	case ValidationSeverityError:
+		return "error"
+	case ValidationSeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
 }
 
-func ValidateTable(t *Table) error {
-	for _, validator := range defaultValidators {
-		return validator.Validate(t)
+func (s ValidationSeverity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// TableValidationError is a single violation reported by a TableValidator
+type TableValidationError struct {
+	// Validator is the name the validator was registered with (set by Validators)
+	Validator string             `json:"validator"`
+	Severity  ValidationSeverity `json:"severity"`
+	Table     string             `json:"table"`
+	// Column is empty for table-level violations
+	Column string `json:"column,omitempty"`
+	Msg    string `json:"message"`
+}
+
+func (e *TableValidationError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("table %s: %s", e.Table, e.Msg)
+	}
+	return fmt.Sprintf("table %s, column %s: %s", e.Table, e.Column, e.Msg)
+}
+
+// TableValidationErrors is a list of violations, usually reported by Validators
+type TableValidationErrors []*TableValidationError
+
+func (errs TableValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Errors returns only the error-level violations
+func (errs TableValidationErrors) Errors() TableValidationErrors {
+	return errs.filter(ValidationSeverityError)
+}
+
+// Warnings returns only the warning-level violations
+func (errs TableValidationErrors) Warnings() TableValidationErrors {
+	return errs.filter(ValidationSeverityWarning)
+}
+
+// Err returns the error-level violations as an error, or nil if there are none
+func (errs TableValidationErrors) Err() error {
+	if e := errs.Errors(); len(e) > 0 {
+		return e
 	}
 	return nil
 }
 
-func validateTableAttributesNameLength(t *Table) error {
-	// validate table name
-	if len(t.Name) > maxTableName {
-		return errors.New("table name has exceeded max length")
+func (errs TableValidationErrors) filter(severity ValidationSeverity) TableValidationErrors {
+	var filtered TableValidationErrors
+	for _, e := range errs {
+		if e.Severity == severity {
+			filtered = append(filtered, e)
+		}
 	}
+	return filtered
+}
 
-	// validate table columns
-	for _, col := range t.Columns.Names() {
-		if len(col) > maxColumnName {
-			return fmt.Errorf("column name %s has exceeded max length", col)
+// Validators is a registry of named table validators.
+// Unlike a single TableValidator, it runs every registered validator and collects all violations.
+type Validators struct {
+	mu         sync.RWMutex
+	names      []string
+	validators map[string]TableValidator
+}
+
+func NewValidators() *Validators {
+	return &Validators{
+		validators: make(map[string]TableValidator),
+	}
+}
+
+// DefaultValidators returns a new registry with all the built-in validators registered
+func DefaultValidators() *Validators {
+	v := NewValidators()
+	v.Register("length", LengthTableValidator{})
+	v.Register("names", NameTableValidator{})
+	v.Register("duplicate_columns", DuplicateColumnsTableValidator{})
+	v.Register("primary_keys", PrimaryKeyTableValidator{})
+	v.Register("reserved_words", ReservedWordsTableValidator{Dialects: []SQLDialect{SQLDialectPostgreSQL}})
+	v.Register("relation_depth", RelationDepthTableValidator{MaxDepth: DefaultMaxRelationDepth})
+	return v
+}
+
+// Register adds a validator under the given name, replacing any validator previously registered with it
+func (v *Validators) Register(name string, validator TableValidator) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.validators[name]; !ok {
+		v.names = append(v.names, name)
+	}
+	v.validators[name] = validator
+}
+
+// Unregister removes the validator registered under the given name, if any
+func (v *Validators) Unregister(name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.validators[name]; !ok {
+		return
+	}
+	delete(v.validators, name)
+	for i, n := range v.names {
+		if n == name {
+			v.names = append(v.names[:i], v.names[i+1:]...)
+			break
 		}
 	}
+}
 
-	// validate table relations
-	for _, rel := range t.Relations {
-		err := validateTableAttributesNameLength(rel)
-		if err != nil {
-			return err
+// Names returns the names of the registered validators in registration order
+func (v *Validators) Names() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return append([]string(nil), v.names...)
+}
+
+// Validate runs all registered validators against the table and returns all the violations found
+func (v *Validators) Validate(t *Table) TableValidationErrors {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	var errs TableValidationErrors
+	for _, name := range v.names {
+		errs = append(errs, collectValidationErrors(name, t, v.validators[name].Validate(t))...)
+	}
+	return errs
+}
+
+// ValidateTables runs all registered validators against every table and additionally checks
+// that table names are unique across all tables and relations.
+func (v *Validators) ValidateTables(tables Tables) TableValidationErrors {
+	var errs TableValidationErrors
+	seen := make(map[string]bool)
+	for _, t := range tables {
+		walkTables(t, 1, func(t *Table, _ int) {
+			if seen[t.Name] {
+				errs = append(errs, &TableValidationError{Validator: "duplicate_tables", Table: t.Name, Msg: "duplicate table"})
+			}
+			seen[t.Name] = true
+		})
+	}
+	for _, t := range tables {
+		errs = append(errs, v.Validate(t)...)
+	}
+	return errs
+}
+
+func collectValidationErrors(validator string, t *Table, err error) TableValidationErrors {
+	if err == nil {
+		return nil
+	}
+	var errs TableValidationErrors
+	var single *TableValidationError
+	switch {
+	case errors.As(err, &errs):
+	case errors.As(err, &single):
+		errs = TableValidationErrors{single}
+	default:
+		errs = TableValidationErrors{{Table: t.Name, Msg: err.Error()}}
+	}
+	for _, e := range errs {
+		if e.Validator == "" {
+			e.Validator = validator
 		}
 	}
-	return nil
+	return errs
+}
+
+const (
+	maxTableName  = 63 // maximum allowed identifier length is 63 bytes https://www.postgresql.org/docs/13/limits.html
+	maxColumnName = 63
+
+	// DefaultMaxRelationDepth is the default maximum relation depth, which is also the default max depth of the scheduler
+	DefaultMaxRelationDepth = 4
+)
+
+// ValidateTable checks that the names of the table, its columns and relations fit in the maximum identifier length.
+// It doesn't run the other built-in validators, so tables that passed it keep passing; use DefaultValidators for those.
+func ValidateTable(t *Table) error {
+	return collectValidationErrors("length", t, LengthTableValidator{}.Validate(t)).Err()
+}
+
+// walkTables calls fn for the table and all of its relations, with depth 1 for the table itself
+func walkTables(t *Table, depth int, fn func(t *Table, depth int)) {
+	fn(t, depth)
+	for _, rel := range t.Relations {
+		walkTables(rel, depth+1, fn)
+	}
 }
 
+// LengthTableValidator checks that table and column names fit in the maximum identifier length
+type LengthTableValidator struct{}
+
 func (LengthTableValidator) Validate(t *Table) error {
-	return validateTableAttributesNameLength(t)
+	var errs TableValidationErrors
+	walkTables(t, 1, func(t *Table, _ int) {
+		if len(t.Name) > maxTableName {
+			errs = append(errs, &TableValidationError{Table: t.Name, Msg: "table name has exceeded max length"})
+		}
+		for _, col := range t.Columns.Names() {
+			if len(col) > maxColumnName {
+				errs = append(errs, &TableValidationError{Table: t.Name, Column: col, Msg: fmt.Sprintf("column name %s has exceeded max length", col)})
+			}
+		}
+	})
+	return validationErrorsOrNil(errs)
+}
+
+// NameTableValidator checks that table and column names contain only lower-case letters, numbers and underscores
+type NameTableValidator struct{}
+
+func (NameTableValidator) Validate(t *Table) error {
+	var errs TableValidationErrors
+	walkTables(t, 1, func(t *Table, _ int) {
+		if err := t.ValidateName(); err != nil {
+			errs = append(errs, &TableValidationError{Table: t.Name, Msg: err.Error()})
+		}
+		for _, c := range t.Columns {
+			if !ValidColumnName(c.Name) {
+				errs = append(errs, &TableValidationError{Table: t.Name, Column: c.Name, Msg: "column names must contain only lower-case letters, numbers and underscores, and must start with a lower-case letter or underscore"})
+			}
+		}
+	})
+	return validationErrorsOrNil(errs)
+}
+
+// DuplicateColumnsTableValidator checks that column names are unique within each table
+type DuplicateColumnsTableValidator struct{}
+
+func (DuplicateColumnsTableValidator) Validate(t *Table) error {
+	var errs TableValidationErrors
+	walkTables(t, 1, func(t *Table, _ int) {
+		columns := make(map[string]bool, len(t.Columns))
+		for _, c := range t.Columns {
+			if columns[c.Name] {
+				errs = append(errs, &TableValidationError{Table: t.Name, Column: c.Name, Msg: "duplicate column"})
+			}
+			columns[c.Name] = true
+		}
+	})
+	return validationErrorsOrNil(errs)
+}
+
+// PrimaryKeyTableValidator warns about tables without primary keys.
+// Such tables can only be appended to, as destinations have no way to de-duplicate rows.
+type PrimaryKeyTableValidator struct{}
+
+func (PrimaryKeyTableValidator) Validate(t *Table) error {
+	var errs TableValidationErrors
+	walkTables(t, 1, func(t *Table, _ int) {
+		if len(t.PrimaryKeys()) == 0 {
+			errs = append(errs, &TableValidationError{Severity: ValidationSeverityWarning, Table: t.Name, Msg: "table has no primary keys"})
+		}
+	})
+	return validationErrorsOrNil(errs)
+}
+
+// ReservedWordsTableValidator warns about table and column names that are reserved words in any of the given SQL dialects.
+// Such names have to be quoted by destinations and are easy to get wrong in queries.
+type ReservedWordsTableValidator struct {
+	Dialects []SQLDialect
+}
+
+func (v ReservedWordsTableValidator) Validate(t *Table) error {
+	var errs TableValidationErrors
+	check := func(table, column, name string) {
+		var dialects []string
+		for _, d := range v.Dialects {
+			if d.IsReserved(name) {
+				dialects = append(dialects, d.String())
+			}
+		}
+		if len(dialects) > 0 {
+			errs = append(errs, &TableValidationError{
+				Severity: ValidationSeverityWarning,
+				Table:    table,
+				Column:   column,
+				Msg:      fmt.Sprintf("%q is a reserved word in %s", name, strings.Join(dialects, ", ")),
+			})
+		}
+	}
+	walkTables(t, 1, func(t *Table, _ int) {
+		check(t.Name, "", t.Name)
+		for _, c := range t.Columns {
+			check(t.Name, c.Name, c.Name)
+		}
+	})
+	return validationErrorsOrNil(errs)
+}
+
+// RelationDepthTableValidator checks that the relations are not nested deeper than the scheduler supports
+type RelationDepthTableValidator struct {
+	MaxDepth int
+}
+
+func (v RelationDepthTableValidator) Validate(t *Table) error {
+	var errs TableValidationErrors
+	walkTables(t, 1, func(rel *Table, depth int) {
+		if depth == v.MaxDepth+1 {
+			errs = append(errs, &TableValidationError{Table: rel.Name, Msg: fmt.Sprintf("relation depth %d of table %s exceeds max depth %d", depth, t.Name, v.MaxDepth)})
+		}
+	})
+	return validationErrorsOrNil(errs)
+}
+
+func validationErrorsOrNil(errs TableValidationErrors) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
 }
 
 func FindEmptyColumns(table *Table, records []arrow.Record) []string {
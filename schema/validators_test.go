@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/apache/arrow/go/v13/arrow"
@@ -42,3 +44,100 @@ func TestTableValidators(t *testing.T) {
 	err = ValidateTable(&tableWithLongColumnName)
 	assert.Error(t, err)
 }
+
+func TestValidateTableOnlyChecksLength(t *testing.T) {
+	// violations of the other built-in validators don't fail ValidateTable
+	table := &Table{
+		Name: "Test_Table",
+		Columns: ColumnList{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		},
+	}
+	parent := table
+	for i := 0; i < DefaultMaxRelationDepth; i++ {
+		rel := &Table{Name: fmt.Sprintf("test_relation_%d", i)}
+		parent.Relations = Tables{rel}
+		parent = rel
+	}
+	assert.NoError(t, ValidateTable(table))
+	assert.Error(t, DefaultValidators().Validate(table).Err())
+}
+
+func TestValidatorsReportAllViolations(t *testing.T) {
+	table := &Table{
+		Name: "test_table",
+		Columns: ColumnList{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "User", Type: arrow.BinaryTypes.String},
+		},
+		Relations: Tables{
+			{Name: "test_table_child", Columns: ColumnList{{Name: "id", Type: arrow.PrimitiveTypes.Int64, PrimaryKey: true}}},
+		},
+	}
+
+	errs := DefaultValidators().Validate(table)
+	assert.Len(t, errs.Errors(), 2)
+	assert.Equal(t, "duplicate_columns", errs.Errors()[1].Validator)
+	assert.Equal(t, "names", errs.Errors()[0].Validator)
+	assert.Equal(t, "User", errs.Errors()[0].Column)
+	// no primary keys on test_table, "user" is a reserved word in postgresql
+	assert.Len(t, errs.Warnings(), 2)
+	assert.Error(t, errs.Err())
+}
+
+func TestValidatorsRelationDepth(t *testing.T) {
+	table := &Table{Name: "level_1", Relations: Tables{
+		{Name: "level_2", Relations: Tables{
+			{Name: "level_3"},
+		}},
+	}}
+	v := NewValidators()
+	v.Register("relation_depth", RelationDepthTableValidator{MaxDepth: 2})
+	errs := v.Validate(table)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "level_3", errs[0].Table)
+
+	v.Register("relation_depth", RelationDepthTableValidator{MaxDepth: 3})
+	assert.Empty(t, v.Validate(table))
+}
+
+func TestValidatorsDuplicateTables(t *testing.T) {
+	tables := Tables{
+		{Name: "table_a", Relations: Tables{{Name: "table_b"}}},
+		{Name: "table_b"},
+	}
+	errs := NewValidators().ValidateTables(tables)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "table_b", errs[0].Table)
+	assert.Equal(t, "duplicate_tables", errs[0].Validator)
+}
+
+type testCustomValidator struct{}
+
+func (testCustomValidator) Validate(*Table) error {
+	return errors.New("custom failure")
+}
+
+func TestValidatorsCustom(t *testing.T) {
+	v := NewValidators()
+	v.Register("custom", testCustomValidator{})
+	errs := v.Validate(&Table{Name: "test_table"})
+	assert.Equal(t, TableValidationErrors{{Validator: "custom", Table: "test_table", Msg: "custom failure"}}, errs)
+
+	v.Unregister("custom")
+	assert.Empty(t, v.Names())
+	assert.Empty(t, v.Validate(&Table{Name: "test_table"}))
+}
+
+func TestSQLDialectIsReserved(t *testing.T) {
+	assert.True(t, SQLDialectPostgreSQL.IsReserved("USER"))
+	assert.False(t, SQLDialectPostgreSQL.IsReserved("key"))
+	assert.True(t, SQLDialectMySQL.IsReserved("key"))
+	d, err := SQLDialectFromString("SQLite")
+	assert.NoError(t, err)
+	assert.Equal(t, SQLDialectSQLite, d)
+	_, err = SQLDialectFromString("oracle")
+	assert.Error(t, err)
+}
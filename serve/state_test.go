@@ -2,8 +2,11 @@ package serve
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	pb "github.com/cloudquery/plugin-pb-go/pb/plugin/v3"
 	"github.com/cloudquery/plugin-sdk/v4/internal/clients/state/v3"
@@ -75,6 +78,302 @@ func TestState(t *testing.T) {
 		t.Fatalf("expected value to be value but got %s", val)
 	}
 
+	// deleted keys are flushed, and listed keys are sorted
+	for _, key := range []string{"b", "a", "c"} {
+		if err := stateClient.SetKey(ctx, "prefix:"+key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stateClient.DeleteKey(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stateClient.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stateClient, err = state.NewClient(ctx, c, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := stateClient.GetKey(ctx, "key"); val != "" {
+		t.Fatalf("expected deleted key to be empty but got %s", val)
+	}
+	keys, err := stateClient.ListKeys(ctx, "prefix:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "prefix:a,prefix:b,prefix:c" {
+		t.Fatalf("expected sorted prefixed keys but got %v", keys)
+	}
+
+	// the client flushes when its context is done
+	clientCtx, clientCancel := context.WithCancel(ctx)
+	stateClient, err = state.NewClient(clientCtx, c, "test", state.WithFlushInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stateClient.SetKey(ctx, "key", "flushed on cancel"); err != nil {
+		t.Fatal(err)
+	}
+	clientCancel()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		stateClient, err = state.NewClient(ctx, c, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val, _ := stateClient.GetKey(ctx, "key"); val == "flushed on cancel" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected key to be flushed when the client context is done")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	wg.Wait()
+	if serverErr != nil {
+		t.Fatal(serverErr)
+	}
+}
+
+func TestStateConflicts(t *testing.T) {
+	p := plugin.NewPlugin(
+		"testPluginV3",
+		"v1.0.0",
+		memdb.NewMemDBClient)
+	srv := Plugin(p, WithArgs("serve"), WithTestListener())
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var serverErr error
+	go func() {
+		defer wg.Done()
+		serverErr = srv.Serve(ctx)
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(srv.bufPluginDialer), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	c := pb.NewPluginClient(conn)
+	if _, err := c.Init(ctx, &pb.Init_Request{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// two shards sharing the state table
+	first, err := state.NewClient(ctx, c, "test", state.WithFlushInterval(0), state.WithConflictCheck(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := state.NewClient(ctx, c, "test", state.WithFlushInterval(0), state.WithConflictCheck(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.SetKey(ctx, "shared", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second client read the key before the first client changed it
+	if err := second.SetKey(ctx, "shared", "second"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.SetKey(ctx, "second", "value"); err != nil {
+		t.Fatal(err)
+	}
+	err = second.Flush(ctx)
+	var conflictErr *state.ConflictError
+	if !errors.As(err, &conflictErr) || strings.Join(conflictErr.Keys, ",") != "shared" {
+		t.Fatalf("expected a conflict on the shared key but got %v", err)
+	}
+	if val, _ := second.GetKey(ctx, "shared"); val != "first" {
+		t.Fatalf("expected conflicting key to be reloaded but got %s", val)
+	}
+
+	// setting the key again after the conflict succeeds
+	if err := second.SetKey(ctx, "shared", "second"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := state.NewClient(ctx, c, "test", state.WithFlushInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, expected := range map[string]string{"shared": "second", "second": "value"} {
+		if val, _ := reader.GetKey(ctx, key); val != expected {
+			t.Fatalf("expected %s to be %s but got %s", key, expected, val)
+		}
+	}
+
+	// now the first client is behind
+	if err := first.SetKey(ctx, "shared", "first again"); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Flush(ctx); !errors.As(err, &conflictErr) {
+		t.Fatalf("expected a conflict but got %v", err)
+	}
+
+	// a flush racing between the write and the read back of a flush
+	raced := &racingClient{PluginClient: c}
+	checked, err := state.NewClient(ctx, raced, "test", state.WithFlushInterval(0), state.WithConflictCheck(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	racer, err := state.NewClient(ctx, c, "test", state.WithFlushInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checked.SetKey(ctx, "raced", "checked"); err != nil {
+		t.Fatal(err)
+	}
+	if err := racer.SetKey(ctx, "raced", "racer"); err != nil {
+		t.Fatal(err)
+	}
+	raced.afterWrite = func() {
+		raced.afterWrite = nil
+		if err := racer.Flush(ctx); err != nil {
+			t.Error(err)
+		}
+	}
+	err = checked.Flush(ctx)
+	if !errors.As(err, &conflictErr) || strings.Join(conflictErr.Keys, ",") != "raced" {
+		t.Fatalf("expected a conflict on the raced key but got %v", err)
+	}
+	if val, _ := checked.GetKey(ctx, "raced"); val != "racer" {
+		t.Fatalf("expected overwritten key to be reloaded but got %s", val)
+	}
+
+	// without the conflict check, flushes don't read the state table
+	counted := &racingClient{PluginClient: c}
+	unchecked, err := state.NewClient(ctx, counted, "test", state.WithFlushInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unchecked.SetKey(ctx, "unchecked", "value"); err != nil {
+		t.Fatal(err)
+	}
+	reads := counted.reads
+	if err := unchecked.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if counted.reads != reads {
+		t.Fatalf("expected flush not to read the state table, got %d reads", counted.reads-reads)
+	}
+
+	cancel()
+	wg.Wait()
+	if serverErr != nil {
+		t.Fatal(serverErr)
+	}
+}
+
+// racingClient calls afterWrite after every write, e.g. to flush another client between the write and the read back of a flush,
+// and counts the reads
+type racingClient struct {
+	pb.PluginClient
+	afterWrite func()
+	reads      int
+}
+
+func (c *racingClient) Read(ctx context.Context, in *pb.Read_Request, opts ...grpc.CallOption) (pb.Plugin_ReadClient, error) {
+	c.reads++
+	return c.PluginClient.Read(ctx, in, opts...)
+}
+
+func (c *racingClient) Write(ctx context.Context, opts ...grpc.CallOption) (pb.Plugin_WriteClient, error) {
+	w, err := c.PluginClient.Write(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &racingWriteClient{Plugin_WriteClient: w, client: c}, nil
+}
+
+type racingWriteClient struct {
+	pb.Plugin_WriteClient
+	client *racingClient
+}
+
+func (w *racingWriteClient) CloseAndRecv() (*pb.Write_Response, error) {
+	res, err := w.Plugin_WriteClient.CloseAndRecv()
+	if w.client.afterWrite != nil {
+		w.client.afterWrite()
+	}
+	return res, err
+}
+
+func TestStateClose(t *testing.T) {
+	p := plugin.NewPlugin(
+		"testPluginV3",
+		"v1.0.0",
+		memdb.NewMemDBClient)
+	srv := Plugin(p, WithArgs("serve"), WithTestListener())
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var serverErr error
+	go func() {
+		defer wg.Done()
+		serverErr = srv.Serve(ctx)
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(srv.bufPluginDialer), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	c := pb.NewPluginClient(conn)
+	if _, err := c.Init(ctx, &pb.Init_Request{}); err != nil {
+		t.Fatal(err)
+	}
+
+	stateClient, err := state.NewClient(ctx, c, "test", state.WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stateClient.SetKey(ctx, "key", "flushed on close"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stateClient.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := stateClient.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := state.NewClient(ctx, c, "test", state.WithFlushInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := reader.GetKey(ctx, "key"); val != "flushed on close" {
+		t.Fatalf("expected key to be flushed on close but got %s", val)
+	}
+
+	// the error of the last flush when the client context is done is returned by close
+	clientCtx, clientCancel := context.WithCancel(ctx)
+	stateClient, err = state.NewClient(clientCtx, c, "test", state.WithFlushInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stateClient.SetKey(ctx, "key", "not flushed"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	clientCancel()
+	if err := stateClient.Close(ctx); err == nil {
+		t.Fatal("expected the failed flush to be returned by close")
+	}
+
 	cancel()
 	wg.Wait()
 	if serverErr != nil {
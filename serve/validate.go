@@ -0,0 +1,95 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudquery/plugin-sdk/v4/plugin"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/spf13/cobra"
+)
+
+const (
+	pluginValidateShort = "Validate the plugin tables"
+	pluginValidateLong  = `Validate the plugin tables
+
+Runs all the built-in table validators (names, lengths, duplicate columns, primary keys,
+reserved words and relation depth) and reports every violation found.
+Exits with an error if any error-level violation is found, or on warnings too if --strict is set.
+Example:
+validate --reserved-words-dialect postgresql,mysql --max-depth 4
+`
+)
+
+type validationReport struct {
+	Errors   schema.TableValidationErrors `json:"errors"`
+	Warnings schema.TableValidationErrors `json:"warnings"`
+}
+
+func (s *PluginServe) newCmdPluginValidate() *cobra.Command {
+	format := newEnum([]string{"text", "json"}, "text")
+	var dialects []string
+	var maxDepth int
+	var strict bool
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: pluginValidateShort,
+		Long:  pluginValidateLong,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sqlDialects := make([]schema.SQLDialect, len(dialects))
+			for i, d := range dialects {
+				dialect, err := schema.SQLDialectFromString(d)
+				if err != nil {
+					return err
+				}
+				sqlDialects[i] = dialect
+			}
+			if err := s.plugin.Init(cmd.Context(), nil, plugin.NewClientOptions{
+				NoConnection: true,
+			}); err != nil {
+				return err
+			}
+			tables, err := s.plugin.Tables(cmd.Context(), plugin.TableOptions{
+				Tables: []string{"*"},
+			})
+			if err != nil {
+				return err
+			}
+
+			validators := schema.DefaultValidators()
+			validators.Register("reserved_words", schema.ReservedWordsTableValidator{Dialects: sqlDialects})
+			validators.Register("relation_depth", schema.RelationDepthTableValidator{MaxDepth: maxDepth})
+			errs := validators.ValidateTables(tables)
+			report := validationReport{Errors: errs.Errors(), Warnings: errs.Warnings()}
+
+			out := cmd.OutOrStdout()
+			if format.Value == "json" {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				for _, e := range errs {
+					fmt.Fprintf(out, "%s [%s]: %s\n", e.Severity, e.Validator, e.Error())
+				}
+				fmt.Fprintf(out, "%d tables validated: %d errors, %d warnings\n", len(tables.FlattenTables()), len(report.Errors), len(report.Warnings))
+			}
+
+			if len(report.Errors) > 0 {
+				return fmt.Errorf("validation failed with %d errors", len(report.Errors))
+			}
+			if strict && len(report.Warnings) > 0 {
+				return fmt.Errorf("validation failed with %d warnings", len(report.Warnings))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Var(format, "format", fmt.Sprintf("output format. one of: %s", strings.Join(format.Allowed, ",")))
+	cmd.Flags().StringSliceVar(&dialects, "reserved-words-dialect", []string{schema.SQLDialectPostgreSQL.String()}, fmt.Sprintf("SQL dialects to check reserved words against. any of: %s", strings.Join(schema.SQLDialects(), ",")))
+	cmd.Flags().IntVar(&maxDepth, "max-depth", schema.DefaultMaxRelationDepth, "maximum relation depth supported by the scheduler")
+	cmd.Flags().BoolVar(&strict, "strict", false, "fail on warnings as well as errors")
+	return cmd
+}
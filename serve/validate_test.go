@@ -0,0 +1,78 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/internal/memdb"
+	"github.com/cloudquery/plugin-sdk/v4/message"
+	"github.com/cloudquery/plugin-sdk/v4/plugin"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/rs/zerolog"
+)
+
+type testValidateSourceClient struct {
+	tables schema.Tables
+}
+
+func (*testValidateSourceClient) Close(context.Context) error { return nil }
+
+func (c *testValidateSourceClient) Tables(context.Context, plugin.TableOptions) (schema.Tables, error) {
+	return c.tables, nil
+}
+
+func (*testValidateSourceClient) Sync(context.Context, plugin.SyncOptions, chan<- message.SyncMessage) error {
+	return nil
+}
+
+func TestPluginValidate(t *testing.T) {
+	p := plugin.NewPlugin(
+		"testPlugin",
+		"v1.0.0",
+		memdb.NewMemDBClient)
+	srv := Plugin(p)
+	cmd := srv.newCmdPluginRoot()
+	cmd.SetArgs([]string{"validate"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPluginValidateReportsViolations(t *testing.T) {
+	tables := schema.Tables{
+		{
+			Name: "test_table",
+			Columns: schema.ColumnList{
+				{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+				{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+				{Name: "key", Type: arrow.BinaryTypes.String},
+			},
+		},
+	}
+	p := plugin.NewSourcePlugin("testPlugin", "v1.0.0", func(context.Context, zerolog.Logger, any) (plugin.SourceClient, error) {
+		return &testValidateSourceClient{tables: tables}, nil
+	})
+	srv := Plugin(p)
+	cmd := srv.newCmdPluginRoot()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"validate", "--reserved-words-dialect", "mysql"})
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	for _, want := range []string{
+		"error [duplicate_columns]: table test_table, column id: duplicate column",
+		"warning [primary_keys]: table test_table: table has no primary keys",
+		`warning [reserved_words]: table test_table, column key: "key" is a reserved word in mysql`,
+		"1 tables validated: 1 errors, 2 warnings",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
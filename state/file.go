@@ -0,0 +1,228 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/ipc"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+)
+
+// FileConnectionPrefix is the prefix of backend connections selecting a local state file, e.g. "file:///tmp/state.json".
+const FileConnectionPrefix = "file://"
+
+var fileSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "table", Type: arrow.BinaryTypes.String},
+	{Name: "key", Type: arrow.BinaryTypes.String},
+	{Name: "value", Type: arrow.BinaryTypes.String},
+}, nil)
+
+// FileClient is a state client backed by a local file, for developing and testing plugins without a state destination.
+// Files with the .arrow or .ipc extension are written in the Arrow IPC file format, and other files as JSON objects
+// mapping table names to their keys and values. A file can hold the state of multiple tables.
+// Flush replaces the file atomically, so an interrupted write doesn't corrupt it.
+// Clients in the same process can share a file, but it shouldn't be shared by concurrent processes.
+type FileClient struct {
+	path      string
+	tableName string
+	mutex     sync.RWMutex
+	mem       map[string]string
+}
+
+// NewFileClient returns a client for the state of the table in the file at path, loading the existing state if the file exists.
+func NewFileClient(path string, tableName string) (*FileClient, error) {
+	c := &FileClient{
+		path:      path,
+		tableName: tableName,
+		mem:       make(map[string]string),
+	}
+	tables, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range tables[tableName] {
+		if v != "" {
+			c.mem[k] = v
+		}
+	}
+	return c, nil
+}
+
+func (c *FileClient) SetKey(_ context.Context, key string, value string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if value == "" {
+		delete(c.mem, key)
+		return nil
+	}
+	c.mem[key] = value
+	return nil
+}
+
+func (c *FileClient) GetKey(_ context.Context, key string) (string, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.mem[key], nil
+}
+
+func (c *FileClient) DeleteKey(_ context.Context, key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.mem, key)
+	return nil
+}
+
+func (c *FileClient) ListKeys(_ context.Context, prefix string) ([]string, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return listKeys(c.mem, prefix), nil
+}
+
+// fileLocks serializes the flushes of the clients sharing a file, by absolute path
+var fileLocks sync.Map
+
+// Flush writes the state of the table to the file, keeping the state of the other tables in it.
+func (c *FileClient) Flush(_ context.Context) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	path, err := filepath.Abs(c.path)
+	if err != nil {
+		return err
+	}
+	lock, _ := fileLocks.LoadOrStore(path, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	tables, err := c.read()
+	if err != nil {
+		return err
+	}
+	table := make(map[string]string, len(c.mem))
+	for k, v := range c.mem {
+		table[k] = v
+	}
+	tables[c.tableName] = table
+	return c.write(tables)
+}
+
+func (c *FileClient) isArrow() bool {
+	switch strings.ToLower(filepath.Ext(c.path)) {
+	case ".arrow", ".ipc":
+		return true
+	default:
+		return false
+	}
+}
+
+// read returns the keys and values of all the tables in the file, or an empty map if it doesn't exist
+func (c *FileClient) read() (map[string]map[string]string, error) {
+	tables := make(map[string]map[string]string)
+	f, err := os.Open(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tables, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if c.isArrow() {
+		err = readArrowState(f, tables)
+	} else {
+		err = json.NewDecoder(f).Decode(&tables)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", c.path, err)
+	}
+	return tables, nil
+}
+
+func readArrowState(f *os.File, tables map[string]map[string]string) error {
+	rdr, err := ipc.NewFileReader(f, ipc.WithSchema(fileSchema))
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+	for i := 0; i < rdr.NumRecords(); i++ {
+		record, err := rdr.Record(i)
+		if err != nil {
+			return err
+		}
+		tableNames := record.Column(0).(*array.String)
+		keys := record.Column(1).(*array.String)
+		values := record.Column(2).(*array.String)
+		for j := 0; j < int(record.NumRows()); j++ {
+			table := tables[tableNames.Value(j)]
+			if table == nil {
+				table = make(map[string]string)
+				tables[tableNames.Value(j)] = table
+			}
+			table[keys.Value(j)] = values.Value(j)
+		}
+	}
+	return nil
+}
+
+// write replaces the file with the tables, by writing a temporary file in the same directory and renaming it
+func (c *FileClient) write(tables map[string]map[string]string) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if c.isArrow() {
+		err = writeArrowState(tmp, tables)
+	} else {
+		enc := json.NewEncoder(tmp)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(tables)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func writeArrowState(w io.WriteSeeker, tables map[string]map[string]string) error {
+	bldr := array.NewRecordBuilder(memory.DefaultAllocator, fileSchema)
+	defer bldr.Release()
+	for table, keys := range tables {
+		for k, v := range keys {
+			bldr.Field(0).(*array.StringBuilder).Append(table)
+			bldr.Field(1).(*array.StringBuilder).Append(k)
+			bldr.Field(2).(*array.StringBuilder).Append(v)
+		}
+	}
+	record := bldr.NewRecord()
+	defer record.Release()
+	wr, err := ipc.NewFileWriter(w, ipc.WithSchema(fileSchema))
+	if err != nil {
+		return err
+	}
+	if err := wr.Write(record); err != nil {
+		return err
+	}
+	return wr.Close()
+}
+
+// static check
+var _ Client = (*FileClient)(nil)
@@ -0,0 +1,76 @@
+package state
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileClient(t *testing.T) {
+	for _, name := range []string{"state.json", "state.arrow"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			path := filepath.Join(dir, name)
+
+			// a missing file is empty state
+			client, err := NewFileClient(path, "table_a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v, err := client.GetKey(ctx, "key"); err != nil || v != "" {
+				t.Fatalf("expected empty value, got %q (%v)", v, err)
+			}
+			if err := client.SetKey(ctx, "key", "a"); err != nil {
+				t.Fatal(err)
+			}
+			if err := client.Flush(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			// another table in the same file
+			other, err := NewFileClient(path, "table_b")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v, _ := other.GetKey(ctx, "key"); v != "" {
+				t.Fatalf("expected tables not to share keys, got %q", v)
+			}
+			if err := other.SetKey(ctx, "key", "b"); err != nil {
+				t.Fatal(err)
+			}
+			if err := other.Flush(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			for table, expected := range map[string]string{"table_a": "a", "table_b": "b"} {
+				client, err := NewFileClient(path, table)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if v, _ := client.GetKey(ctx, "key"); v != expected {
+					t.Fatalf("expected %s key to be %q, got %q", table, expected, v)
+				}
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected only the state file to be left, got %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestFileClientCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileClient(path, "table"); err == nil {
+		t.Fatal("expected an error reading a corrupt state file")
+	}
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GetJSON decodes the JSON value of the key into v, and reports whether the key is set.
+func GetJSON(ctx context.Context, c Client, key string, v any) (bool, error) {
+	value, err := c.GetKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return false, fmt.Errorf("failed to decode state key %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// SetJSON sets the key to the JSON encoding of v.
+func SetJSON(ctx context.Context, c Client, key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode state key %s: %w", key, err)
+	}
+	return c.SetKey(ctx, key, string(b))
+}
+
+// Namespace returns a client storing its keys in the client c, prefixed with the namespace parts,
+// e.g. Namespace(c, table.Name, client.ID()) for the state of a table and client.
+// Keys are listed without the prefix, and flushing flushes all the keys of c.
+func Namespace(c Client, parts ...string) Client {
+	return &namespacedClient{
+		client: c,
+		prefix: strings.Join(parts, ":") + ":",
+	}
+}
+
+type namespacedClient struct {
+	client Client
+	prefix string
+}
+
+func (c *namespacedClient) SetKey(ctx context.Context, key string, value string) error {
+	return c.client.SetKey(ctx, c.prefix+key, value)
+}
+
+func (c *namespacedClient) GetKey(ctx context.Context, key string) (string, error) {
+	return c.client.GetKey(ctx, c.prefix+key)
+}
+
+func (c *namespacedClient) DeleteKey(ctx context.Context, key string) error {
+	return c.client.DeleteKey(ctx, c.prefix+key)
+}
+
+func (c *namespacedClient) ListKeys(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := c.client.ListKeys(ctx, c.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, c.prefix)
+	}
+	return keys, nil
+}
+
+func (c *namespacedClient) Flush(ctx context.Context) error {
+	return c.client.Flush(ctx)
+}
+
+// listKeys returns the sorted keys of the map starting with the prefix
+func listKeys(mem map[string]string, prefix string) []string {
+	keys := make([]string, 0)
+	for k := range mem {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// static check
+var _ Client = (*namespacedClient)(nil)
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONAndNamespace(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewFileClient(filepath.Join(t.TempDir(), "state.json"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns := Namespace(client, "table", "client")
+
+	type cursor struct {
+		Page int `json:"page"`
+	}
+	var got cursor
+	if ok, err := GetJSON(ctx, ns, "cursor", &got); err != nil || ok {
+		t.Fatalf("expected unset key, got %v (%v)", ok, err)
+	}
+	if err := SetJSON(ctx, ns, "cursor", cursor{Page: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := GetJSON(ctx, ns, "cursor", &got); err != nil || !ok || got.Page != 2 {
+		t.Fatalf("expected page 2, got %v (%v, %v)", got, ok, err)
+	}
+	if v, _ := client.GetKey(ctx, "table:client:cursor"); v != `{"page":2}` {
+		t.Fatalf("expected namespaced key, got %q", v)
+	}
+
+	if err := ns.SetKey(ctx, "other", "value"); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ns.ListKeys(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "cursor,other" {
+		t.Fatalf("expected keys without namespace, got %v", keys)
+	}
+
+	if err := ns.DeleteKey(ctx, "cursor"); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := client.ListKeys(ctx, "table:"); strings.Join(keys, ",") != "table:client:other" {
+		t.Fatalf("expected deleted key not to be listed, got %v", keys)
+	}
+
+	if err := client.SetKey(ctx, "invalid", "{"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetJSON(ctx, client, "invalid", &got); err == nil {
+		t.Fatal("expected an error decoding invalid JSON")
+	}
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"fmt"
+	"time"
 
 	pbDiscovery "github.com/cloudquery/plugin-pb-go/pb/discovery/v1"
 	pbPluginV3 "github.com/cloudquery/plugin-pb-go/pb/plugin/v3"
@@ -11,20 +12,74 @@ import (
 	"google.golang.org/grpc"
 )
 
+// Client stores string values by key. Changes are kept in memory until they're flushed.
+// Getting a key that isn't set returns an empty value, so setting a key to an empty value is the same as deleting it.
 type Client interface {
 	SetKey(ctx context.Context, key string, value string) error
 	GetKey(ctx context.Context, key string) (string, error)
+	DeleteKey(ctx context.Context, key string) error
+	// ListKeys returns the sorted keys starting with the prefix.
+	ListKeys(ctx context.Context, prefix string) ([]string, error)
 	Flush(ctx context.Context) error
 }
 
-func NewClient(ctx context.Context, conn *grpc.ClientConn, tableName string) (Client, error) {
+// Closer is implemented by the clients returned by NewClient. Close stops the background flushes, and flushes the client.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// ConflictError is returned by the Flush of clients returned by NewClient with WithConflictCheck when keys were changed
+// by another client sharing the state table since they were read. The conflicting keys are reloaded with their stored values,
+// discarding the local changes, and the other keys are written.
+type ConflictError = stateV3.ConflictError
+
+// DefaultFlushInterval is the default interval of the background flushes of clients returned by NewClient.
+const DefaultFlushInterval = time.Minute
+
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	flushInterval time.Duration
+	checkConflict bool
+}
+
+// WithFlushInterval sets the interval of the background flushes of the client, or disables them if it isn't positive.
+// Defaults to DefaultFlushInterval.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(o *clientOptions) {
+		o.flushInterval = interval
+	}
+}
+
+// WithConflictCheck makes the client detect some of the changes other clients sharing the state table made to the same keys
+// (see ConflictError). Every flush then reads the whole state table before and after writing the changed keys.
+// The check is best-effort: destinations have no conditional writes, so a flush of another client that runs between the
+// read and the write of this client's flush is still overwritten without either client getting an error.
+// Sharing a state table between syncs running at the same time, e.g. parallel syncs of shards of the same source,
+// isn't safe even with the check: give each of them its own state table instead.
+func WithConflictCheck() Option {
+	return func(o *clientOptions) {
+		o.checkConflict = true
+	}
+}
+
+// NewClient returns a client for the state table in the destination plugin at conn.
+// The keys are versioned, so clients sharing a state table can detect some of each other's changes (see WithConflictCheck).
+// The client flushes its changes periodically in the background (see WithFlushInterval), and when ctx is done.
+// It implements Closer, which should be called before closing conn.
+func NewClient(ctx context.Context, conn *grpc.ClientConn, tableName string, opts ...Option) (Client, error) {
+	o := &clientOptions{flushInterval: DefaultFlushInterval}
+	for _, opt := range opts {
+		opt(o)
+	}
 	discoveryClient := pbDiscovery.NewDiscoveryClient(conn)
 	versions, err := discoveryClient.GetVersions(ctx, &pbDiscovery.GetVersions_Request{})
 	if err != nil {
 		return nil, err
 	}
 	if slices.Contains(versions.Versions, 3) {
-		return stateV3.NewClient(ctx, pbPluginV3.NewPluginClient(conn), tableName)
+		return stateV3.NewClient(ctx, pbPluginV3.NewPluginClient(conn), tableName,
+			stateV3.WithFlushInterval(o.flushInterval), stateV3.WithConflictCheck(o.checkConflict))
 	}
 	return nil, fmt.Errorf("please upgrade your state backend plugin. state supporting version 3 plugin has %v", versions.Versions)
 }
@@ -39,6 +94,14 @@ func (*NoOpClient) GetKey(_ context.Context, _ string) (string, error) {
 	return "", nil
 }
 
+func (*NoOpClient) DeleteKey(_ context.Context, _ string) error {
+	return nil
+}
+
+func (*NoOpClient) ListKeys(_ context.Context, _ string) ([]string, error) {
+	return nil, nil
+}
+
 func (*NoOpClient) Flush(_ context.Context) error {
 	return nil
 }
@@ -0,0 +1,323 @@
+package transformers
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+)
+
+// fieldDecoder decodes a single column into a struct field
+type fieldDecoder struct {
+	column string
+	index  []int
+}
+
+type recordDecoder struct {
+	fields []fieldDecoder
+}
+
+// newRecordDecoder maps the columns TransformWithStruct would create for the struct type to struct fields,
+// using the same naming rules and options
+func newRecordDecoder(structType reflect.Type, opts ...StructTransformerOption) (*recordDecoder, error) {
+	t := newStructTransformer(opts...)
+	d := &recordDecoder{}
+	seen := make(map[string]bool)
+	err := t.walkFields(structType, func(field reflect.StructField, parents []reflect.StructField, index []int) error {
+		if t.ignoreField(field) {
+			return nil
+		}
+		name, _, err := t.columnName(field, parents)
+		if err != nil {
+			return err
+		}
+		if name == "" || seen[name] {
+			return nil
+		}
+		seen[name] = true
+		d.fields = append(d.fields, fieldDecoder{column: name, index: index})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *recordDecoder) decodeRow(rec arrow.Record, row int, dst reflect.Value) error {
+	sc := rec.Schema()
+	for _, f := range d.fields {
+		indices := sc.FieldIndices(f.column)
+		if len(indices) == 0 {
+			continue
+		}
+		s, err := scalar.FromArray(rec.Column(indices[0]), row)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", f.column, err)
+		}
+		if !s.IsValid() {
+			continue
+		}
+		field, err := fieldByIndexAlloc(dst, f.index)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", f.column, err)
+		}
+		if err := decodeScalar(s, field); err != nil {
+			return fmt.Errorf("column %s: %w", f.column, err)
+		}
+	}
+	return nil
+}
+
+// DecodeRecord decodes all rows of the record into dst, which must be a pointer to a slice of structs or struct pointers.
+// Decoded rows are appended to the slice.
+// Columns are matched to struct fields using the same rules (and options) as TransformWithStruct.
+// Columns without a matching field, and fields without a matching column, are ignored.
+func DecodeRecord(rec arrow.Record, dst any, opts ...StructTransformerOption) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected pointer to slice, got %T", dst)
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPointer := elemType.Kind() == reflect.Pointer
+	structType := elemType
+	if isPointer {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("expected slice of structs, got %T", dst)
+	}
+	d, err := newRecordDecoder(structType, opts...)
+	if err != nil {
+		return err
+	}
+	for row := 0; row < int(rec.NumRows()); row++ {
+		elem := reflect.New(structType)
+		if err := d.decodeRow(rec, row, elem.Elem()); err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		if isPointer {
+			slice = reflect.Append(slice, elem)
+		} else {
+			slice = reflect.Append(slice, elem.Elem())
+		}
+	}
+	v.Elem().Set(slice)
+	return nil
+}
+
+// DecodeRow decodes a single row of the record into dst, which must be a pointer to a struct.
+// See DecodeRecord for how columns are matched to fields.
+func DecodeRow(rec arrow.Record, row int, dst any, opts ...StructTransformerOption) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected pointer to struct, got %T", dst)
+	}
+	if row < 0 || row >= int(rec.NumRows()) {
+		return fmt.Errorf("row %d out of range [0, %d)", row, rec.NumRows())
+	}
+	d, err := newRecordDecoder(v.Elem().Type(), opts...)
+	if err != nil {
+		return err
+	}
+	return d.decodeRow(rec, row, v.Elem())
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex, but allocates nil embedded struct pointers
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set nil pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	netIPType = reflect.TypeOf(net.IP{})
+)
+
+// decodeScalar sets the field to the value of the (valid) scalar
+func decodeScalar(s scalar.Scalar, field reflect.Value) error {
+	t := field.Type()
+	if t.Kind() == reflect.Pointer {
+		v := reflect.New(t.Elem())
+		if err := decodeScalar(s, v.Elem()); err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	}
+
+	switch s := s.(type) {
+	case *scalar.JSON:
+		// JSON columns hold structs, maps, slices of interfaces etc.
+		return json.Unmarshal(s.Value, field.Addr().Interface())
+	case *scalar.Struct:
+		b, err := json.Marshal(s.Value)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(b, field.Addr().Interface())
+	case *scalar.List:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			break
+		}
+		if t.Kind() == reflect.Array && t.Len() < len(s.Value) {
+			return fmt.Errorf("cannot decode %d values into %s", len(s.Value), t)
+		}
+		if t.Kind() == reflect.Slice {
+			field.Set(reflect.MakeSlice(t, len(s.Value), len(s.Value)))
+		}
+		for i, elem := range s.Value {
+			if !elem.IsValid() {
+				continue
+			}
+			if err := decodeScalar(elem, field.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return nil
+	case *scalar.Map:
+		if t.Kind() != reflect.Map {
+			break
+		}
+		m := reflect.MakeMapWithSize(t, len(s.Value))
+		for _, item := range s.Value {
+			k := reflect.New(t.Key()).Elem()
+			if err := decodeScalar(item.Key, k); err != nil {
+				return fmt.Errorf("map key: %w", err)
+			}
+			v := reflect.New(t.Elem()).Elem()
+			if item.Value.IsValid() {
+				if err := decodeScalar(item.Value, v); err != nil {
+					return fmt.Errorf("map value: %w", err)
+				}
+			}
+			m.SetMapIndex(k, v)
+		}
+		field.Set(m)
+		return nil
+	case *scalar.Inet:
+		if t == netIPType {
+			ip := s.Value.IP
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			field.Set(reflect.ValueOf(ip))
+			return nil
+		}
+	case *scalar.Date32:
+		if t == timeType {
+			field.Set(reflect.ValueOf(s.Value.ToTime()))
+			return nil
+		}
+	case *scalar.Date64:
+		if t == timeType {
+			field.Set(reflect.ValueOf(s.Value.ToTime()))
+			return nil
+		}
+	case *scalar.Decimal128, *scalar.Decimal256:
+		if ok, err := decodeDecimal(s.String(), field); ok {
+			return err
+		}
+	case *scalar.Duration:
+		if t == durationType {
+			field.Set(reflect.ValueOf(time.Duration(s.Value) * s.Unit.Multiplier()))
+			return nil
+		}
+	}
+
+	v := reflect.ValueOf(s.Get())
+	if !v.IsValid() {
+		return nil
+	}
+	switch {
+	case v.Type().AssignableTo(t):
+		field.Set(v)
+	case isNumber(v.Kind()) && isNumber(t.Kind()):
+		if overflows(v, t) {
+			return fmt.Errorf("value %v overflows %s", v, t)
+		}
+		field.Set(v.Convert(t))
+	case v.Type().ConvertibleTo(t) && !isNumber(v.Kind()) && !isNumber(t.Kind()):
+		field.Set(v.Convert(t))
+	case t.Kind() == reflect.String:
+		field.SetString(s.String())
+	default:
+		return fmt.Errorf("cannot decode %s into %s", s.DataType(), t)
+	}
+	return nil
+}
+
+// decodeDecimal sets big numbers and decimal types implementing encoding.TextUnmarshaler to the decimal string.
+// It returns false if the field isn't one of these types.
+func decodeDecimal(str string, field reflect.Value) (bool, error) {
+	var ok bool
+	switch dst := field.Addr().Interface().(type) {
+	case *big.Int:
+		_, ok = dst.SetString(str, 10)
+	case *big.Float:
+		_, ok = dst.SetString(str)
+	case *big.Rat:
+		_, ok = dst.SetString(str)
+	case encoding.TextUnmarshaler:
+		return true, dst.UnmarshalText([]byte(str))
+	default:
+		return false, nil
+	}
+	if !ok {
+		return true, fmt.Errorf("cannot decode %s into %s", str, field.Type())
+	}
+	return true, nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// overflows reports whether the number v can't be represented by type t
+func overflows(v reflect.Value, t reflect.Type) bool {
+	target := reflect.New(t).Elem()
+	switch {
+	case v.CanInt() && target.CanInt():
+		return target.OverflowInt(v.Int())
+	case v.CanInt() && target.CanUint():
+		return v.Int() < 0 || target.OverflowUint(uint64(v.Int()))
+	case v.CanUint() && target.CanUint():
+		return target.OverflowUint(v.Uint())
+	case v.CanUint() && target.CanInt():
+		return v.Uint() > math.MaxInt64 || target.OverflowInt(int64(v.Uint()))
+	case v.CanFloat() && target.CanFloat():
+		return target.OverflowFloat(v.Float())
+	case v.CanFloat():
+		// floats can only be decoded into integers if they are whole numbers
+		f := v.Float()
+		return f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 || overflows(reflect.ValueOf(int64(f)), t)
+	default:
+		return false
+	}
+}
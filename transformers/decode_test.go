@@ -0,0 +1,189 @@
+package transformers
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodeInnerStruct struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type DecodeEmbeddedStruct struct {
+	EmbeddedString string
+}
+
+type decodeTestStruct struct {
+	IntCol        int                `json:"int_col"`
+	Int8Col       int8               `json:"int8_col"`
+	UintCol       uint32             `json:"uint_col"`
+	FloatCol      float32            `json:"float_col"`
+	StringCol     string             `json:"string_col"`
+	BoolCol       bool               `json:"bool_col"`
+	StringPtrCol  *string            `json:"string_ptr_col"`
+	NilPtrCol     *int               `json:"nil_ptr_col"`
+	TimeCol       time.Time          `json:"time_col"`
+	TimePtrCol    *time.Time         `json:"time_ptr_col"`
+	InetCol       net.IP             `json:"inet_col"`
+	ByteArrayCol  []byte             `json:"byte_array_col"`
+	IntArrayCol   []int              `json:"int_array_col"`
+	StringPtrsCol []*string          `json:"string_ptrs_col"`
+	JSONCol       decodeInnerStruct  `json:"json_col"`
+	JSONPtrCol    *decodeInnerStruct `json:"json_ptr_col"`
+	MapCol        map[string]any     `json:"map_col"`
+	AnyArrayCol   []any              `json:"any_array_col"`
+	SkippedCol    string             `json:"-"`
+	unexported    string
+	*DecodeEmbeddedStruct
+}
+
+func decodeTestRecord(t *testing.T, items []decodeTestStruct, opts ...StructTransformerOption) (*schema.Table, arrow.Record) {
+	t.Helper()
+	table := &schema.Table{Name: "test_decode", Columns: schema.ColumnList{schema.CqIDColumn}}
+	require.NoError(t, TransformWithStruct(&decodeTestStruct{}, opts...)(table))
+
+	sc := table.ToArrowSchema()
+	bldr := array.NewRecordBuilder(memory.DefaultAllocator, sc)
+	defer bldr.Release()
+	for _, item := range items {
+		resource := schema.NewResourceData(table, nil, item)
+		for _, c := range table.Columns {
+			if c.Resolver == nil {
+				continue
+			}
+			require.NoError(t, c.Resolver(context.Background(), nil, resource, c))
+		}
+		scalar.AppendToRecordBuilder(bldr, resource.GetValues())
+	}
+	return table, bldr.NewRecord()
+}
+
+func TestDecodeRecord(t *testing.T) {
+	str := "foo"
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	items := []decodeTestStruct{
+		{
+			IntCol:        -1,
+			Int8Col:       8,
+			UintCol:       32,
+			FloatCol:      1.5,
+			StringCol:     "string",
+			BoolCol:       true,
+			StringPtrCol:  &str,
+			TimeCol:       now,
+			TimePtrCol:    &now,
+			InetCol:       net.ParseIP("192.168.1.1").To4(),
+			ByteArrayCol:  []byte("bytes"),
+			IntArrayCol:   []int{1, 2, 3},
+			StringPtrsCol: []*string{&str, &str},
+			JSONCol:       decodeInnerStruct{Name: "inner", Count: 1},
+			JSONPtrCol:    &decodeInnerStruct{Name: "inner_ptr", Count: 2},
+			MapCol:        map[string]any{"a": "b"},
+			AnyArrayCol:   []any{"a", float64(1)},
+			DecodeEmbeddedStruct: &DecodeEmbeddedStruct{
+				EmbeddedString: "embedded",
+			},
+		},
+		{
+			// zero times and nil JSON values don't round-trip, as they're stored as valid values
+			TimeCol:              now,
+			MapCol:               map[string]any{},
+			AnyArrayCol:          []any{},
+			DecodeEmbeddedStruct: &DecodeEmbeddedStruct{},
+		},
+	}
+
+	_, rec := decodeTestRecord(t, items, WithUnwrapAllEmbeddedStructs())
+	defer rec.Release()
+
+	var got []decodeTestStruct
+	require.NoError(t, DecodeRecord(rec, &got, WithUnwrapAllEmbeddedStructs()))
+	require.Len(t, got, 2)
+	assert.Equal(t, items, got)
+
+	var gotPtrs []*decodeTestStruct
+	require.NoError(t, DecodeRecord(rec, &gotPtrs, WithUnwrapAllEmbeddedStructs()))
+	require.Len(t, gotPtrs, 2)
+	assert.Equal(t, items[0], *gotPtrs[0])
+
+	var row decodeTestStruct
+	require.NoError(t, DecodeRow(rec, 0, &row, WithUnwrapAllEmbeddedStructs()))
+	assert.Equal(t, items[0], row)
+	assert.Error(t, DecodeRow(rec, 2, &row))
+}
+
+func TestDecodeRecordOptions(t *testing.T) {
+	type item struct {
+		ID   int64
+		Name string
+	}
+	prefixed := WithNameTransformer(func(field reflect.StructField) (string, error) {
+		name, err := DefaultNameTransformer(field)
+		return "x_" + name, err
+	})
+	table := &schema.Table{Name: "test_options"}
+	require.NoError(t, TransformWithStruct(item{}, prefixed)(table))
+	rec := scalar.Vector{&scalar.Int{Valid: true, Value: 1}, &scalar.String{Valid: true, Value: "a"}}.ToArrowRecord(table.ToArrowSchema())
+	defer rec.Release()
+
+	var got []item
+	require.NoError(t, DecodeRecord(rec, &got, prefixed))
+	assert.Equal(t, []item{{ID: 1, Name: "a"}}, got)
+
+	// without the name transformer no columns match
+	got = nil
+	require.NoError(t, DecodeRecord(rec, &got))
+	assert.Equal(t, []item{{}}, got)
+
+	got = nil
+	require.NoError(t, DecodeRecord(rec, &got, prefixed, WithSkipFields("Name")))
+	assert.Equal(t, []item{{ID: 1}}, got)
+}
+
+func TestDecodeRecordErrors(t *testing.T) {
+	sc := arrow.NewSchema([]arrow.Field{{Name: "value", Type: arrow.PrimitiveTypes.Int64, Nullable: true}}, nil)
+	rec := scalar.Vector{&scalar.Int{Valid: true, Value: 300}}.ToArrowRecord(sc)
+	defer rec.Release()
+
+	var int8s []struct{ Value int8 }
+	assert.ErrorContains(t, DecodeRecord(rec, &int8s), "overflows")
+	var uint8s []struct{ Value uint8 }
+	assert.ErrorContains(t, DecodeRecord(rec, &uint8s), "overflows")
+	var times []struct{ Value time.Time }
+	assert.ErrorContains(t, DecodeRecord(rec, &times), "cannot decode")
+	var ints []struct{ Value int16 }
+	require.NoError(t, DecodeRecord(rec, &ints))
+	assert.Equal(t, int16(300), ints[0].Value)
+
+	assert.Error(t, DecodeRecord(rec, ints))
+	assert.Error(t, DecodeRecord(rec, &[]int{}))
+	assert.Error(t, DecodeRow(rec, 0, ints))
+}
+
+func TestDecodeRecordTags(t *testing.T) {
+	type item struct {
+		ID     int64  `cq:"pk,name=item_id"`
+		Secret string `cq:"skip"`
+	}
+	table := &schema.Table{Name: "test_tags"}
+	require.NoError(t, TransformWithStruct(item{})(table))
+	sc := arrow.NewSchema(append(table.ToArrowSchema().Fields(), arrow.Field{Name: "secret", Type: arrow.BinaryTypes.String, Nullable: true}), nil)
+	rec := scalar.Vector{&scalar.Int{Valid: true, Value: 1}, &scalar.String{Valid: true, Value: "s"}}.ToArrowRecord(sc)
+	defer rec.Release()
+
+	var got []item
+	require.NoError(t, DecodeRecord(rec, &got))
+	assert.Equal(t, []item{{ID: 1}}, got)
+}
@@ -0,0 +1,132 @@
+package transformers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// descriptionTag is the struct tag used to set the description of a column, e.g. `description:"The ID of the resource"`
+const descriptionTag = "description"
+
+// DescriptionsFromSource parses the Go files (or directories of Go files, not recursively) at paths
+// and returns the doc comments of struct fields, keyed by "TypeName.FieldName".
+// Trailing line comments are used for fields without a doc comment.
+// The result can be passed to WithDescriptions, e.g. from a pre-generated file, as the source code of
+// third party packages is usually not available when the plugin is running.
+func DescriptionsFromSource(paths ...string) (map[string]string, error) {
+	fset := token.NewFileSet()
+	descriptions := make(map[string]string)
+	for _, p := range paths {
+		files, err := goSourceFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			addFieldDescriptions(f, descriptions)
+		}
+	}
+	return descriptions, nil
+}
+
+// goSourceFiles returns p if it's a file, or the non-test Go files in p if it's a directory
+func goSourceFiles(p string) ([]string, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{p}, nil
+	}
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, filepath.Join(p, name))
+	}
+	return files, nil
+}
+
+func addFieldDescriptions(f *ast.File, descriptions map[string]string) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		for _, field := range st.Fields.List {
+			doc := field.Doc
+			if doc == nil {
+				doc = field.Comment
+			}
+			description := commentText(doc)
+			if description == "" {
+				continue
+			}
+			for _, name := range field.Names {
+				descriptions[spec.Name.Name+"."+name.Name] = description
+			}
+		}
+		return true
+	})
+}
+
+// commentText returns the text of the comment group as a single line
+func commentText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+// fieldDescription returns the description set in the description tag, falling back to the descriptions
+// set with WithDescriptions and WithDescriptionsFromSource. owner is the struct type declaring the field.
+func (t *structTransformer) fieldDescription(field reflect.StructField, owner reflect.Type) string {
+	if description, ok := field.Tag.Lookup(descriptionTag); ok {
+		return description
+	}
+	if owner == nil || owner.Name() == "" {
+		return ""
+	}
+	key := descriptionKey(owner, field)
+	if description, ok := t.descriptions[key]; ok {
+		return description
+	}
+	return t.sourceDescriptions[key]
+}
+
+func descriptionKey(owner reflect.Type, field reflect.StructField) string {
+	// strip type parameters of generic types, as they aren't part of the type name in the source
+	name, _, _ := strings.Cut(owner.Name(), "[")
+	return name + "." + field.Name
+}
+
+// ownerType returns the struct type declaring the field at index of eType
+func ownerType(eType reflect.Type, index []int) reflect.Type {
+	owner := eType
+	for _, i := range index[:len(index)-1] {
+		owner = owner.Field(i).Type
+		if owner.Kind() == reflect.Pointer {
+			owner = owner.Elem()
+		}
+	}
+	return owner
+}
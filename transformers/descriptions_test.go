@@ -0,0 +1,112 @@
+package transformers
+
+import (
+	"testing"
+
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type describedDetails struct {
+	// Region is where the resource
+	// is located.
+	Region string
+	Zone   string // the availability zone
+}
+
+type describedResource struct {
+	// ID is the unique identifier.
+	ID string
+	// Name is overridden by the tag.
+	Name string `description:"The name | label"`
+	// Details are unwrapped.
+	Details describedDetails
+	*describedEmbedded
+	Undocumented int
+}
+
+type describedEmbedded struct {
+	// Owner of the resource.
+	Owner string
+}
+
+func TestDescriptionsFromSource(t *testing.T) {
+	descriptions, err := DescriptionsFromSource("descriptions_test.go")
+	require.NoError(t, err)
+	assert.Equal(t, "Region is where the resource is located.", descriptions["describedDetails.Region"])
+	assert.Equal(t, "the availability zone", descriptions["describedDetails.Zone"])
+	assert.Equal(t, "ID is the unique identifier.", descriptions["describedResource.ID"])
+	assert.NotContains(t, descriptions, "describedResource.Undocumented")
+
+	// test files are skipped when parsing directories
+	descriptions, err = DescriptionsFromSource(".")
+	require.NoError(t, err)
+	assert.NotContains(t, descriptions, "describedResource.ID")
+
+	_, err = DescriptionsFromSource("does_not_exist.go")
+	require.Error(t, err)
+}
+
+func TestTransformWithStructDescriptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []StructTransformerOption
+		descriptions map[string]string
+	}{
+		{
+			name: "tags only",
+			descriptions: map[string]string{
+				"name": "The name | label",
+			},
+		},
+		{
+			name: "from source",
+			opts: []StructTransformerOption{WithDescriptionsFromSource("descriptions_test.go"), WithUnwrapStructFields("Details")},
+			descriptions: map[string]string{
+				"id":             "ID is the unique identifier.",
+				"name":           "The name | label",
+				"details_region": "Region is where the resource is located.",
+				"details_zone":   "the availability zone",
+			},
+		},
+		{
+			name: "map takes precedence over source",
+			opts: []StructTransformerOption{
+				WithDescriptionsFromSource("descriptions_test.go"),
+				WithDescriptions(map[string]string{"describedResource.ID": "The ID", "describedResource.Name": "ignored", "describedResource.Undocumented": "Documented"}),
+			},
+			descriptions: map[string]string{
+				"id":           "The ID",
+				"name":         "The name | label",
+				"details":      "Details are unwrapped.",
+				"undocumented": "Documented",
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			table := &schema.Table{Name: "test_descriptions"}
+			require.NoError(t, TransformWithStruct(&describedResource{}, tc.opts...)(table))
+			got := make(map[string]string)
+			for _, c := range table.Columns {
+				if c.Description != "" {
+					got[c.Name] = c.Description
+				}
+			}
+			assert.Equal(t, tc.descriptions, got)
+		})
+	}
+
+	// descriptions are kept in the arrow field metadata, so destinations can use them as column comments
+	table := &schema.Table{Name: "test_descriptions"}
+	require.NoError(t, TransformWithStruct(&describedResource{})(table))
+	roundTrip, err := schema.NewTableFromArrowSchema(table.ToArrowSchema())
+	require.NoError(t, err)
+	assert.Equal(t, "The name | label", roundTrip.Columns.Get("name").Description)
+
+	table = &schema.Table{Name: "test_descriptions"}
+	err = TransformWithStruct(&describedResource{}, WithDescriptionsFromSource("does_not_exist.go"))(table)
+	require.ErrorContains(t, err, "failed to parse descriptions")
+}
@@ -0,0 +1,561 @@
+package transformers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+	"github.com/thoas/go-funk"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// TransformWithJSONSchema returns a transform that adds a column for every property of the JSON Schema object in document,
+// which can be JSON or YAML. Local references (e.g. "#/$defs/Address") are resolved.
+//
+// Columns are named and typed the same way TransformWithStruct names and types the fields of the equivalent Go struct
+// (properties are treated like JSON field names), required properties are not null unless they allow null values
+// (a "null" type, or nullable: true in OpenAPI 3.0), and property descriptions are used
+// as column descriptions. The column resolvers expect the resource items to be map[string]any values, as decoded by encoding/json.
+//
+// The supported options are WithSkipFields and WithPrimaryKeys (property names, or paths such as "spec.region" for unwrapped objects),
+// WithArrowMaps, WithArrowStructs, WithExactNumericTypes, WithUnwrapAllStructFields, WithUnwrapStructFields, WithoutUnwrapStructFields
+// and WithMaxUnwrapDepth. The other options work on Go struct fields and are ignored.
+func TransformWithJSONSchema(document []byte, opts ...StructTransformerOption) schema.Transform {
+	return func(table *schema.Table) error {
+		root, err := parseJSONSchemaDocument(document)
+		if err != nil {
+			return err
+		}
+		return transformWithJSONSchema(table, root, root, opts...)
+	}
+}
+
+// TransformWithOpenAPIComponent returns a transform that adds a column for every property of the named schema
+// in the OpenAPI 3 (components/schemas) or Swagger 2 (definitions) spec, which can be JSON or YAML.
+// See TransformWithJSONSchema for how columns are created.
+func TransformWithOpenAPIComponent(spec []byte, name string, opts ...StructTransformerOption) schema.Transform {
+	return func(table *schema.Table) error {
+		root, err := parseJSONSchemaDocument(spec)
+		if err != nil {
+			return err
+		}
+		component := lookupNode(root, "components", "schemas", name)
+		if component == nil {
+			component = lookupNode(root, "definitions", name)
+		}
+		if component == nil {
+			return fmt.Errorf("schema %q not found in components/schemas or definitions", name)
+		}
+		return transformWithJSONSchema(table, root, component, opts...)
+	}
+}
+
+func transformWithJSONSchema(table *schema.Table, root, node *yaml.Node, opts ...StructTransformerOption) error {
+	p := &jsonSchemaParser{root: root}
+	s, err := p.parse(node)
+	if err != nil {
+		return err
+	}
+	if typ, _ := s.singleType(); typ != "object" {
+		return fmt.Errorf("expected object schema, got %q", strings.Join(s.Types, ","))
+	}
+
+	t := &jsonSchemaTransformer{structTransformer: newStructTransformer(opts...), table: table}
+	if err := t.addColumns(s, nil, true); err != nil {
+		return err
+	}
+	// Validate that all expected PK fields were found
+	if diff := funk.SubtractString(t.pkFields, t.pkFieldsFound); len(diff) > 0 {
+		return fmt.Errorf("failed to create all of the desired primary keys: %v", diff)
+	}
+	return nil
+}
+
+func parseJSONSchemaDocument(document []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(document, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, fmt.Errorf("failed to parse schema: empty document")
+	}
+	return doc.Content[0], nil
+}
+
+// jsonSchema is the subset of a JSON Schema (or OpenAPI schema object) used to create columns
+type jsonSchema struct {
+	Types                []string
+	Format               string
+	Description          string
+	Properties           []jsonSchemaProperty
+	Required             []string
+	Items                *jsonSchema
+	AdditionalProperties *jsonSchema
+	// Nullable is set by nullable: true (OpenAPI 3.0), or a null alternative of oneOf or anyOf
+	Nullable bool
+	// Composite is set for schemas using oneOf, anyOf or not, as their type can't be determined
+	Composite bool
+	// Recursive is set for references to a schema that is being parsed
+	Recursive bool
+}
+
+type jsonSchemaProperty struct {
+	Name   string
+	Schema *jsonSchema
+}
+
+// singleType returns the type of the schema, ignoring "null"
+func (s *jsonSchema) singleType() (string, bool) {
+	var typ string
+	for _, t := range s.Types {
+		switch {
+		case t == "null":
+		case typ != "":
+			return "", false
+		default:
+			typ = t
+		}
+	}
+	return typ, typ != ""
+}
+
+// allowsNull reports whether null is a valid value of the schema
+func (s *jsonSchema) allowsNull() bool {
+	return s.Nullable || slices.Contains(s.Types, "null")
+}
+
+func (s *jsonSchema) merge(other *jsonSchema) {
+	if len(s.Types) == 0 {
+		s.Types = other.Types
+	}
+	if s.Format == "" {
+		s.Format = other.Format
+	}
+	if s.Description == "" {
+		s.Description = other.Description
+	}
+	for _, p := range other.Properties {
+		if !slices.ContainsFunc(s.Properties, func(sp jsonSchemaProperty) bool { return sp.Name == p.Name }) {
+			s.Properties = append(s.Properties, p)
+		}
+	}
+	s.Required = append(s.Required, other.Required...)
+	if s.Items == nil {
+		s.Items = other.Items
+	}
+	if s.AdditionalProperties == nil {
+		s.AdditionalProperties = other.AdditionalProperties
+	}
+	s.Nullable = s.Nullable || other.Nullable
+	s.Composite = s.Composite || other.Composite
+	s.Recursive = s.Recursive || other.Recursive
+}
+
+type jsonSchemaParser struct {
+	root *yaml.Node
+	// refs are the references being parsed, to detect recursive schemas
+	refs []string
+}
+
+func (p *jsonSchemaParser) parse(n *yaml.Node) (*jsonSchema, error) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	// boolean schemas (true/false) allow any value
+	if n.Kind == yaml.ScalarNode {
+		return &jsonSchema{}, nil
+	}
+	if n.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected schema object", n.Line)
+	}
+
+	if ref := lookupNode(n, "$ref"); ref != nil {
+		return p.parseRef(n, ref.Value)
+	}
+
+	s := &jsonSchema{}
+	var title string
+	var allOf []*jsonSchema
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i].Value, n.Content[i+1]
+		var err error
+		switch key {
+		case "type":
+			if value.Kind == yaml.SequenceNode {
+				err = value.Decode(&s.Types)
+			} else {
+				s.Types = []string{value.Value}
+			}
+		case "format":
+			s.Format = value.Value
+		case "description":
+			s.Description = value.Value
+		case "nullable":
+			err = value.Decode(&s.Nullable)
+		case "title":
+			title = value.Value
+		case "required":
+			// OpenAPI 3.1 and JSON Schema draft 3 allow a boolean
+			if value.Kind == yaml.SequenceNode {
+				err = value.Decode(&s.Required)
+			}
+		case "properties":
+			err = p.parseProperties(s, value)
+		case "items":
+			// tuples (items as a list of schemas) are mapped to JSON
+			if value.Kind != yaml.SequenceNode {
+				s.Items, err = p.parse(value)
+			}
+		case "additionalProperties":
+			if value.Kind == yaml.MappingNode {
+				s.AdditionalProperties, err = p.parse(value)
+			}
+		case "allOf":
+			for _, sub := range value.Content {
+				var subSchema *jsonSchema
+				if subSchema, err = p.parse(sub); err != nil {
+					break
+				}
+				allOf = append(allOf, subSchema)
+			}
+		case "oneOf", "anyOf":
+			err = p.parseAlternatives(s, value)
+		case "not":
+			s.Composite = true
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	for _, sub := range allOf {
+		s.merge(sub)
+	}
+	if s.Description == "" {
+		s.Description = title
+	}
+	if len(s.Types) == 0 && len(s.Properties) > 0 {
+		s.Types = []string{"object"}
+	}
+	return s, nil
+}
+
+func (p *jsonSchemaParser) parseRef(n *yaml.Node, ref string) (*jsonSchema, error) {
+	if slices.Contains(p.refs, ref) {
+		return &jsonSchema{Recursive: true}, nil
+	}
+	target, err := lookupRef(p.root, ref)
+	if err != nil {
+		return nil, err
+	}
+	p.refs = append(p.refs, ref)
+	defer func() { p.refs = p.refs[:len(p.refs)-1] }()
+	s, err := p.parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	// a description next to the reference describes the property (OpenAPI 3.1, JSON Schema 2019-09)
+	if description := lookupNode(n, "description"); description != nil {
+		c := *s
+		c.Description = description.Value
+		return &c, nil
+	}
+	return s, nil
+}
+
+func (p *jsonSchemaParser) parseProperties(s *jsonSchema, n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected object", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		name := n.Content[i].Value
+		ps, err := p.parse(n.Content[i+1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		s.Properties = append(s.Properties, jsonSchemaProperty{Name: name, Schema: ps})
+	}
+	return nil
+}
+
+// parseAlternatives handles oneOf and anyOf. A single alternative besides null (e.g. anyOf: [{$ref: ...}, {type: null}])
+// is used as the schema, otherwise the schema is composite.
+func (p *jsonSchemaParser) parseAlternatives(s *jsonSchema, n *yaml.Node) error {
+	var alternatives []*jsonSchema
+	for _, sub := range n.Content {
+		subSchema, err := p.parse(sub)
+		if err != nil {
+			return err
+		}
+		if len(subSchema.Types) == 1 && subSchema.Types[0] == "null" {
+			s.Nullable = true
+			continue
+		}
+		alternatives = append(alternatives, subSchema)
+	}
+	if len(alternatives) != 1 {
+		s.Composite = true
+		return nil
+	}
+	s.merge(alternatives[0])
+	return nil
+}
+
+// lookupNode returns the node at the path of mapping keys, or nil
+func lookupNode(n *yaml.Node, path ...string) *yaml.Node {
+	for _, key := range path {
+		if n.Kind == yaml.AliasNode {
+			n = n.Alias
+		}
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				next = n.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return n
+}
+
+// lookupRef resolves a local reference (a JSON pointer fragment such as "#/components/schemas/Item")
+func lookupRef(root *yaml.Node, ref string) (*yaml.Node, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported reference %q: only local references are supported", ref)
+	}
+	n := root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token, err := url.PathUnescape(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference %q: %w", ref, err)
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if n.Kind == yaml.SequenceNode {
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n.Content) {
+				return nil, fmt.Errorf("reference %q not found", ref)
+			}
+			n = n.Content[i]
+			continue
+		}
+		if n = lookupNode(n, token); n == nil {
+			return nil, fmt.Errorf("reference %q not found", ref)
+		}
+	}
+	return n, nil
+}
+
+type jsonSchemaTransformer struct {
+	*structTransformer
+	table *schema.Table
+}
+
+// addColumns adds the columns for the properties of the object schema.
+// required is false if any of the unwrapped parents isn't required, as the properties can be missing then.
+func (t *jsonSchemaTransformer) addColumns(s *jsonSchema, parents []string, required bool) error {
+	for _, prop := range s.Properties {
+		path := append(parents[:len(parents):len(parents)], prop.Name)
+		dotted := strings.Join(path, ".")
+		if slices.Contains(t.skipFields, prop.Name) || slices.Contains(t.skipFields, dotted) {
+			continue
+		}
+		// properties allowing null values can't be not null columns, and neither can the properties unwrapped from them
+		propRequired := required && slices.Contains(s.Required, prop.Name) && !prop.Schema.allowsNull()
+		if t.shouldUnwrapProperty(prop.Schema, dotted, len(parents)) {
+			if err := t.addColumns(prop.Schema, path, propRequired); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := t.addColumn(prop.Schema, path, propRequired); err != nil {
+			return fmt.Errorf("failed to add column for property %s: %w", dotted, err)
+		}
+	}
+	return nil
+}
+
+func (t *jsonSchemaTransformer) shouldUnwrapProperty(s *jsonSchema, path string, depth int) bool {
+	typ, _ := s.singleType()
+	switch {
+	case typ != "object",
+		len(s.Properties) == 0,
+		s.Composite,
+		s.Recursive,
+		depth >= t.maxUnwrapDepth,
+		slices.Contains(t.structFieldsToKeep, path):
+		return false
+	default:
+		return t.unwrapAllStructFields || slices.Contains(t.structFieldsToUnwrap, path)
+	}
+}
+
+func (t *jsonSchemaTransformer) addColumn(s *jsonSchema, path []string, required bool) error {
+	names := make([]string, len(path))
+	for i, property := range path {
+		names[i] = defaultCaser.ToSnake(property)
+	}
+	name := strings.Join(names, "_")
+	if !schema.ValidColumnName(name) {
+		return fmt.Errorf("invalid column name %q, use WithSkipFields to skip the property", name)
+	}
+	if c := t.table.Columns.Get(name); c != nil {
+		return fmt.Errorf("column name %q collides with an existing column", name)
+	}
+
+	dotted := strings.Join(path, ".")
+	column := schema.Column{
+		Name:        name,
+		Type:        jsonSchemaType(s, goTypeOptions{arrowMaps: t.arrowMaps, maxStructDepth: t.arrowStructsMaxDepth, exactNumericTypes: t.exactNumericTypes}, 0),
+		Description: s.Description,
+		Resolver:    jsonSchemaPathResolver(path),
+		NotNull:     required,
+	}
+	if slices.Contains(t.pkFields, dotted) {
+		column.PrimaryKey = true
+		t.pkFieldsFound = append(t.pkFieldsFound, dotted)
+	}
+	t.table.Columns = append(t.table.Columns, column)
+	return nil
+}
+
+// jsonSchemaType maps the schema to an arrow type, following the conventions of goTypeToSchemaType for the equivalent Go type.
+// depth is the number of arrow structs the schema is nested in.
+func jsonSchemaType(s *jsonSchema, opts goTypeOptions, depth int) arrow.DataType {
+	typ, ok := s.singleType()
+	if !ok || s.Composite || s.Recursive {
+		return types.ExtensionTypes.JSON
+	}
+	switch typ {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return arrow.FixedWidthTypes.Timestamp_us
+		case "uuid":
+			return types.ExtensionTypes.UUID
+		case "ipv4", "ipv6":
+			return types.ExtensionTypes.Inet
+		case "byte":
+			// base64 encoded, like []byte in encoding/json
+			return arrow.BinaryTypes.Binary
+		}
+		return arrow.BinaryTypes.String
+	case "integer":
+		if opts.exactNumericTypes {
+			switch s.Format {
+			case "int32":
+				return arrow.PrimitiveTypes.Int32
+			case "uint32":
+				return arrow.PrimitiveTypes.Uint32
+			case "uint64":
+				return arrow.PrimitiveTypes.Uint64
+			}
+		}
+		return arrow.PrimitiveTypes.Int64
+	case "number":
+		if opts.exactNumericTypes && s.Format == "float" {
+			return arrow.PrimitiveTypes.Float32
+		}
+		return arrow.PrimitiveTypes.Float64
+	case "boolean":
+		return arrow.FixedWidthTypes.Boolean
+	case "array":
+		if s.Items == nil {
+			return types.ExtensionTypes.JSON
+		}
+		elem := jsonSchemaType(s.Items, opts, depth)
+		// if it's already JSON then we don't want to create list of JSON
+		if arrow.TypeEqual(elem, types.ExtensionTypes.JSON) {
+			return elem
+		}
+		return arrow.ListOf(elem)
+	case "object":
+		if len(s.Properties) > 0 {
+			if depth >= opts.maxStructDepth {
+				return types.ExtensionTypes.JSON
+			}
+			fields := make([]arrow.Field, len(s.Properties))
+			for i, p := range s.Properties {
+				fields[i] = arrow.Field{Name: p.Name, Type: jsonSchemaType(p.Schema, opts, depth+1), Nullable: true}
+			}
+			return arrow.StructOf(fields...)
+		}
+		if s.AdditionalProperties != nil && opts.arrowMaps {
+			return arrow.MapOf(arrow.BinaryTypes.String, jsonSchemaType(s.AdditionalProperties, opts, depth))
+		}
+		return types.ExtensionTypes.JSON
+	default:
+		return types.ExtensionTypes.JSON
+	}
+}
+
+// jsonSchemaPathResolver resolves the value at the path of map keys of a map[string]any item
+func jsonSchemaPathResolver(path []string) schema.ColumnResolver {
+	return func(_ context.Context, _ schema.ClientMeta, r *schema.Resource, c schema.Column) error {
+		var v any = r.Item
+		for _, key := range path {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return r.Set(c.Name, nil)
+			}
+			v = m[key]
+		}
+		v, err := jsonValueForType(v, c.Type)
+		if err != nil {
+			return err
+		}
+		return r.Set(c.Name, v)
+	}
+}
+
+// jsonValueForType converts a value decoded by encoding/json to a value the scalar of the data type accepts:
+// base64 strings are decoded for binary types, and properties that aren't part of struct types are dropped.
+func jsonValueForType(v any, dt arrow.DataType) (any, error) {
+	switch dt := dt.(type) {
+	case *arrow.BinaryType:
+		if s, ok := v.(string); ok {
+			return base64.StdEncoding.DecodeString(s)
+		}
+	case *arrow.StructType:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v, nil
+		}
+		fields := make(map[string]any, len(dt.Fields()))
+		for _, f := range dt.Fields() {
+			fv, err := jsonValueForType(m[f.Name], f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			fields[f.Name] = fv
+		}
+		return fields, nil
+	case *arrow.ListType:
+		l, ok := v.([]any)
+		if !ok {
+			return v, nil
+		}
+		values := make([]any, len(l))
+		for i := range l {
+			ev, err := jsonValueForType(l[i], dt.Elem())
+			if err != nil {
+				return nil, err
+			}
+			values[i] = ev
+		}
+		return values, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,328 @@
+package transformers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSONSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["id", "name", "location"],
+	"properties": {
+		"id": {"type": "string", "format": "uuid", "description": "The instance ID"},
+		"name": {"type": "string", "title": "Instance name"},
+		"createdAt": {"type": "string", "format": "date-time"},
+		"cpuCount": {"type": "integer", "format": "int32"},
+		"load": {"type": ["number", "null"], "format": "float"},
+		"enabled": {"type": "boolean"},
+		"ip": {"type": "string", "format": "ipv4"},
+		"userData": {"type": "string", "format": "byte"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+		"location": {"$ref": "#/$defs/Location", "description": "Where the instance runs"},
+		"disks": {"type": "array", "items": {"$ref": "#/$defs/Disk"}},
+		"parent": {"anyOf": [{"$ref": "#/$defs/Node"}, {"type": "null"}]},
+		"value": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+	},
+	"$defs": {
+		"Location": {
+			"type": "object",
+			"required": ["region"],
+			"properties": {
+				"region": {"type": "string"},
+				"zone": {"type": "string"}
+			}
+		},
+		"Disk": {
+			"allOf": [
+				{"properties": {"size": {"type": "integer"}}},
+				{"properties": {"encrypted": {"type": "boolean"}}, "required": ["size"]}
+			]
+		},
+		"Node": {
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"children": {"type": "array", "items": {"$ref": "#/$defs/Node"}}
+			}
+		}
+	}
+}`
+
+func TestTransformWithJSONSchema(t *testing.T) {
+	table := &schema.Table{Name: "test_json_schema"}
+	require.NoError(t, TransformWithJSONSchema([]byte(testJSONSchema), WithPrimaryKeys("id"))(table))
+
+	expected := schema.ColumnList{
+		{Name: "id", Type: types.ExtensionTypes.UUID, Description: "The instance ID", NotNull: true, PrimaryKey: true},
+		{Name: "name", Type: arrow.BinaryTypes.String, Description: "Instance name", NotNull: true},
+		{Name: "created_at", Type: arrow.FixedWidthTypes.Timestamp_us},
+		{Name: "cpu_count", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "load", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "enabled", Type: arrow.FixedWidthTypes.Boolean},
+		{Name: "ip", Type: types.ExtensionTypes.Inet},
+		{Name: "user_data", Type: arrow.BinaryTypes.Binary},
+		{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String)},
+		{Name: "labels", Type: types.ExtensionTypes.JSON},
+		{Name: "location", Type: types.ExtensionTypes.JSON, Description: "Where the instance runs", NotNull: true},
+		{Name: "disks", Type: types.ExtensionTypes.JSON},
+		{Name: "parent", Type: types.ExtensionTypes.JSON},
+		{Name: "value", Type: types.ExtensionTypes.JSON},
+	}
+	require.Equal(t, expected.Names(), table.Columns.Names())
+	for i, c := range table.Columns {
+		want := expected[i]
+		assert.Truef(t, arrow.TypeEqual(want.Type, c.Type), "column %s: expected type %s, got %s", c.Name, want.Type, c.Type)
+		assert.Equal(t, want.Description, c.Description, c.Name)
+		assert.Equal(t, want.NotNull, c.NotNull, c.Name)
+		assert.Equal(t, want.PrimaryKey, c.PrimaryKey, c.Name)
+		assert.NotNil(t, c.Resolver, c.Name)
+	}
+}
+
+func TestTransformWithJSONSchemaOptions(t *testing.T) {
+	nodeType := arrow.StructOf(
+		arrow.Field{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+		arrow.Field{Name: "children", Type: types.ExtensionTypes.JSON, Nullable: true},
+	)
+	diskType := arrow.StructOf(
+		arrow.Field{Name: "size", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		arrow.Field{Name: "encrypted", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+	)
+	table := &schema.Table{Name: "test_json_schema"}
+	require.NoError(t, TransformWithJSONSchema([]byte(testJSONSchema),
+		WithUnwrapStructFields("location"),
+		WithArrowStructs(1),
+		WithArrowMaps(),
+		WithExactNumericTypes(),
+		WithSkipFields("value", "location.zone"),
+		WithPrimaryKeys("location.region"),
+	)(table))
+
+	for name, want := range map[string]arrow.DataType{
+		"cpu_count":       arrow.PrimitiveTypes.Int32,
+		"load":            arrow.PrimitiveTypes.Float32,
+		"labels":          arrow.MapOf(arrow.BinaryTypes.String, arrow.BinaryTypes.String),
+		"location_region": arrow.BinaryTypes.String,
+		"disks":           arrow.ListOf(diskType),
+		"parent":          nodeType,
+	} {
+		c := table.Column(name)
+		require.NotNil(t, c, name)
+		assert.Truef(t, arrow.TypeEqual(want, c.Type), "column %s: expected type %s, got %s", name, want, c.Type)
+	}
+	assert.Nil(t, table.Column("value"))
+	assert.Nil(t, table.Column("location_zone"))
+	assert.Nil(t, table.Column("location"))
+	assert.Equal(t, []string{"location_region"}, table.PrimaryKeys())
+	assert.True(t, table.Column("location_region").NotNull)
+}
+
+func TestTransformWithJSONSchemaResolvers(t *testing.T) {
+	table := &schema.Table{Name: "test_json_schema"}
+	require.NoError(t, TransformWithJSONSchema([]byte(testJSONSchema), WithArrowStructs(1), WithUnwrapAllStructFields())(table))
+
+	var item map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "f81d4fae-7dec-11d0-a765-00a0c91e6bf6",
+		"name": "instance",
+		"createdAt": "2023-01-02T03:04:05Z",
+		"cpuCount": 4,
+		"enabled": true,
+		"ip": "10.0.0.1",
+		"userData": "aGVsbG8=",
+		"tags": ["a", "b"],
+		"labels": {"env": "prod"},
+		"location": {"region": "us-east-1"},
+		"disks": [{"size": 10, "encrypted": true, "unknown": "dropped"}],
+		"value": 1
+	}`), &item))
+
+	resource := schema.NewResourceData(table, nil, item)
+	for _, c := range table.Columns {
+		require.NoError(t, c.Resolver(context.Background(), nil, resource, c), c.Name)
+	}
+	assert.Equal(t, uuid.MustParse("f81d4fae-7dec-11d0-a765-00a0c91e6bf6"), resource.Get("id").Get())
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), resource.Get("created_at").Get())
+	assert.Equal(t, int64(4), resource.Get("cpu_count").Get())
+	assert.Equal(t, "10.0.0.1/32", resource.Get("ip").String())
+	assert.Equal(t, []byte("hello"), resource.Get("user_data").Get())
+	assert.Equal(t, "us-east-1", resource.Get("location_region").Get())
+	assert.False(t, resource.Get("location_zone").IsValid())
+	assert.False(t, resource.Get("load").IsValid())
+	assert.JSONEq(t, `{"env":"prod"}`, resource.Get("labels").String())
+	assert.Equal(t, `[{"encrypted":true,"size":10}]`, resource.Get("disks").String())
+
+	// the values can be added to a record
+	rec := resource.GetValues().ToArrowRecord(table.ToArrowSchema())
+	defer rec.Release()
+	assert.EqualValues(t, 1, rec.NumRows())
+}
+
+func TestTransformWithJSONSchemaNullable(t *testing.T) {
+	document := []byte(`{
+		"type": "object",
+		"required": ["id", "load", "note", "owner", "spec"],
+		"properties": {
+			"id": {"type": "integer"},
+			"load": {"type": ["number", "null"]},
+			"note": {"type": "string", "nullable": true},
+			"owner": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+			"spec": {"type": "object", "nullable": true, "required": ["size"], "properties": {"size": {"type": "integer"}}}
+		}
+	}`)
+	table := &schema.Table{Name: "test_json_schema_nullable"}
+	require.NoError(t, TransformWithJSONSchema(document, WithUnwrapAllStructFields())(table))
+	assert.Equal(t, []string{"id", "load", "note", "owner", "spec_size"}, table.Columns.Names())
+	assert.True(t, table.Column("id").NotNull)
+	assert.Equal(t, arrow.PrimitiveTypes.Float64, table.Column("load").Type)
+	for _, name := range []string{"load", "note", "owner", "spec_size"} {
+		assert.False(t, table.Column(name).NotNull, name)
+	}
+
+	// null values of required properties pass validation
+	var item map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{"id": 1, "load": null, "note": null, "owner": null, "spec": null}`), &item))
+	resource := schema.NewResourceData(table, nil, item)
+	for _, c := range table.Columns {
+		require.NoError(t, c.Resolver(context.Background(), nil, resource, c), c.Name)
+	}
+	require.NoError(t, resource.Validate())
+
+	openapi := []byte(`
+openapi: 3.0.0
+components:
+  schemas:
+    Metric:
+      type: object
+      required: [load, note]
+      properties:
+        load:
+          type: number
+        note:
+          type: string
+          nullable: true
+`)
+	table = &schema.Table{Name: "test_openapi_nullable"}
+	require.NoError(t, TransformWithOpenAPIComponent(openapi, "Metric")(table))
+	assert.True(t, table.Column("load").NotNull)
+	assert.False(t, table.Column("note").NotNull)
+}
+
+func TestTransformWithOpenAPIComponent(t *testing.T) {
+	spec := []byte(`
+openapi: 3.0.0
+info:
+  title: test
+  version: "1"
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: integer
+          format: int64
+          description: Pet ID
+        name:
+          type: string
+        owner:
+          $ref: '#/components/schemas/Owner'
+    Owner:
+      type: object
+      properties:
+        email:
+          type: string
+`)
+	table := &schema.Table{Name: "test_openapi"}
+	require.NoError(t, TransformWithOpenAPIComponent(spec, "Pet", WithUnwrapAllStructFields())(table))
+	assert.Equal(t, []string{"id", "name", "owner_email"}, table.Columns.Names())
+	assert.Equal(t, "Pet ID", table.Column("id").Description)
+	assert.True(t, table.Column("id").NotNull)
+
+	swagger := []byte(`{"swagger": "2.0", "definitions": {"Pet": {"properties": {"id": {"type": "integer"}}}}}`)
+	table = &schema.Table{Name: "test_swagger"}
+	require.NoError(t, TransformWithOpenAPIComponent(swagger, "Pet")(table))
+	assert.Equal(t, []string{"id"}, table.Columns.Names())
+}
+
+// The columns match the columns of the equivalent Go struct
+func TestTransformWithJSONSchemaMatchesStruct(t *testing.T) {
+	type location struct {
+		Region string `json:"region"`
+		Zone   string `json:"zone"`
+	}
+	type instance struct {
+		ID        uuid.UUID         `json:"id"`
+		Name      string            `json:"name"`
+		CreatedAt time.Time         `json:"createdAt"`
+		CPUCount  int32             `json:"cpuCount"`
+		Load      *float32          `json:"load"`
+		Enabled   bool              `json:"enabled"`
+		IP        net.IP            `json:"ip"`
+		UserData  []byte            `json:"userData"`
+		Tags      []string          `json:"tags"`
+		Labels    map[string]string `json:"labels"`
+		Location  location          `json:"location"`
+	}
+	opts := []StructTransformerOption{WithArrowStructs(2), WithArrowMaps(), WithExactNumericTypes(), WithSkipFields("disks", "parent", "value")}
+
+	fromSchema := &schema.Table{Name: "test"}
+	require.NoError(t, TransformWithJSONSchema([]byte(testJSONSchema), opts...)(fromSchema))
+	fromStruct := &schema.Table{Name: "test"}
+	require.NoError(t, TransformWithStruct(&instance{}, append(opts, WithTypeTransformer(func(f reflect.StructField) (arrow.DataType, error) {
+		if f.Type == reflect.TypeOf(uuid.UUID{}) {
+			return types.ExtensionTypes.UUID, nil
+		}
+		return nil, nil
+	}))...)(fromStruct))
+
+	require.Equal(t, fromStruct.Columns.Names(), fromSchema.Columns.Names())
+	for i, c := range fromSchema.Columns {
+		assert.Truef(t, arrow.TypeEqual(fromStruct.Columns[i].Type, c.Type), "column %s: expected type %s, got %s", c.Name, fromStruct.Columns[i].Type, c.Type)
+	}
+}
+
+func TestTransformWithJSONSchemaErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		document string
+		opts     []StructTransformerOption
+		errMsg   string
+	}{
+		{name: "invalid document", document: `{`, errMsg: "failed to parse schema"},
+		{name: "not an object", document: `{"type": "string"}`, errMsg: `expected object schema, got "string"`},
+		{name: "remote reference", document: `{"properties": {"a": {"$ref": "other.json#/a"}}}`, errMsg: "only local references are supported"},
+		{name: "missing reference", document: `{"properties": {"a": {"$ref": "#/$defs/missing"}}}`, errMsg: `reference "#/$defs/missing" not found`},
+		{name: "missing primary key", document: `{"properties": {"a": {"type": "string"}}}`, opts: []StructTransformerOption{WithPrimaryKeys("b")}, errMsg: "failed to create all of the desired primary keys: [b]"},
+		{name: "invalid column name", document: `{"properties": {"@odata.type": {"type": "string"}}}`, errMsg: "failed to add column for property @odata.type"},
+		{name: "collision", document: `{"properties": {"fooBar": {"type": "string"}, "foo_bar": {"type": "string"}}}`, errMsg: `column name "foo_bar" collides with an existing column`},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			table := &schema.Table{Name: "test_json_schema"}
+			err := TransformWithJSONSchema([]byte(tc.document), tc.opts...)(table)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+
+	table := &schema.Table{Name: "test_openapi"}
+	err := TransformWithOpenAPIComponent([]byte(`{"openapi": "3.0.0"}`), "Missing")(table)
+	assert.EqualError(t, err, `schema "Missing" not found in components/schemas or definitions`)
+}
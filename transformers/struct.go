@@ -1,7 +1,9 @@
 package transformers
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"net"
 	"reflect"
 	"strings"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/cloudquery/plugin-sdk/v4/caser"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
 	"github.com/cloudquery/plugin-sdk/v4/schema"
 	"github.com/cloudquery/plugin-sdk/v4/types"
 	"github.com/thoas/go-funk"
@@ -23,9 +26,19 @@ type structTransformer struct {
 	resolverTransformer           ResolverTransformer
 	ignoreInTestsTransformer      IgnoreInTestsTransformer
 	unwrapAllEmbeddedStructFields bool
+	unwrapAllStructFields         bool
 	structFieldsToUnwrap          []string
+	structFieldsToKeep            []string
+	maxUnwrapDepth                int
+	columnPaths                   map[string]string
 	pkFields                      []string
 	pkFieldsFound                 []string
+	arrowMaps                     bool
+	arrowStructsMaxDepth          int
+	exactNumericTypes             bool
+	descriptions                  map[string]string
+	descriptionSources            []string
+	sourceDescriptions            map[string]string
 }
 
 type NameTransformer func(reflect.StructField) (string, error)
@@ -57,20 +70,49 @@ func isFieldStruct(reflectType reflect.Type) bool {
 	}
 }
 
-// WithUnwrapAllEmbeddedStructs instructs codegen to unwrap all embedded fields (1 level deep only)
+// WithUnwrapAllEmbeddedStructs instructs codegen to unwrap all embedded fields (1 level deep, unless WithMaxUnwrapDepth is set)
 func WithUnwrapAllEmbeddedStructs() StructTransformerOption {
 	return func(t *structTransformer) {
 		t.unwrapAllEmbeddedStructFields = true
 	}
 }
 
-// WithUnwrapStructFields allows to unwrap specific struct fields (1 level deep only)
+// WithUnwrapAllStructFields instructs codegen to unwrap all struct fields, embedded or not,
+// that would otherwise be added as JSON (or arrow struct) columns (1 level deep, unless WithMaxUnwrapDepth is set).
+// Use WithoutUnwrapStructFields to keep specific struct fields as JSON columns.
+func WithUnwrapAllStructFields() StructTransformerOption {
+	return func(t *structTransformer) {
+		t.unwrapAllStructFields = true
+	}
+}
+
+// WithUnwrapStructFields allows to unwrap specific struct fields.
+// Nested fields are specified by their path (e.g. "Parent.Child") and require WithMaxUnwrapDepth.
 func WithUnwrapStructFields(fields ...string) StructTransformerOption {
 	return func(t *structTransformer) {
 		t.structFieldsToUnwrap = fields
 	}
 }
 
+// WithoutUnwrapStructFields prevents specific struct fields from being unwrapped by the other unwrap options,
+// so they are added as JSON columns. Nested fields are specified by their path (e.g. "Parent.Child").
+func WithoutUnwrapStructFields(fields ...string) StructTransformerOption {
+	return func(t *structTransformer) {
+		t.structFieldsToKeep = fields
+	}
+}
+
+// WithMaxUnwrapDepth sets how many levels of nested struct fields can be unwrapped (default 1).
+// The columns of unwrapped fields are named after all of their non-embedded parents, e.g. parent_child_field,
+// and resolved with the matching path, e.g. Parent.Child.Field.
+// Struct fields deeper than depth are added as JSON columns.
+// At any depth, unwrapped fields resulting in the same column name as another field fail the transformation.
+func WithMaxUnwrapDepth(depth int) StructTransformerOption {
+	return func(t *structTransformer) {
+		t.maxUnwrapDepth = depth
+	}
+}
+
 // WithSkipFields allows to specify what struct fields should be skipped.
 func WithSkipFields(fields ...string) StructTransformerOption {
 	return func(t *structTransformer) {
@@ -110,6 +152,57 @@ func WithIgnoreInTestsTransformer(transformer IgnoreInTestsTransformer) StructTr
 	}
 }
 
+// WithArrowMaps instructs the default type transformer to map Go maps to arrow maps instead of JSON.
+// Maps with keys or values that can't be represented as arrow types are still mapped to JSON.
+func WithArrowMaps() StructTransformerOption {
+	return func(t *structTransformer) {
+		t.arrowMaps = true
+	}
+}
+
+// WithArrowStructs instructs the default type transformer to map Go structs to arrow structs instead of JSON,
+// and slices of structs to lists of arrow structs, so destinations that support nested data keep the types.
+// The arrow struct fields are named after the JSON fields of the Go struct, as scalar.Struct uses the JSON encoding of the value.
+// Structs nested more than maxDepth levels deep, recursive types and types implementing json.Marshaler are still mapped to JSON.
+func WithArrowStructs(maxDepth int) StructTransformerOption {
+	return func(t *structTransformer) {
+		t.arrowStructsMaxDepth = maxDepth
+	}
+}
+
+// WithExactNumericTypes instructs the default type transformer to keep the width and signedness of Go numeric types
+// (e.g. uint64 is mapped to Uint64 and float32 to Float32, instead of Int64 and Float64),
+// to map time.Duration to a nanosecond duration, big.Int to Decimal256 (precision 76, scale 0),
+// and big.Float and big.Rat to Decimal256 (precision 76, scale 38), rounding values with more fractional digits.
+// Other decimal types (e.g. from third-party packages) aren't covered, and can be mapped with WithTypeTransformer.
+func WithExactNumericTypes() StructTransformerOption {
+	return func(t *structTransformer) {
+		t.exactNumericTypes = true
+	}
+}
+
+// WithDescriptions sets the descriptions of the columns created from struct fields, keyed by "TypeName.FieldName"
+// (e.g. generated with DescriptionsFromSource). The description tag of a field takes precedence.
+func WithDescriptions(descriptions map[string]string) StructTransformerOption {
+	return func(t *structTransformer) {
+		if t.descriptions == nil {
+			t.descriptions = make(map[string]string, len(descriptions))
+		}
+		for k, v := range descriptions {
+			t.descriptions[k] = v
+		}
+	}
+}
+
+// WithDescriptionsFromSource sets the descriptions of the columns to the doc comments of the struct fields,
+// parsed from the Go files (or directories) at paths when the transform is run (see DescriptionsFromSource).
+// Descriptions set with WithDescriptions and the description tag take precedence.
+func WithDescriptionsFromSource(paths ...string) StructTransformerOption {
+	return func(t *structTransformer) {
+		t.descriptionSources = append(t.descriptionSources, paths...)
+	}
+}
+
 // WithPrimaryKeys allows to specify what struct fields should be used as primary keys
 func WithPrimaryKeys(fields ...string) StructTransformerOption {
 	return func(t *structTransformer) {
@@ -117,44 +210,59 @@ func WithPrimaryKeys(fields ...string) StructTransformerOption {
 	}
 }
 
-func TransformWithStruct(st any, opts ...StructTransformerOption) schema.Transform {
+func newStructTransformer(opts ...StructTransformerOption) *structTransformer {
 	t := &structTransformer{
 		nameTransformer:          DefaultNameTransformer,
-		typeTransformer:          DefaultTypeTransformer,
 		resolverTransformer:      DefaultResolverTransformer,
 		ignoreInTestsTransformer: DefaultIgnoreInTestsTransformer,
+		maxUnwrapDepth:           1,
 	}
 	for _, opt := range opts {
 		opt(t)
 	}
+	if t.typeTransformer == nil {
+		t.typeTransformer = t.defaultTypeTransformer
+	}
+	return t
+}
+
+// structType returns the struct type of st, which can be a struct, a pointer to a struct or a slice of structs
+func structType(st any) (reflect.Type, error) {
+	e := reflect.ValueOf(st)
+	if e.Kind() == reflect.Pointer {
+		e = e.Elem()
+	}
+	if e.Kind() == reflect.Slice {
+		e = reflect.MakeSlice(e.Type(), 1, 1).Index(0)
+	}
+	if e.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct, got %s", e.Kind())
+	}
+	return e.Type(), nil
+}
+
+func TransformWithStruct(st any, opts ...StructTransformerOption) schema.Transform {
+	t := newStructTransformer(opts...)
 
 	return func(table *schema.Table) error {
 		t.table = table
-		e := reflect.ValueOf(st)
-		if e.Kind() == reflect.Pointer {
-			e = e.Elem()
-		}
-		if e.Kind() == reflect.Slice {
-			e = reflect.MakeSlice(e.Type(), 1, 1).Index(0)
-		}
-		if e.Kind() != reflect.Struct {
-			return fmt.Errorf("expected struct, got %s", e.Kind())
-		}
-		eType := e.Type()
-		for i := 0; i < e.NumField(); i++ {
-			field := eType.Field(i)
-
-			switch {
-			case t.shouldUnwrapField(field):
-				if err := t.unwrapField(field); err != nil {
-					return err
-				}
-			default:
-				if err := t.addColumnFromField(field, nil); err != nil {
-					return fmt.Errorf("failed to add column for field %s: %w", field.Name, err)
-				}
+		eType, err := structType(st)
+		if err != nil {
+			return err
+		}
+		if len(t.descriptionSources) > 0 && t.sourceDescriptions == nil {
+			t.sourceDescriptions, err = DescriptionsFromSource(t.descriptionSources...)
+			if err != nil {
+				return fmt.Errorf("failed to parse descriptions: %w", err)
 			}
 		}
+		t.columnPaths = make(map[string]string)
+		err = t.walkFields(eType, func(field reflect.StructField, parents []reflect.StructField, index []int) error {
+			return t.addColumnFromField(field, parents, ownerType(eType, index))
+		})
+		if err != nil {
+			return err
+		}
 		// Validate that all expected PK fields were found
 		if diff := funk.SubtractString(t.pkFields, t.pkFieldsFound); len(diff) > 0 {
 			return fmt.Errorf("failed to create all of the desired primary keys: %v", diff)
@@ -163,51 +271,89 @@ func TransformWithStruct(st any, opts ...StructTransformerOption) schema.Transfo
 	}
 }
 
-func (t *structTransformer) getUnwrappedFields(field reflect.StructField) []reflect.StructField {
-	reflectType := field.Type
-	if reflectType.Kind() == reflect.Ptr {
-		reflectType = reflectType.Elem()
-	}
+// walkFunc is called by walkFields for every field that can become a column.
+// parents are the non-embedded struct fields the field was unwrapped from (outermost first),
+// and index is the field index sequence (see reflect.Value.FieldByIndex).
+type walkFunc func(field reflect.StructField, parents []reflect.StructField, index []int) error
 
-	fields := make([]reflect.StructField, 0)
-	for i := 0; i < reflectType.NumField(); i++ {
-		sf := reflectType.Field(i)
-		if t.ignoreField(sf) {
+// walkFields calls fn for every field of the struct type that can become a column, in column order.
+// Struct fields are unwrapped recursively, up to the maximum unwrap depth.
+func (t *structTransformer) walkFields(eType reflect.Type, fn walkFunc) error {
+	return t.walkStruct(eType, nil, nil, fn)
+}
+
+func (t *structTransformer) walkStruct(eType reflect.Type, parents []reflect.StructField, index []int, fn walkFunc) error {
+	depth := len(index)
+	for i := 0; i < eType.NumField(); i++ {
+		field := eType.Field(i)
+		if depth > 0 && t.ignoreField(field) {
 			continue
 		}
+		wrapErr := func(err error) error {
+			if depth == 0 {
+				return fmt.Errorf("failed to add column for field %s: %w", field.Name, err)
+			}
+			return fmt.Errorf("failed to add column from field %s: %w", field.Name, err)
+		}
 
-		fields = append(fields, sf)
-	}
-	return fields
-}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		unwrap, err := t.shouldUnwrapField(field, fieldPath(field, parents), depth)
+		if err != nil {
+			return wrapErr(err)
+		}
+		if !unwrap {
+			if err := fn(field, parents, fieldIndex); err != nil {
+				return wrapErr(err)
+			}
+			continue
+		}
 
-func (t *structTransformer) unwrapField(field reflect.StructField) error {
-	unwrappedFields := t.getUnwrappedFields(field)
-	var parent *reflect.StructField
-	// For non embedded structs we need to add the parent field name to the path
-	if !field.Anonymous {
-		parent = &field
-	}
-	for _, f := range unwrappedFields {
-		if err := t.addColumnFromField(f, parent); err != nil {
-			return fmt.Errorf("failed to add column from field %s: %w", f.Name, err)
+		fieldParents := parents
+		// For non embedded structs we need to add the parent field name to the path
+		if !field.Anonymous {
+			fieldParents = append(parents[:len(parents):len(parents)], field)
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if err := t.walkStruct(fieldType, fieldParents, fieldIndex, fn); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func (t *structTransformer) shouldUnwrapField(field reflect.StructField) bool {
+// shouldUnwrapField reports whether the fields of the struct field should be added as columns instead of the field itself.
+// depth is the number of structs the field was unwrapped from.
+func (t *structTransformer) shouldUnwrapField(field reflect.StructField, path string, depth int) (bool, error) {
+	tag, err := parseFieldTag(field)
+	if err != nil {
+		return false, err
+	}
 	switch {
-	case !isFieldStruct(field.Type):
-		return false
-	case slices.Contains(t.structFieldsToUnwrap, field.Name):
-		return true
-	case !field.Anonymous:
-		return false
-	case t.unwrapAllEmbeddedStructFields:
-		return true
+	case !isFieldStruct(field.Type),
+		tag.skip,
+		slices.Contains(t.structFieldsToKeep, path):
+		return false, nil
+	case tag.unwrap && depth >= t.maxUnwrapDepth:
+		return false, fmt.Errorf("unwrap exceeds the maximum unwrap depth of %d (see WithMaxUnwrapDepth)", t.maxUnwrapDepth)
+	case depth >= t.maxUnwrapDepth:
+		return false, nil
+	case tag.unwrap,
+		slices.Contains(t.structFieldsToUnwrap, path):
+		return true, nil
+	case field.Anonymous && t.unwrapAllEmbeddedStructFields:
+		return true, nil
+	case t.unwrapAllStructFields:
+		// only unwrap structs that would otherwise be added as JSON or struct columns (e.g. not time.Time)
+		columnType, err := t.columnType(field, tag)
+		if err != nil || columnType == nil {
+			return false, err
+		}
+		return arrow.TypeEqual(columnType, types.ExtensionTypes.JSON) || columnType.ID() == arrow.STRUCT, nil
 	default:
-		return false
+		return false, nil
 	}
 }
 
@@ -215,6 +361,7 @@ func (t *structTransformer) ignoreField(field reflect.StructField) bool {
 	switch {
 	case len(field.Name) == 0,
 		slices.Contains(t.skipFields, field.Name),
+		fieldTagOrZero(field).skip,
 		!field.IsExported(),
 		isTypeIgnored(field.Type):
 		return true
@@ -223,46 +370,60 @@ func (t *structTransformer) ignoreField(field reflect.StructField) bool {
 	}
 }
 
-func (t *structTransformer) addColumnFromField(field reflect.StructField, parent *reflect.StructField) error {
+// columnType returns the type set in the cq tag, falling back to the type transformers.
+// A nil type means the field should be ignored.
+func (t *structTransformer) columnType(field reflect.StructField, tag fieldTag) (arrow.DataType, error) {
+	if tag.typ != nil {
+		return tag.typ, nil
+	}
+	columnType, err := t.typeTransformer(field)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform type for field %s: %w", field.Name, err)
+	}
+	if columnType != nil {
+		return columnType, nil
+	}
+	columnType, err = t.defaultTypeTransformer(field)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform type for field %s: %w", field.Name, err)
+	}
+	return columnType, nil
+}
+
+func (t *structTransformer) addColumnFromField(field reflect.StructField, parents []reflect.StructField, owner reflect.Type) error {
 	if t.ignoreField(field) {
 		return nil
 	}
-
-	columnType, err := t.typeTransformer(field)
+	tag, err := parseFieldTag(field)
 	if err != nil {
-		return fmt.Errorf("failed to transform type for field %s: %w", field.Name, err)
+		return err
 	}
 
-	if columnType == nil {
-		columnType, err = DefaultTypeTransformer(field)
-		if err != nil {
-			return fmt.Errorf("failed to transform type for field %s: %w", field.Name, err)
-		}
+	columnType, err := t.columnType(field, tag)
+	if err != nil {
+		return err
 	}
 	if columnType == nil {
 		return nil // ignored
 	}
 
-	path := field.Name
-	name, err := t.nameTransformer(field)
+	name, path, err := t.columnName(field, parents)
 	if err != nil {
-		return fmt.Errorf("failed to transform field name for field %s: %w", field.Name, err)
+		return err
 	}
 	// skip field if there is no name
 	if name == "" {
 		return nil
 	}
-	if parent != nil {
-		parentName, err := t.nameTransformer(*parent)
-		if err != nil {
-			return fmt.Errorf("failed to transform field name for parent field %s: %w", parent.Name, err)
-		}
-		name = parentName + "_" + name
-		path = parent.Name + `.` + path
+	// Fields promoted from embedded structs share the path of the field that shadows them and are skipped.
+	// Different fields resulting in the same column name are an error, as one of them would be silently dropped.
+	if other, ok := t.columnPaths[name]; ok && other != path {
+		return fmt.Errorf("column name %q of field %s collides with the column of field %s", name, path, other)
 	}
 	if t.table.Columns.Get(name) != nil {
 		return nil
 	}
+	t.columnPaths[name] = path
 
 	resolver := t.resolverTransformer(field, path)
 	if resolver == nil {
@@ -270,10 +431,15 @@ func (t *structTransformer) addColumnFromField(field reflect.StructField, parent
 	}
 
 	column := schema.Column{
-		Name:          name,
-		Type:          columnType,
-		Resolver:      resolver,
-		IgnoreInTests: t.ignoreInTestsTransformer(field),
+		Name:           name,
+		Type:           columnType,
+		Description:    t.fieldDescription(field, owner),
+		Resolver:       resolver,
+		IgnoreInTests:  t.ignoreInTestsTransformer(field),
+		PrimaryKey:     tag.pk,
+		NotNull:        tag.notNull,
+		Unique:         tag.unique,
+		IncrementalKey: tag.incremental,
 	}
 
 	for _, pk := range t.pkFields {
@@ -291,6 +457,44 @@ func (t *structTransformer) addColumnFromField(field reflect.StructField, parent
 	return nil
 }
 
+// fieldPath returns the resolver path of the field, e.g. Parent.Child.Field.
+// Embedded structs aren't part of the path, as their fields are promoted.
+func fieldPath(field reflect.StructField, parents []reflect.StructField) string {
+	path := field.Name
+	for i := len(parents) - 1; i >= 0; i-- {
+		path = parents[i].Name + "." + path
+	}
+	return path
+}
+
+// columnName returns the column name (e.g. parent_child_field) and resolver path for the field.
+// An empty name means the field should be skipped.
+func (t *structTransformer) columnName(field reflect.StructField, parents []reflect.StructField) (name string, path string, err error) {
+	name, err = t.fieldName(field)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to transform field name for field %s: %w", field.Name, err)
+	}
+	if name == "" {
+		return "", "", nil
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		parentName, err := t.fieldName(parents[i])
+		if err != nil {
+			return "", "", fmt.Errorf("failed to transform field name for parent field %s: %w", parents[i].Name, err)
+		}
+		name = parentName + "_" + name
+	}
+	return name, fieldPath(field, parents), nil
+}
+
+// fieldName returns the name set in the cq tag, falling back to the name transformer
+func (t *structTransformer) fieldName(field reflect.StructField) (string, error) {
+	if name := fieldTagOrZero(field).name; name != "" {
+		return name, nil
+	}
+	return t.nameTransformer(field)
+}
+
 func isTypeIgnored(t reflect.Type) bool {
 	switch t.Kind() {
 	case reflect.Func,
@@ -306,16 +510,50 @@ func DefaultTypeTransformer(v reflect.StructField) (arrow.DataType, error) {
 	return defaultGoTypeToSchemaType(v.Type)
 }
 
+func (t *structTransformer) defaultTypeTransformer(v reflect.StructField) (arrow.DataType, error) {
+	return goTypeToSchemaType(v.Type, goTypeOptions{
+		arrowMaps:         t.arrowMaps,
+		maxStructDepth:    t.arrowStructsMaxDepth,
+		exactNumericTypes: t.exactNumericTypes,
+	})
+}
+
+type goTypeOptions struct {
+	// arrowMaps maps Go maps to arrow maps instead of JSON
+	arrowMaps bool
+	// maxStructDepth maps Go structs nested up to maxStructDepth levels deep to arrow structs instead of JSON
+	maxStructDepth int
+	// structs are the struct types being mapped, outermost first
+	structs []reflect.Type
+	// exactNumericTypes keeps the width and signedness of numeric types
+	exactNumericTypes bool
+}
+
 func defaultGoTypeToSchemaType(v reflect.Type) (arrow.DataType, error) {
+	return goTypeToSchemaType(v, goTypeOptions{})
+}
+
+func goTypeToSchemaType(v reflect.Type, opts goTypeOptions) (arrow.DataType, error) {
+	// plugin-defined extension types
+	if ext, ok := scalar.ExtensionForGoType(v); ok {
+		return ext.Type, nil
+	}
+
 	// Non primitive types
 	if v == reflect.TypeOf(net.IP{}) {
 		return types.ExtensionTypes.Inet, nil
 	}
 
+	if opts.exactNumericTypes {
+		if dt := exactNumericType(v); dt != nil {
+			return dt, nil
+		}
+	}
+
 	k := v.Kind()
 	switch k {
 	case reflect.Pointer:
-		return defaultGoTypeToSchemaType(v.Elem())
+		return goTypeToSchemaType(v.Elem(), opts)
 	case reflect.String:
 		return arrow.BinaryTypes.String, nil
 	case reflect.Bool:
@@ -326,12 +564,15 @@ func defaultGoTypeToSchemaType(v reflect.Type) (arrow.DataType, error) {
 	case reflect.Float32, reflect.Float64:
 		return arrow.PrimitiveTypes.Float64, nil
 	case reflect.Map:
-		return types.ExtensionTypes.JSON, nil
+		if !opts.arrowMaps {
+			return types.ExtensionTypes.JSON, nil
+		}
+		return goMapToSchemaType(v, opts)
 	case reflect.Struct:
 		if v == reflect.TypeOf(time.Time{}) {
 			return arrow.FixedWidthTypes.Timestamp_us, nil
 		}
-		return types.ExtensionTypes.JSON, nil
+		return goStructToSchemaType(v, opts)
 	case reflect.Slice:
 		switch v.Elem().Kind() {
 		case reflect.Uint8:
@@ -340,7 +581,7 @@ func defaultGoTypeToSchemaType(v reflect.Type) (arrow.DataType, error) {
 			return types.ExtensionTypes.JSON, nil
 		}
 
-		elemValueType, err := defaultGoTypeToSchemaType(v.Elem())
+		elemValueType, err := goTypeToSchemaType(v.Elem(), opts)
 		if err != nil {
 			return nil, err
 		}
@@ -358,6 +599,154 @@ func defaultGoTypeToSchemaType(v reflect.Type) (arrow.DataType, error) {
 	}
 }
 
+func goMapToSchemaType(v reflect.Type, opts goTypeOptions) (arrow.DataType, error) {
+	keyType, err := goTypeToSchemaType(v.Key(), opts)
+	if err != nil {
+		return nil, err
+	}
+	// arrow map keys have to be non-null and comparable, so only allow primitive keys
+	if keyType == nil || arrow.IsNested(keyType.ID()) || keyType.ID() == arrow.EXTENSION {
+		return types.ExtensionTypes.JSON, nil
+	}
+	valueType, err := goTypeToSchemaType(v.Elem(), opts)
+	if err != nil {
+		return nil, err
+	}
+	if valueType == nil {
+		return types.ExtensionTypes.JSON, nil
+	}
+	return arrow.MapOf(keyType, valueType), nil
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+// exactNumericType returns the arrow type matching the numeric type exactly, or nil for non numeric types
+func exactNumericType(v reflect.Type) arrow.DataType {
+	switch v {
+	case durationType:
+		return arrow.FixedWidthTypes.Duration_ns
+	case bigIntType:
+		return &arrow.Decimal256Type{Precision: 76, Scale: 0}
+	case bigFloatType, bigRatType:
+		return &arrow.Decimal256Type{Precision: 76, Scale: 38}
+	}
+	switch v.Kind() {
+	case reflect.Int8:
+		return arrow.PrimitiveTypes.Int8
+	case reflect.Int16:
+		return arrow.PrimitiveTypes.Int16
+	case reflect.Int32:
+		return arrow.PrimitiveTypes.Int32
+	case reflect.Int, reflect.Int64:
+		return arrow.PrimitiveTypes.Int64
+	case reflect.Uint8:
+		return arrow.PrimitiveTypes.Uint8
+	case reflect.Uint16:
+		return arrow.PrimitiveTypes.Uint16
+	case reflect.Uint32:
+		return arrow.PrimitiveTypes.Uint32
+	case reflect.Uint, reflect.Uint64:
+		return arrow.PrimitiveTypes.Uint64
+	case reflect.Float32:
+		return arrow.PrimitiveTypes.Float32
+	case reflect.Float64:
+		return arrow.PrimitiveTypes.Float64
+	default:
+		return nil
+	}
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+func goStructToSchemaType(v reflect.Type, opts goTypeOptions) (arrow.DataType, error) {
+	for _, parent := range opts.structs {
+		if parent == v {
+			return types.ExtensionTypes.JSON, nil // recursive type
+		}
+	}
+	switch {
+	case len(opts.structs) >= opts.maxStructDepth,
+		v.Implements(jsonMarshalerType),
+		reflect.PointerTo(v).Implements(jsonMarshalerType):
+		return types.ExtensionTypes.JSON, nil
+	}
+	opts.structs = append(opts.structs[:len(opts.structs):len(opts.structs)], v)
+
+	fields := jsonStructFields(v)
+	arrowFields := make([]arrow.Field, 0, len(fields))
+	for _, f := range fields {
+		dt, err := goTypeToSchemaType(f.Type, opts)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		// interfaces can hold any value
+		if dt == nil {
+			dt = types.ExtensionTypes.JSON
+		}
+		arrowFields = append(arrowFields, arrow.Field{Name: jsonFieldName(f), Type: dt, Nullable: true})
+	}
+	if len(arrowFields) == 0 {
+		return types.ExtensionTypes.JSON, nil
+	}
+	return arrow.StructOf(arrowFields...), nil
+}
+
+// jsonStructFields returns the fields encoding/json encodes for the struct type, in order.
+// Fields of embedded structs are promoted, unless they are shadowed by a shallower field with the same JSON name.
+func jsonStructFields(v reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	byName := make(map[string]int)
+	var opaque [][]int // fields whose promoted fields aren't encoded
+	for _, f := range reflect.VisibleFields(v) {
+		if slices.ContainsFunc(opaque, func(index []int) bool {
+			return len(f.Index) > len(index) && slices.Equal(f.Index[:len(index)], index)
+		}) {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			opaque = append(opaque, f.Index)
+			continue
+		}
+		if f.Anonymous {
+			t := f.Type
+			if t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			if name, _, _ := strings.Cut(tag, ","); t.Kind() == reflect.Struct && name == "" {
+				continue // promoted fields follow
+			}
+		}
+		opaque = append(opaque, f.Index)
+		if !f.IsExported() || isTypeIgnored(f.Type) {
+			continue
+		}
+		name := jsonFieldName(f)
+		if i, ok := byName[name]; ok {
+			if len(fields[i].Index) > len(f.Index) {
+				fields[i] = f
+			}
+			continue
+		}
+		byName[name] = len(fields)
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// jsonFieldName returns the name encoding/json uses for the field
+func jsonFieldName(f reflect.StructField) string {
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return f.Name
+}
+
 var defaultCaser = caser.New()
 
 func DefaultNameTransformer(field reflect.StructField) (string, error) {
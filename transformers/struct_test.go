@@ -1,15 +1,22 @@
 package transformers
 
 import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
 	"net"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
 	"github.com/cloudquery/plugin-sdk/v4/schema"
 	"github.com/cloudquery/plugin-sdk/v4/types"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/exp/slices"
 )
 
@@ -76,6 +83,17 @@ type (
 		AFieldWithCamelCaseName string `json:"camelCaseName"`
 	}
 
+	testMapStruct struct {
+		StringMapCol      map[string]string          `json:"string_map_col"`
+		IntMapCol         map[int]float64            `json:"int_map_col"`
+		ListMapCol        map[string][]int           `json:"list_map_col"`
+		NestedMapCol      map[string]map[string]bool `json:"nested_map_col"`
+		PointerMapCol     *map[string]int            `json:"pointer_map_col"`
+		AnyMapCol         map[string]any             `json:"any_map_col"`
+		StructKeyMapCol   map[embeddedStruct]string  `json:"struct_key_map_col"`
+		StructValueMapCol map[string]embeddedStruct  `json:"struct_value_map_col"`
+	}
+
 	testStructWithAny struct {
 		IntCol     int `json:"int_col"`
 		Properties any
@@ -301,6 +319,35 @@ var (
 	}
 )
 
+var (
+	expectedTestTableMapStruct = schema.Table{
+		Name: "test_map_struct",
+		Columns: schema.ColumnList{
+			{Name: "string_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "int_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "list_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "nested_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "pointer_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "any_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "struct_key_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "struct_value_map_col", Type: types.ExtensionTypes.JSON},
+		},
+	}
+	expectedTestTableMapStructWithArrowMaps = schema.Table{
+		Name: "test_map_struct_with_arrow_maps",
+		Columns: schema.ColumnList{
+			{Name: "string_map_col", Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.BinaryTypes.String)},
+			{Name: "int_map_col", Type: arrow.MapOf(arrow.PrimitiveTypes.Int64, arrow.PrimitiveTypes.Float64)},
+			{Name: "list_map_col", Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.ListOf(arrow.PrimitiveTypes.Int64))},
+			{Name: "nested_map_col", Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.MapOf(arrow.BinaryTypes.String, arrow.FixedWidthTypes.Boolean))},
+			{Name: "pointer_map_col", Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Int64)},
+			{Name: "any_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "struct_key_map_col", Type: types.ExtensionTypes.JSON},
+			{Name: "struct_value_map_col", Type: arrow.MapOf(arrow.BinaryTypes.String, types.ExtensionTypes.JSON)},
+		},
+	}
+)
+
 func TestTableFromGoStruct(t *testing.T) {
 	type args struct {
 		testStruct any
@@ -441,6 +488,36 @@ func TestTableFromGoStruct(t *testing.T) {
 			},
 			want: expectedTestTableStructWithCustomAny,
 		},
+		{
+			name: "Should map Go maps to JSON by default",
+			args: args{
+				testStruct: testMapStruct{},
+			},
+			want: expectedTestTableMapStruct,
+		},
+		{
+			name: "Should map Go maps to arrow maps when option is set",
+			args: args{
+				testStruct: testMapStruct{},
+				options: []StructTransformerOption{
+					WithArrowMaps(),
+				},
+			},
+			want: expectedTestTableMapStructWithArrowMaps,
+		},
+		{
+			name: "Should map Go maps to arrow maps when a custom type transformer falls back to the default",
+			args: args{
+				testStruct: testMapStruct{},
+				options: []StructTransformerOption{
+					WithArrowMaps(),
+					WithTypeTransformer(func(reflect.StructField) (arrow.DataType, error) {
+						return nil, nil
+					}),
+				},
+			},
+			want: expectedTestTableMapStructWithArrowMaps,
+		},
 	}
 
 	for _, tt := range tests {
@@ -480,3 +557,301 @@ func TestTableFromGoStruct(t *testing.T) {
 		})
 	}
 }
+
+type arn string
+
+type arnArray struct {
+	array.ExtensionArrayBase
+}
+
+type arnType struct {
+	arrow.ExtensionBase
+}
+
+func (*arnType) ArrayType() reflect.Type { return reflect.TypeOf(arnArray{}) }
+
+func (*arnType) ExtensionName() string { return "test.arn" }
+
+func (*arnType) Serialize() string { return "test.arn" }
+
+func (*arnType) Deserialize(storage arrow.DataType, _ string) (arrow.ExtensionType, error) {
+	return &arnType{ExtensionBase: arrow.ExtensionBase{Storage: storage}}, nil
+}
+
+func (e *arnType) ExtensionEquals(other arrow.ExtensionType) bool {
+	return e.ExtensionName() == other.ExtensionName()
+}
+
+type arnScalar struct {
+	scalar.Binary
+}
+
+func (*arnScalar) DataType() arrow.DataType {
+	return &arnType{ExtensionBase: arrow.ExtensionBase{Storage: arrow.BinaryTypes.Binary}}
+}
+
+func TestTableFromGoStructWithExtension(t *testing.T) {
+	dt := &arnType{ExtensionBase: arrow.ExtensionBase{Storage: arrow.BinaryTypes.Binary}}
+	if err := scalar.RegisterExtension(scalar.Extension{
+		Type:   dt,
+		New:    func() scalar.Scalar { return &arnScalar{} },
+		GoType: reflect.TypeOf(arn("")),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := scalar.UnregisterExtension(dt.ExtensionName()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	table := schema.Table{Name: "test"}
+	err := TransformWithStruct(struct {
+		ARN        arn
+		ARNPointer *arn
+		ARNList    []arn
+	}{})(&table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]arrow.DataType{
+		"arn":         dt,
+		"arn_pointer": dt,
+		"arn_list":    arrow.ListOf(dt),
+	} {
+		if c := table.Column(name); c == nil || !arrow.TypeEqual(c.Type, want) {
+			t.Fatalf("column %q: expected type %v, got %v", name, want, c)
+		}
+	}
+}
+
+type (
+	arrowStructAddress struct {
+		City string `json:"city"`
+		Zip  *int   `json:"zip,omitempty"`
+	}
+	ArrowStructEmbedded struct {
+		Embedded string `json:"embedded"`
+	}
+	arrowStructNode struct {
+		Name     string            `json:"name"`
+		Children []arrowStructNode `json:"children"`
+	}
+	arrowStructCustomJSON struct {
+		Value string
+	}
+	testArrowStruct struct {
+		ID        int                   `json:"id"`
+		Address   arrowStructAddress    `json:"address"`
+		Addresses []*arrowStructAddress `json:"addresses"`
+		Node      arrowStructNode       `json:"node"`
+		Custom    arrowStructCustomJSON `json:"custom"`
+		Nested    struct {
+			Address arrowStructAddress `json:"address"`
+			Time    time.Time          `json:"time"`
+			Any     any                `json:"any"`
+			ArrowStructEmbedded
+			Skipped string `json:"-"`
+			hidden  string
+		} `json:"nested"`
+	}
+)
+
+func (c arrowStructCustomJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Value)
+}
+
+func (c *arrowStructCustomJSON) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &c.Value)
+}
+
+func TestTableFromGoStructWithArrowStructs(t *testing.T) {
+	addressType := arrow.StructOf(
+		arrow.Field{Name: "city", Type: arrow.BinaryTypes.String, Nullable: true},
+		arrow.Field{Name: "zip", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	)
+	for _, tc := range []struct {
+		maxDepth int
+		want     map[string]arrow.DataType
+	}{
+		{
+			maxDepth: 0,
+			want: map[string]arrow.DataType{
+				"address":   types.ExtensionTypes.JSON,
+				"addresses": types.ExtensionTypes.JSON,
+				"nested":    types.ExtensionTypes.JSON,
+			},
+		},
+		{
+			maxDepth: 1,
+			want: map[string]arrow.DataType{
+				"address":   addressType,
+				"addresses": arrow.ListOf(addressType),
+				"node": arrow.StructOf(
+					arrow.Field{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+					arrow.Field{Name: "children", Type: types.ExtensionTypes.JSON, Nullable: true},
+				),
+				"custom": types.ExtensionTypes.JSON,
+				"nested": arrow.StructOf(
+					arrow.Field{Name: "address", Type: types.ExtensionTypes.JSON, Nullable: true},
+					arrow.Field{Name: "time", Type: arrow.FixedWidthTypes.Timestamp_us, Nullable: true},
+					arrow.Field{Name: "any", Type: types.ExtensionTypes.JSON, Nullable: true},
+					arrow.Field{Name: "embedded", Type: arrow.BinaryTypes.String, Nullable: true},
+				),
+			},
+		},
+		{
+			maxDepth: 2,
+			want: map[string]arrow.DataType{
+				"nested": arrow.StructOf(
+					arrow.Field{Name: "address", Type: addressType, Nullable: true},
+					arrow.Field{Name: "time", Type: arrow.FixedWidthTypes.Timestamp_us, Nullable: true},
+					arrow.Field{Name: "any", Type: types.ExtensionTypes.JSON, Nullable: true},
+					arrow.Field{Name: "embedded", Type: arrow.BinaryTypes.String, Nullable: true},
+				),
+			},
+		},
+	} {
+		table := schema.Table{Name: "test"}
+		if err := TransformWithStruct(&testArrowStruct{}, WithArrowStructs(tc.maxDepth))(&table); err != nil {
+			t.Fatal(err)
+		}
+		for name, want := range tc.want {
+			if c := table.Column(name); c == nil || !arrow.TypeEqual(c.Type, want) {
+				t.Fatalf("max depth %d: column %q: expected type %v, got %v", tc.maxDepth, name, want, c)
+			}
+		}
+	}
+}
+
+func TestResolveArrowStructs(t *testing.T) {
+	opts := []StructTransformerOption{WithArrowStructs(2)}
+	table := &schema.Table{Name: "test"}
+	if err := TransformWithStruct(&testArrowStruct{}, opts...)(table); err != nil {
+		t.Fatal(err)
+	}
+
+	zip := 12345
+	item := testArrowStruct{
+		ID:        1,
+		Address:   arrowStructAddress{City: "Berlin", Zip: &zip},
+		Addresses: []*arrowStructAddress{{City: "Paris"}, {City: "Rome"}},
+		Node:      arrowStructNode{Name: "root", Children: []arrowStructNode{{Name: "leaf"}}},
+		Custom:    arrowStructCustomJSON{Value: "custom"},
+	}
+	item.Nested.Address.City = "Oslo"
+	item.Nested.Time = time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	item.Nested.Embedded = "embedded"
+
+	resource := schema.NewResourceData(table, nil, item)
+	for _, c := range table.Columns {
+		if err := c.Resolver(context.Background(), nil, resource, c); err != nil {
+			t.Fatalf("column %s: %v", c.Name, err)
+		}
+	}
+	rec := resource.GetValues().ToArrowRecord(table.ToArrowSchema())
+	defer rec.Release()
+
+	var got []testArrowStruct
+	if err := DecodeRecord(rec, &got, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]testArrowStruct{item}, got, cmpopts.IgnoreUnexported(testArrowStruct{}.Nested)); diff != "" {
+		t.Fatalf("decoded value does not match (-want +got): %s", diff)
+	}
+}
+
+type testExactNumericStruct struct {
+	Int8Col     int8           `json:"int8_col"`
+	Int16Col    int16          `json:"int16_col"`
+	Int32Col    int32          `json:"int32_col"`
+	IntCol      int            `json:"int_col"`
+	Uint8Col    uint8          `json:"uint8_col"`
+	Uint16Col   uint16         `json:"uint16_col"`
+	Uint32Col   uint32         `json:"uint32_col"`
+	Uint64Col   uint64         `json:"uint64_col"`
+	Float32Col  float32        `json:"float32_col"`
+	Float64Col  float64        `json:"float64_col"`
+	DurationCol time.Duration  `json:"duration_col"`
+	BigIntCol   *big.Int       `json:"big_int_col"`
+	BigFloatCol *big.Float     `json:"big_float_col"`
+	BigRatCol   *big.Rat       `json:"big_rat_col"`
+	Uint64List  []uint64       `json:"uint64_list"`
+	BytesCol    []byte         `json:"bytes_col"`
+	DurationPtr *time.Duration `json:"duration_ptr"`
+}
+
+func TestTableFromGoStructWithExactNumericTypes(t *testing.T) {
+	want := map[string]arrow.DataType{
+		"int8_col":      arrow.PrimitiveTypes.Int8,
+		"int16_col":     arrow.PrimitiveTypes.Int16,
+		"int32_col":     arrow.PrimitiveTypes.Int32,
+		"int_col":       arrow.PrimitiveTypes.Int64,
+		"uint8_col":     arrow.PrimitiveTypes.Uint8,
+		"uint16_col":    arrow.PrimitiveTypes.Uint16,
+		"uint32_col":    arrow.PrimitiveTypes.Uint32,
+		"uint64_col":    arrow.PrimitiveTypes.Uint64,
+		"float32_col":   arrow.PrimitiveTypes.Float32,
+		"float64_col":   arrow.PrimitiveTypes.Float64,
+		"duration_col":  arrow.FixedWidthTypes.Duration_ns,
+		"big_int_col":   &arrow.Decimal256Type{Precision: 76, Scale: 0},
+		"big_float_col": &arrow.Decimal256Type{Precision: 76, Scale: 38},
+		"big_rat_col":   &arrow.Decimal256Type{Precision: 76, Scale: 38},
+		"uint64_list":   arrow.ListOf(arrow.PrimitiveTypes.Uint64),
+		"bytes_col":     arrow.BinaryTypes.Binary,
+		"duration_ptr":  arrow.FixedWidthTypes.Duration_ns,
+	}
+	opts := []StructTransformerOption{WithExactNumericTypes()}
+	table := &schema.Table{Name: "test"}
+	if err := TransformWithStruct(&testExactNumericStruct{}, opts...)(table); err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Columns) != len(want) {
+		t.Fatalf("expected %d columns, got %v", len(want), table.Columns.Names())
+	}
+	for name, dt := range want {
+		if c := table.Column(name); c == nil || !arrow.TypeEqual(c.Type, dt) {
+			t.Fatalf("column %q: expected type %v, got %v", name, dt, c)
+		}
+	}
+
+	bigInt, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	duration := time.Minute
+	item := testExactNumericStruct{
+		Int8Col:     math.MinInt8,
+		Int16Col:    math.MaxInt16,
+		Int32Col:    math.MinInt32,
+		IntCol:      math.MaxInt64,
+		Uint8Col:    math.MaxUint8,
+		Uint16Col:   math.MaxUint16,
+		Uint32Col:   math.MaxUint32,
+		Uint64Col:   math.MaxUint64,
+		Float32Col:  1.5,
+		Float64Col:  math.MaxFloat64,
+		DurationCol: 90 * time.Second,
+		BigIntCol:   bigInt,
+		BigFloatCol: big.NewFloat(-1234.5),
+		BigRatCol:   big.NewRat(1, 8),
+		Uint64List:  []uint64{math.MaxUint64, 0},
+		BytesCol:    []byte("bytes"),
+		DurationPtr: &duration,
+	}
+	resource := schema.NewResourceData(table, nil, item)
+	for _, c := range table.Columns {
+		if err := c.Resolver(context.Background(), nil, resource, c); err != nil {
+			t.Fatalf("column %s: %v", c.Name, err)
+		}
+	}
+	rec := resource.GetValues().ToArrowRecord(table.ToArrowSchema())
+	defer rec.Release()
+
+	var got testExactNumericStruct
+	if err := DecodeRow(rec, 0, &got, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(item, got, cmp.Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 }),
+		cmp.Comparer(func(a, b *big.Float) bool { return a.Cmp(b) == 0 }),
+		cmp.Comparer(func(a, b *big.Rat) bool { return a.Cmp(b) == 0 })); diff != "" {
+		t.Fatalf("decoded value does not match (-want +got): %s", diff)
+	}
+}
@@ -0,0 +1,226 @@
+package transformers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+)
+
+// TagName is the struct tag used to set column options, e.g.
+//
+//	type Item struct {
+//		ID      string    `cq:"pk,notnull,type=uuid"`
+//		Name    string    `cq:"name=item_name,unique"`
+//		Updated time.Time `cq:"incremental"`
+//		Secret  string    `cq:"skip"`
+//		Details Details   `cq:"unwrap"`
+//	}
+//
+// Supported options are:
+//   - pk: the column is part of the primary key
+//   - notnull: the column is not nullable
+//   - unique: the column is unique
+//   - incremental: the column is part of the incremental key
+//   - name=<name>: the column name, instead of the one returned by the name transformer
+//   - type=<type>: the column type, instead of the one returned by the type transformer (see below)
+//   - skip: the field is not added as a column (same as `cq:"-"`)
+//   - unwrap: the fields of the struct field are added as columns (see WithMaxUnwrapDepth for nested fields)
+//
+// Supported types are the arrow primitive type names (e.g. utf8, int64, float64, bool, binary, date32),
+// timestamp (microsecond precision), duration (nanosecond precision), decimal128(precision,scale), decimal256(precision,scale),
+// the extension types uuid, json, inet and mac,
+// registered extension types (see scalar.RegisterExtension) and lists of these types (e.g. list<utf8>).
+const TagName = "cq"
+
+type fieldTag struct {
+	name        string
+	typ         arrow.DataType
+	pk          bool
+	notNull     bool
+	unique      bool
+	incremental bool
+	skip        bool
+	unwrap      bool
+}
+
+var tagTypes = map[string]arrow.DataType{
+	"string":       arrow.BinaryTypes.String,
+	"utf8":         arrow.BinaryTypes.String,
+	"large_string": arrow.BinaryTypes.LargeString,
+	"large_utf8":   arrow.BinaryTypes.LargeString,
+	"binary":       arrow.BinaryTypes.Binary,
+	"large_binary": arrow.BinaryTypes.LargeBinary,
+	"bool":         arrow.FixedWidthTypes.Boolean,
+	"int8":         arrow.PrimitiveTypes.Int8,
+	"int16":        arrow.PrimitiveTypes.Int16,
+	"int32":        arrow.PrimitiveTypes.Int32,
+	"int64":        arrow.PrimitiveTypes.Int64,
+	"uint8":        arrow.PrimitiveTypes.Uint8,
+	"uint16":       arrow.PrimitiveTypes.Uint16,
+	"uint32":       arrow.PrimitiveTypes.Uint32,
+	"uint64":       arrow.PrimitiveTypes.Uint64,
+	"float32":      arrow.PrimitiveTypes.Float32,
+	"float64":      arrow.PrimitiveTypes.Float64,
+	"date32":       arrow.FixedWidthTypes.Date32,
+	"date64":       arrow.FixedWidthTypes.Date64,
+	"timestamp":    arrow.FixedWidthTypes.Timestamp_us,
+	"duration":     arrow.FixedWidthTypes.Duration_ns,
+	"uuid":         types.ExtensionTypes.UUID,
+	"json":         types.ExtensionTypes.JSON,
+	"inet":         types.ExtensionTypes.Inet,
+	"mac":          types.ExtensionTypes.MAC,
+}
+
+func parseTagType(s string) (arrow.DataType, error) {
+	if strings.HasPrefix(s, "list<") && strings.HasSuffix(s, ">") {
+		elem, err := parseTagType(s[len("list<") : len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+		return arrow.ListOf(elem), nil
+	}
+	if dt, ok := tagTypes[s]; ok {
+		return dt, nil
+	}
+	if strings.HasPrefix(s, "decimal128(") || strings.HasPrefix(s, "decimal256(") {
+		return parseTagDecimalType(s)
+	}
+	for _, ext := range scalar.Extensions() {
+		if ext.Type.ExtensionName() == s {
+			return ext.Type, nil
+		}
+	}
+	names := make([]string, 0, len(tagTypes))
+	for name := range tagTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown type %q (supported: %s, registered extension types and list<type>)", s, strings.Join(names, ", "))
+}
+
+// parseTagDecimalType parses decimal128(precision,scale) and decimal256(precision,scale)
+func parseTagDecimalType(s string) (arrow.DataType, error) {
+	var precision, scale int32
+	var bits int
+	if _, err := fmt.Sscanf(s, "decimal%d(%d,%d)", &bits, &precision, &scale); err != nil || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("invalid decimal type %q, expected decimal128(precision,scale) or decimal256(precision,scale)", s)
+	}
+	var dt arrow.DataType
+	maxPrecision := int32(38)
+	if bits == 128 {
+		dt = &arrow.Decimal128Type{Precision: precision, Scale: scale}
+	} else {
+		dt = &arrow.Decimal256Type{Precision: precision, Scale: scale}
+		maxPrecision = 76
+	}
+	if precision < 1 || precision > maxPrecision || scale < 0 || scale > precision {
+		return nil, fmt.Errorf("invalid decimal type %q: precision must be between 1 and %d, and scale between 0 and precision", s, maxPrecision)
+	}
+	return dt, nil
+}
+
+// parseFieldTag parses and validates the cq tag of the field
+func parseFieldTag(field reflect.StructField) (fieldTag, error) {
+	var tag fieldTag
+	value, ok := field.Tag.Lookup(TagName)
+	if !ok {
+		return tag, nil
+	}
+	if value == "-" {
+		tag.skip = true
+		return tag, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, opt := range splitTagOptions(value) {
+		opt = strings.TrimSpace(opt)
+		key, arg, hasArg := strings.Cut(opt, "=")
+		if seen[key] {
+			return tag, fmt.Errorf("invalid %s tag %q: duplicate option %q", TagName, value, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "pk":
+			tag.pk = true
+		case "notnull":
+			tag.notNull = true
+		case "unique":
+			tag.unique = true
+		case "incremental":
+			tag.incremental = true
+		case "skip":
+			tag.skip = true
+		case "unwrap":
+			tag.unwrap = true
+		case "name":
+			if !schema.ValidColumnName(arg) {
+				err = fmt.Errorf("invalid column name %q", arg)
+			}
+			tag.name = arg
+		case "type":
+			tag.typ, err = parseTagType(arg)
+		case "":
+			err = fmt.Errorf("empty option")
+		default:
+			err = fmt.Errorf("unknown option %q", key)
+		}
+		if err == nil && hasArg != (key == "name" || key == "type") {
+			if hasArg {
+				err = fmt.Errorf("option %q doesn't take a value", key)
+			} else {
+				err = fmt.Errorf("option %q requires a value", key)
+			}
+		}
+		if err != nil {
+			return tag, fmt.Errorf("invalid %s tag %q: %w", TagName, value, err)
+		}
+	}
+
+	switch {
+	case tag.skip && len(seen) > 1:
+		return tag, fmt.Errorf("invalid %s tag %q: skip can't be combined with other options", TagName, value)
+	case tag.unwrap && !isFieldStruct(field.Type):
+		return tag, fmt.Errorf("invalid %s tag %q: unwrap is only supported for struct fields", TagName, value)
+	case tag.unwrap && (tag.pk || tag.notNull || tag.unique || tag.incremental || tag.typ != nil):
+		return tag, fmt.Errorf("invalid %s tag %q: unwrap can only be combined with name", TagName, value)
+	}
+	return tag, nil
+}
+
+// splitTagOptions splits the tag value on commas, except for commas in type parameters (e.g. decimal128(10,2))
+func splitTagOptions(value string) []string {
+	var opts []string
+	depth, start := 0, 0
+	for i, r := range value {
+		switch r {
+		case '(', '<':
+			depth++
+		case ')', '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				opts = append(opts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(opts, value[start:])
+}
+
+// fieldTagOrZero returns the parsed tag, or the zero tag if it is invalid.
+// Invalid tags are reported by walkFields.
+func fieldTagOrZero(field reflect.StructField) fieldTag {
+	tag, err := parseFieldTag(field)
+	if err != nil {
+		return fieldTag{}
+	}
+	return tag
+}
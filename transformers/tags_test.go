@@ -0,0 +1,144 @@
+package transformers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type taggedDetails struct {
+	Region string `cq:"notnull"`
+	Zone   string `json:"zone" cq:"name=availability_zone"`
+}
+
+type taggedStruct struct {
+	ID       string        `cq:"pk,notnull,type=uuid"`
+	Name     string        `json:"display_name" cq:"name=item_name,unique"`
+	Updated  time.Time     `cq:"incremental"`
+	Tags     []string      `cq:"type=list<utf8>"`
+	Count    int           `cq:"type=int32"`
+	Price    string        `cq:"type=decimal128(10,2),notnull"`
+	Prices   []string      `cq:"type=list<decimal256(40,4)>"`
+	Timeout  int64         `cq:"type=duration"`
+	Secret   string        `cq:"skip"`
+	Hidden   string        `cq:"-"`
+	Ignored  string        `json:"-" cq:"name=not_ignored"`
+	Details  taggedDetails `cq:"unwrap,name=info"`
+	Embedded taggedDetails
+}
+
+func TestTransformWithStructTags(t *testing.T) {
+	table := schema.Table{Name: "test_tags"}
+	require.NoError(t, TransformWithStruct(&taggedStruct{})(&table))
+
+	expected := schema.ColumnList{
+		{Name: "id", Type: types.ExtensionTypes.UUID, PrimaryKey: true, NotNull: true},
+		{Name: "item_name", Type: arrow.BinaryTypes.String, Unique: true},
+		{Name: "updated", Type: arrow.FixedWidthTypes.Timestamp_us, IncrementalKey: true},
+		{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String)},
+		{Name: "count", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "price", Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}, NotNull: true},
+		{Name: "prices", Type: arrow.ListOf(&arrow.Decimal256Type{Precision: 40, Scale: 4})},
+		{Name: "timeout", Type: arrow.FixedWidthTypes.Duration_ns},
+		{Name: "not_ignored", Type: arrow.BinaryTypes.String},
+		{Name: "info_region", Type: arrow.BinaryTypes.String, NotNull: true},
+		{Name: "info_availability_zone", Type: arrow.BinaryTypes.String},
+		{Name: "embedded", Type: types.ExtensionTypes.JSON},
+	}
+	require.Len(t, table.Columns, len(expected), "columns: %v", table.Columns.Names())
+	for i, c := range table.Columns {
+		want := expected[i]
+		assert.Equal(t, want.Name, c.Name)
+		assert.Truef(t, arrow.TypeEqual(want.Type, c.Type), "column %s: expected type %s, got %s", c.Name, want.Type, c.Type)
+		assert.Equal(t, want.PrimaryKey, c.PrimaryKey, c.Name)
+		assert.Equal(t, want.NotNull, c.NotNull, c.Name)
+		assert.Equal(t, want.Unique, c.Unique, c.Name)
+		assert.Equal(t, want.IncrementalKey, c.IncrementalKey, c.Name)
+	}
+	assert.Equal(t, []string{"id"}, table.PrimaryKeys())
+}
+
+func TestTransformWithStructTagsSkipEmbedded(t *testing.T) {
+	type item struct {
+		ID              int
+		*embeddedStruct `cq:"skip"`
+	}
+	table := schema.Table{Name: "test_tags"}
+	require.NoError(t, TransformWithStruct(&item{}, WithUnwrapAllEmbeddedStructs())(&table))
+	assert.Equal(t, []string{"id"}, table.Columns.Names())
+}
+
+func TestTransformWithStructTagErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		st     any
+		errMsg string
+	}{
+		{name: "unknown option", st: struct {
+			A string `cq:"primary"`
+		}{}, errMsg: `unknown option "primary"`},
+		{name: "duplicate option", st: struct {
+			A string `cq:"pk,pk"`
+		}{}, errMsg: `duplicate option "pk"`},
+		{name: "empty option", st: struct {
+			A string `cq:"pk,,notnull"`
+		}{}, errMsg: "empty option"},
+		{name: "invalid name", st: struct {
+			A string `cq:"name=Not-Valid"`
+		}{}, errMsg: `invalid column name "Not-Valid"`},
+		{name: "missing name", st: struct {
+			A string `cq:"name"`
+		}{}, errMsg: `invalid column name ""`},
+		{name: "missing type", st: struct {
+			A string `cq:"type"`
+		}{}, errMsg: `unknown type ""`},
+		{name: "unknown type", st: struct {
+			A string `cq:"type=varchar"`
+		}{}, errMsg: `unknown type "varchar"`},
+		{name: "unknown list type", st: struct {
+			A []string `cq:"type=list<varchar>"`
+		}{}, errMsg: `unknown type "varchar"`},
+		{name: "invalid decimal type", st: struct {
+			A string `cq:"type=decimal128(40,2)"`
+		}{}, errMsg: `invalid decimal type "decimal128(40,2)"`},
+		{name: "malformed decimal type", st: struct {
+			A string `cq:"type=decimal128(10)"`
+		}{}, errMsg: `invalid decimal type "decimal128(10)"`},
+		{name: "option with value", st: struct {
+			A string `cq:"pk=true"`
+		}{}, errMsg: `option "pk" doesn't take a value`},
+		{name: "skip with other options", st: struct {
+			A string `cq:"skip,pk"`
+		}{}, errMsg: "skip can't be combined with other options"},
+		{name: "unwrap non-struct", st: struct {
+			A string `cq:"unwrap"`
+		}{}, errMsg: "unwrap is only supported for struct fields"},
+		{name: "unwrap with pk", st: struct {
+			A taggedDetails `cq:"unwrap,pk"`
+		}{}, errMsg: "unwrap can only be combined with name"},
+		{name: "nested unwrap", st: struct {
+			A struct {
+				B taggedDetails `cq:"unwrap"`
+			} `cq:"unwrap"`
+		}{}, errMsg: "unwrap exceeds the maximum unwrap depth of 1"},
+		{name: "invalid tag in unwrapped struct", st: struct {
+			A struct {
+				B string `cq:"bogus"`
+			} `cq:"unwrap"`
+		}{}, errMsg: "failed to add column from field B"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			table := schema.Table{Name: "test_tags"}
+			err := TransformWithStruct(tc.st)(&table)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
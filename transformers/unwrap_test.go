@@ -0,0 +1,180 @@
+package transformers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/cloudquery/plugin-sdk/v4/scalar"
+	"github.com/cloudquery/plugin-sdk/v4/schema"
+	"github.com/cloudquery/plugin-sdk/v4/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unwrapLocation struct {
+	Region string
+	Zone   string
+}
+
+type unwrapSpec struct {
+	Location *unwrapLocation
+	Size     int
+}
+
+type UnwrapMeta struct {
+	Owner string
+}
+
+type unwrapResource struct {
+	ID      string
+	Spec    unwrapSpec
+	Created time.Time
+	*UnwrapMeta
+	Labels struct {
+		Env string
+	}
+}
+
+func transformUnwrapResource(t *testing.T, opts ...StructTransformerOption) *schema.Table {
+	t.Helper()
+	table := &schema.Table{Name: "test_unwrap"}
+	require.NoError(t, TransformWithStruct(&unwrapResource{}, opts...)(table))
+	return table
+}
+
+func TestTransformWithStructUnwrapDepth(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []StructTransformerOption
+		columns []string
+	}{
+		{
+			name:    "default depth",
+			opts:    []StructTransformerOption{WithUnwrapAllStructFields()},
+			columns: []string{"id", "spec_location", "spec_size", "created", "owner", "labels_env"},
+		},
+		{
+			name:    "recursive",
+			opts:    []StructTransformerOption{WithUnwrapAllStructFields(), WithMaxUnwrapDepth(2)},
+			columns: []string{"id", "spec_location_region", "spec_location_zone", "spec_size", "created", "owner", "labels_env"},
+		},
+		{
+			name:    "opt out",
+			opts:    []StructTransformerOption{WithUnwrapAllStructFields(), WithMaxUnwrapDepth(2), WithoutUnwrapStructFields("Spec.Location", "Labels")},
+			columns: []string{"id", "spec_location", "spec_size", "created", "owner", "labels"},
+		},
+		{
+			name:    "opt in by path",
+			opts:    []StructTransformerOption{WithUnwrapStructFields("Spec", "Spec.Location"), WithMaxUnwrapDepth(2)},
+			columns: []string{"id", "spec_location_region", "spec_location_zone", "spec_size", "created", "unwrap_meta", "labels"},
+		},
+		{
+			name:    "opt in by path beyond max depth",
+			opts:    []StructTransformerOption{WithUnwrapStructFields("Spec", "Spec.Location")},
+			columns: []string{"id", "spec_location", "spec_size", "created", "unwrap_meta", "labels"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			table := transformUnwrapResource(t, tc.opts...)
+			assert.Equal(t, tc.columns, table.Columns.Names())
+		})
+	}
+
+	table := transformUnwrapResource(t, WithUnwrapAllStructFields(), WithMaxUnwrapDepth(2))
+	assert.True(t, arrow.TypeEqual(arrow.FixedWidthTypes.Timestamp_us, table.Column("created").Type))
+	assert.True(t, arrow.TypeEqual(arrow.PrimitiveTypes.Int64, table.Column("spec_size").Type))
+	table = transformUnwrapResource(t, WithUnwrapAllStructFields())
+	assert.True(t, arrow.TypeEqual(types.ExtensionTypes.JSON, table.Column("spec_location").Type))
+}
+
+func TestTransformWithStructUnwrapResolvers(t *testing.T) {
+	opts := []StructTransformerOption{WithUnwrapAllStructFields(), WithMaxUnwrapDepth(2), WithPrimaryKeys("Spec.Location.Region")}
+	table := transformUnwrapResource(t, opts...)
+	assert.Equal(t, []string{"spec_location_region"}, table.PrimaryKeys())
+
+	items := []unwrapResource{
+		{ID: "a", Spec: unwrapSpec{Location: &unwrapLocation{Region: "us-east-1", Zone: "us-east-1a"}, Size: 3}, UnwrapMeta: &UnwrapMeta{Owner: "me"}},
+		{ID: "b", Spec: unwrapSpec{Size: 1}, UnwrapMeta: &UnwrapMeta{}},
+	}
+	bldr := array.NewRecordBuilder(memory.DefaultAllocator, table.ToArrowSchema())
+	defer bldr.Release()
+	for _, item := range items {
+		resource := schema.NewResourceData(table, nil, item)
+		for _, c := range table.Columns {
+			require.NoError(t, c.Resolver(context.Background(), nil, resource, c), c.Name)
+		}
+		scalar.AppendToRecordBuilder(bldr, resource.GetValues())
+	}
+	rec := bldr.NewRecord()
+	defer rec.Release()
+
+	assert.Equal(t, "us-east-1", rec.Column(table.Columns.Index("spec_location_region")).ValueStr(0))
+	assert.Equal(t, "us-east-1a", rec.Column(table.Columns.Index("spec_location_zone")).ValueStr(0))
+	// like PathResolver for a single level, nil pointers along the path resolve to the zero value
+	assert.Equal(t, "", rec.Column(table.Columns.Index("spec_location_region")).ValueStr(1))
+
+	var got []unwrapResource
+	require.NoError(t, DecodeRecord(rec, &got, opts...))
+	require.Len(t, got, 2)
+	assert.Equal(t, items[0].Spec, got[0].Spec)
+	assert.Equal(t, items[0].Owner, got[0].Owner)
+	assert.Equal(t, &unwrapLocation{}, got[1].Spec.Location)
+	assert.Equal(t, 1, got[1].Spec.Size)
+}
+
+func TestTransformWithStructUnwrapCollision(t *testing.T) {
+	type item struct {
+		SpecSize int
+		Spec     struct {
+			Size int
+		}
+	}
+	table := &schema.Table{Name: "test_unwrap"}
+	err := TransformWithStruct(&item{}, WithUnwrapStructFields("Spec"))(table)
+	require.ErrorContains(t, err, `column name "spec_size" of field Spec.Size collides with the column of field SpecSize`)
+
+	table = &schema.Table{Name: "test_unwrap"}
+	err = TransformWithStruct(&item{}, WithUnwrapStructFields("Spec"), WithMaxUnwrapDepth(2))(table)
+	require.ErrorContains(t, err, `column name "spec_size" of field Spec.Size collides with the column of field SpecSize`)
+
+	// fields of embedded structs resulting in the same column name as a different field collide at the default depth too
+	type sized struct {
+		Size int
+	}
+	type embedded struct {
+		Length int `json:"size"`
+		sized
+	}
+	table = &schema.Table{Name: "test_unwrap"}
+	err = TransformWithStruct(&embedded{}, WithUnwrapAllEmbeddedStructs())(table)
+	require.ErrorContains(t, err, `column name "size" of field Size collides with the column of field Length`)
+
+	// fields of embedded structs shadowed by the outer struct aren't collisions
+	type shadowed struct {
+		IntCol int
+		*embeddedStruct
+	}
+	table = &schema.Table{Name: "test_unwrap"}
+	require.NoError(t, TransformWithStruct(&shadowed{}, WithUnwrapAllEmbeddedStructs())(table))
+	assert.Equal(t, []string{"int_col", "embedded_string"}, table.Columns.Names())
+}
+
+func TestTransformWithStructUnwrapTags(t *testing.T) {
+	type item struct {
+		Outer struct {
+			Inner taggedDetails `cq:"unwrap,name=in"`
+			Kept  struct {
+				A string
+			}
+		} `cq:"unwrap"`
+	}
+	table := &schema.Table{Name: "test_unwrap"}
+	require.NoError(t, TransformWithStruct(&item{}, WithMaxUnwrapDepth(2))(table))
+	assert.Equal(t, []string{"outer_in_region", "outer_in_availability_zone", "outer_kept"}, table.Columns.Names())
+}
@@ -1,29 +1,124 @@
 package types
 
-import "github.com/apache/arrow/go/v13/arrow"
+import (
+	"fmt"
+	"sort"
+	"sync"
 
-func RegisterAllExtensions() error {
-	if err := arrow.RegisterExtensionType(&UUIDType{}); err != nil {
-		return err
+	"github.com/apache/arrow/go/v13/arrow"
+)
+
+var (
+	customExtensionsMu sync.RWMutex
+	customExtensions   = make(map[string]arrow.ExtensionType)
+	// registered is set by RegisterAllExtensions, so custom extensions added afterwards are registered with arrow too
+	registered bool
+	// arrowRegistered has the names of the extension types registered with arrow by this package
+	arrowRegistered = make(map[string]bool)
+)
+
+func builtinExtensions() []arrow.ExtensionType {
+	return []arrow.ExtensionType{&UUIDType{}, &JSONType{}, &InetType{}, &MACType{}}
+}
+
+// AddCustomExtension adds a plugin-defined extension type to the types registered by RegisterAllExtensions,
+// and registers it with arrow if RegisterAllExtensions was already called.
+// Plugins should normally use scalar.RegisterExtension, which calls this.
+func AddCustomExtension(typ arrow.ExtensionType) error {
+	name := typ.ExtensionName()
+	for _, builtin := range builtinExtensions() {
+		if builtin.ExtensionName() == name {
+			return fmt.Errorf("extension type %s is a built-in type", name)
+		}
 	}
-	if err := arrow.RegisterExtensionType(&JSONType{}); err != nil {
-		return err
+	customExtensionsMu.Lock()
+	defer customExtensionsMu.Unlock()
+	if _, ok := customExtensions[name]; ok {
+		return fmt.Errorf("extension type %s is already registered", name)
 	}
-	if err := arrow.RegisterExtensionType(&InetType{}); err != nil {
-		return err
+	if registered {
+		if err := registerWithArrow(typ); err != nil {
+			return err
+		}
+	}
+	customExtensions[name] = typ
+	return nil
+}
+
+// RemoveCustomExtension removes a plugin-defined extension type added with AddCustomExtension,
+// unregistering it from arrow if it was registered
+func RemoveCustomExtension(name string) error {
+	customExtensionsMu.Lock()
+	defer customExtensionsMu.Unlock()
+	if _, ok := customExtensions[name]; !ok {
+		return nil
+	}
+	delete(customExtensions, name)
+	return unregisterFromArrow(name)
+}
+
+// CustomExtensions returns the plugin-defined extension types, sorted by extension name
+func CustomExtensions() []arrow.ExtensionType {
+	customExtensionsMu.RLock()
+	defer customExtensionsMu.RUnlock()
+	return customExtensionsLocked()
+}
+
+func customExtensionsLocked() []arrow.ExtensionType {
+	exts := make([]arrow.ExtensionType, 0, len(customExtensions))
+	for _, typ := range customExtensions {
+		exts = append(exts, typ)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		return exts[i].ExtensionName() < exts[j].ExtensionName()
+	})
+	return exts
+}
+
+// RegisterAllExtensions registers the built-in and plugin-defined extension types with arrow.
+// Plugin-defined extension types added afterwards are registered when they're added.
+func RegisterAllExtensions() error {
+	customExtensionsMu.Lock()
+	defer customExtensionsMu.Unlock()
+	for _, typ := range append(builtinExtensions(), customExtensionsLocked()...) {
+		if err := registerWithArrow(typ); err != nil {
+			return err
+		}
 	}
-	return arrow.RegisterExtensionType(&MACType{})
+	registered = true
+	return nil
 }
 
+// UnregisterAllExtensions unregisters the extension types registered with arrow by RegisterAllExtensions and AddCustomExtension.
 func UnregisterAllExtensions() error {
-	if err := arrow.UnregisterExtensionType(ExtensionTypes.MAC.ExtensionName()); err != nil {
-		return err
+	customExtensionsMu.Lock()
+	defer customExtensionsMu.Unlock()
+	registered = false
+	exts := append(builtinExtensions(), customExtensionsLocked()...)
+	for i := len(exts) - 1; i >= 0; i-- {
+		if err := unregisterFromArrow(exts[i].ExtensionName()); err != nil {
+			return err
+		}
 	}
-	if err := arrow.UnregisterExtensionType(ExtensionTypes.Inet.ExtensionName()); err != nil {
+	return nil
+}
+
+func registerWithArrow(typ arrow.ExtensionType) error {
+	if err := arrow.RegisterExtensionType(typ); err != nil {
 		return err
 	}
-	if err := arrow.UnregisterExtensionType(ExtensionTypes.JSON.ExtensionName()); err != nil {
+	arrowRegistered[typ.ExtensionName()] = true
+	return nil
+}
+
+// unregisterFromArrow unregisters the extension type from arrow, unless it wasn't registered by this package
+func unregisterFromArrow(name string) error {
+	if !arrowRegistered[name] {
+		return nil
+	}
+	if err := arrow.UnregisterExtensionType(name); err != nil {
 		return err
 	}
-	return arrow.UnregisterExtensionType(ExtensionTypes.UUID.ExtensionName())
+	delete(arrowRegistered, name)
+	return nil
 }